@@ -0,0 +1,435 @@
+// mini-projects/cmd/productbench/main.go
+//
+// productbench adalah alat uji beban untuk API Produk. Secara default alat ini menjalankan
+// handler API di dalam proses yang sama (dengan data di direktori sementara), atau bisa
+// diarahkan ke server yang sudah berjalan dengan flag -url.
+//
+// Contoh:
+//
+//	go run ./cmd/productbench -duration 15s -rps 500 -workers 32 -mix get=60,list=10,post=15,put=10,delete=5
+//	go run ./cmd/productbench -url http://localhost:8080 -rps 100
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mini-projects/product_service"
+)
+
+// Jenis operasi yang bisa dicampur dalam uji beban.
+const (
+	opGet    = "get"    // GET /api/products/{id}
+	opList   = "list"   // GET /api/products
+	opPost   = "post"   // POST /api/products
+	opPut    = "put"    // PUT /api/products/{id}
+	opDelete = "delete" // DELETE /api/products/{id}
+	opSearch = "search" // GET /api/products/search
+)
+
+var allOps = []string{opGet, opList, opPost, opPut, opDelete, opSearch}
+
+// maxRPS adalah laju tertinggi yang bisa dijadwalkan: di atasnya interval tiket menjadi
+// 0 dan time.NewTicker panik.
+const maxRPS = int(time.Second)
+
+func main() {
+	os.Exit(run())
+}
+
+// run menjalankan uji beban dan mengembalikan kode keluar. Dipisah dari main agar
+// semua defer (server dan direktori data sementara) dijalankan sebelum os.Exit.
+func run() int {
+	targetURL := flag.String("url", "", "URL server API Produk; kosong berarti handler dijalankan di dalam proses")
+	duration := flag.Duration("duration", 10*time.Second, "lama uji beban")
+	rps := flag.Int("rps", 200, "target permintaan per detik (0 = secepat mungkin)")
+	workers := flag.Int("workers", 16, "jumlah goroutine pekerja")
+	mixFlag := flag.String("mix", "get=60,list=10,post=15,put=10,delete=5", "komposisi operasi (get, list, post, put, delete, search)")
+	seed := flag.Int("seed-products", 100, "jumlah produk awal yang dibuat sebelum uji dimulai")
+	tenant := flag.String("tenant", "", "nilai header X-Tenant-ID (opsional; tenant selain default butuh -api-key)")
+	apiKey := flag.String("api-key", "", "nilai header X-API-Key (opsional)")
+	verbose := flag.Bool("v", false, "tampilkan log server saat berjalan di dalam proses")
+	flag.Parse()
+
+	mix, err := parseMix(*mixFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *rps < 0 || *rps > maxRPS {
+		fmt.Fprintf(os.Stderr, "Error: -rps harus antara 0 dan %d\n", maxRPS)
+		return 2
+	}
+	if *workers <= 0 {
+		*workers = 1
+	}
+
+	baseURL := strings.TrimRight(*targetURL, "/")
+	if baseURL == "" {
+		if !*verbose {
+			log.SetOutput(io.Discard) // Log per permintaan akan mendominasi waktu dan output.
+		}
+		dataDir, err := os.MkdirTemp("", "productbench-")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: gagal membuat direktori data sementara: %v\n", err)
+			return 1
+		}
+		defer os.RemoveAll(dataDir)
+		handler, err := product_service.NewHandler(product_service.Config{DataDir: dataDir})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		baseURL = server.URL
+		fmt.Printf("Handler API Produk berjalan di dalam proses: %s (data: %s)\n", baseURL, dataDir)
+	}
+
+	b := &bench{
+		baseURL: baseURL,
+		tenant:  *tenant,
+		apiKey:  *apiKey,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{MaxIdleConnsPerHost: *workers},
+		},
+		stats: make(map[string]*opStats),
+	}
+	for _, op := range allOps {
+		b.stats[op] = &opStats{}
+	}
+
+	fmt.Printf("Menyiapkan %d produk awal...\n", *seed)
+	for i := 0; i < *seed; i++ {
+		if _, err := b.createProduct(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: gagal membuat produk awal: %v\n", err)
+			return 1
+		}
+	}
+
+	fmt.Printf("Uji beban: %s, target %s, %d pekerja, komposisi %s\n", *duration, rpsLabel(*rps), *workers, *mixFlag)
+	elapsed := b.run(*duration, *rps, *workers, mix)
+	b.report(elapsed)
+	return 0
+}
+
+func rpsLabel(rps int) string {
+	if rps <= 0 {
+		return "tanpa batas"
+	}
+	return fmt.Sprintf("%d req/s", rps)
+}
+
+// weightedOp adalah satu operasi dengan bobot kumulatif untuk pemilihan acak.
+type weightedOp struct {
+	name       string
+	cumulative int
+}
+
+// parseMix mengurai "get=60,post=20" menjadi daftar operasi berbobot.
+func parseMix(s string) ([]weightedOp, error) {
+	var mix []weightedOp
+	total := 0
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, weightStr, ok := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if !ok || err != nil || weight < 0 {
+			return nil, fmt.Errorf("komposisi '%s' tidak valid, gunakan format op=bobot", part)
+		}
+		known := false
+		for _, op := range allOps {
+			known = known || op == name
+		}
+		if !known {
+			return nil, fmt.Errorf("operasi '%s' tidak dikenal", name)
+		}
+		if weight == 0 {
+			continue
+		}
+		total += weight
+		mix = append(mix, weightedOp{name: name, cumulative: total})
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("komposisi operasi kosong")
+	}
+	return mix, nil
+}
+
+func pickOp(mix []weightedOp, rng *rand.Rand) string {
+	n := rng.Intn(mix[len(mix)-1].cumulative)
+	for _, op := range mix {
+		if n < op.cumulative {
+			return op.name
+		}
+	}
+	return mix[len(mix)-1].name
+}
+
+// opStats mengumpulkan latensi dan error untuk satu jenis operasi.
+type opStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    int
+}
+
+func (s *opStats) record(d time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies = append(s.latencies, d)
+	if failed {
+		s.errors++
+	}
+}
+
+type bench struct {
+	baseURL string
+	tenant  string
+	apiKey  string
+	client  *http.Client
+	stats   map[string]*opStats
+
+	idsMu sync.Mutex
+	ids   []int // ID produk yang diketahui masih ada
+
+	missed int // Jadwal permintaan yang terlewat karena semua pekerja sibuk
+}
+
+func (b *bench) addID(id int) {
+	b.idsMu.Lock()
+	b.ids = append(b.ids, id)
+	b.idsMu.Unlock()
+}
+
+// randomID memilih ID yang ada; jika take true, ID dikeluarkan dari daftar (untuk DELETE).
+func (b *bench) randomID(rng *rand.Rand, take bool) (int, bool) {
+	b.idsMu.Lock()
+	defer b.idsMu.Unlock()
+	if len(b.ids) == 0 {
+		return 0, false
+	}
+	i := rng.Intn(len(b.ids))
+	id := b.ids[i]
+	if take {
+		b.ids[i] = b.ids[len(b.ids)-1]
+		b.ids = b.ids[:len(b.ids)-1]
+	}
+	return id, true
+}
+
+func (b *bench) do(method, path string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, b.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.tenant != "" {
+		req.Header.Set("X-Tenant-ID", b.tenant)
+	}
+	if b.apiKey != "" {
+		req.Header.Set("X-API-Key", b.apiKey)
+	}
+	return b.client.Do(req)
+}
+
+func (b *bench) createProduct() (int, error) {
+	body := []byte(`{"name": "Produk Uji Beban", "price": 150000, "stock": 10, "tags": ["bench"]}`)
+	resp, err := b.do("POST", "/api/products", body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		io.Copy(io.Discard, resp.Body)
+		return 0, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var p product_service.Product
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return 0, err
+	}
+	b.addID(p.ID)
+	return p.ID, nil
+}
+
+// execute menjalankan satu operasi dan mencatat latensinya.
+func (b *bench) execute(op string, rng *rand.Rand) {
+	start := time.Now()
+	failed := false
+	switch op {
+	case opPost:
+		_, err := b.createProduct()
+		failed = err != nil
+	case opList:
+		failed = !b.expect("GET", "/api/products", nil, http.StatusOK, false)
+	case opSearch:
+		failed = !b.expect("GET", "/api/products/search?q=produk+uji", nil, http.StatusOK, false)
+	case opGet, opPut, opDelete:
+		id, ok := b.randomID(rng, op == opDelete)
+		if !ok {
+			// Katalog kosong: isi kembali agar operasi berikutnya punya target.
+			_, err := b.createProduct()
+			failed = err != nil
+			break
+		}
+		path := fmt.Sprintf("/api/products/%d", id)
+		switch op {
+		case opGet:
+			failed = !b.expect("GET", path, nil, http.StatusOK, true)
+		case opPut:
+			body := []byte(fmt.Sprintf(`{"name": "Produk Diperbarui", "price": %d, "stock": %d}`, 1000+rng.Intn(1000), rng.Intn(100)))
+			failed = !b.expect("PUT", path, body, http.StatusOK, true)
+		case opDelete:
+			failed = !b.expect("DELETE", path, nil, http.StatusNoContent, false)
+		}
+	}
+	b.stats[op].record(time.Since(start), failed)
+}
+
+// expect mengirim permintaan dan mengembalikan true jika status sesuai harapan.
+// allowNotFound dipakai untuk GET/PUT per ID: produk bisa saja baru dihapus pekerja lain.
+func (b *bench) expect(method, path string, body []byte, want int, allowNotFound bool) bool {
+	resp, err := b.do(method, path, body)
+	if err != nil {
+		return false
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode == want || (allowNotFound && resp.StatusCode == http.StatusNotFound)
+}
+
+// run menjalankan pekerja selama durasi yang ditentukan. Jika rps > 0, penjadwal mengirim
+// "tiket" dengan laju tetap; tiket yang tidak bisa diambil karena semua pekerja sibuk
+// dihitung sebagai terlewat agar laju yang tidak tercapai terlihat di laporan.
+func (b *bench) run(duration time.Duration, rps, workers int, mix []weightedOp) time.Duration {
+	tickets := make(chan struct{}, workers)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for {
+				if rps > 0 {
+					if _, ok := <-tickets; !ok {
+						return
+					}
+				} else {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+				}
+				b.execute(pickOp(mix, rng), rng)
+			}
+		}(time.Now().UnixNano() + int64(w))
+	}
+
+	start := time.Now()
+	if rps > 0 {
+		interval := time.Second / time.Duration(rps)
+		ticker := time.NewTicker(interval)
+		deadline := time.After(duration)
+	loop:
+		for {
+			select {
+			case <-deadline:
+				break loop
+			case <-ticker.C:
+				select {
+				case tickets <- struct{}{}:
+				default:
+					b.missed++
+				}
+			}
+		}
+		ticker.Stop()
+		close(tickets)
+	} else {
+		time.Sleep(duration)
+		close(stop)
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+// percentile mengembalikan nilai persentil p (0-100) dari latensi yang sudah terurut.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	i = max(0, min(i, len(sorted)-1))
+	return sorted[i]
+}
+
+func (b *bench) report(elapsed time.Duration) {
+	var all []time.Duration
+	totalErrors := 0
+
+	fmt.Println("\n--- Hasil Uji Beban ---")
+	fmt.Printf("%-8s %9s %8s %10s %10s %10s %10s\n", "OPERASI", "JUMLAH", "ERROR", "P50", "P95", "P99", "MAKS")
+	for _, op := range allOps {
+		s := b.stats[op]
+		if len(s.latencies) == 0 {
+			continue
+		}
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		all = append(all, s.latencies...)
+		totalErrors += s.errors
+		fmt.Printf("%-8s %9d %8d %10s %10s %10s %10s\n", op, len(s.latencies), s.errors,
+			roundDuration(percentile(s.latencies, 50)), roundDuration(percentile(s.latencies, 95)),
+			roundDuration(percentile(s.latencies, 99)), roundDuration(s.latencies[len(s.latencies)-1]))
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+
+	fmt.Printf("\nTotal permintaan : %d dalam %s\n", len(all), roundDuration(elapsed))
+	fmt.Printf("Throughput       : %.1f req/s\n", float64(len(all))/elapsed.Seconds())
+	fmt.Printf("Latensi          : p50 %s, p95 %s, p99 %s\n",
+		roundDuration(percentile(all, 50)), roundDuration(percentile(all, 95)), roundDuration(percentile(all, 99)))
+	fmt.Printf("Error            : %d", totalErrors)
+	if len(all) > 0 {
+		fmt.Printf(" (%.2f%%)", float64(totalErrors)*100/float64(len(all)))
+	}
+	fmt.Println()
+	if b.missed > 0 {
+		fmt.Printf("Terlewat         : %d permintaan (semua pekerja sibuk; tambah -workers)\n", b.missed)
+	}
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
@@ -0,0 +1,91 @@
+// mini-projects/cmd/productbench/main_test.go
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []weightedOp
+	}{
+		{"get=60,post=40", []weightedOp{{opGet, 60}, {opPost, 100}}},
+		{" GET = 3 , search=1 ,", []weightedOp{{opGet, 3}, {opSearch, 4}}},
+		{"get=0,list=5,delete=0", []weightedOp{{opList, 5}}}, // Bobot 0 dilewati
+	}
+	for _, tt := range tests {
+		got, err := parseMix(tt.in)
+		if err != nil {
+			t.Errorf("parseMix(%q): %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseMix(%q) = %v, ingin %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", ",", "get", "get=", "get=x", "get=-1", "fetch=5", "get=0,post=0"} {
+		if _, err := parseMix(in); err == nil {
+			t.Errorf("parseMix(%q) seharusnya ditolak", in)
+		}
+	}
+}
+
+func TestPickOpFollowsWeights(t *testing.T) {
+	mix, err := parseMix("get=3,list=0,post=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	counts := make(map[string]int)
+	for range 4000 {
+		counts[pickOp(mix, rng)]++
+	}
+	if counts[opList] != 0 || len(counts) != 2 {
+		t.Fatalf("operasi yang dipilih tidak sesuai komposisi: %v", counts)
+	}
+	// Perbandingan 3:1, dengan toleransi untuk keacakan.
+	if ratio := float64(counts[opGet]) / float64(counts[opPost]); ratio < 2.5 || ratio > 3.5 {
+		t.Errorf("rasio get/post %.2f, ingin sekitar 3 (%v)", ratio, counts)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	ms := func(values ...int) []time.Duration {
+		out := make([]time.Duration, len(values))
+		for i, v := range values {
+			out[i] = time.Duration(v) * time.Millisecond
+		}
+		return out
+	}
+	hundred := make([]int, 100)
+	for i := range hundred {
+		hundred[i] = i + 1
+	}
+
+	tests := []struct {
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{nil, 50, 0},
+		{ms(7), 0, 7 * time.Millisecond},
+		{ms(7), 99, 7 * time.Millisecond},
+		{ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 0, 1 * time.Millisecond}, // Tidak pernah di bawah indeks 0
+		{ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 50, 5 * time.Millisecond},
+		{ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 95, 10 * time.Millisecond},
+		{ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 100, 10 * time.Millisecond},
+		{ms(hundred...), 50, 50 * time.Millisecond},
+		{ms(hundred...), 95, 95 * time.Millisecond},
+		{ms(hundred...), 99, 99 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%d nilai, p%v) = %s, ingin %s", len(tt.sorted), tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,427 @@
+// mini-projects/downloader-app/checksum.go
+package parallel_downloader_app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// errChecksumMismatch dikembalikan jika digest file hasil download tidak sama dengan yang diharapkan.
+var errChecksumMismatch = errors.New("checksum tidak cocok")
+
+// Tindakan terhadap file output jika checksum tidak cocok.
+const (
+	MismatchQuarantine = "quarantine" // Ganti nama menjadi <output>.corrupt (default)
+	MismatchDelete     = "delete"     // Hapus file output
+)
+
+// newHashFuncs memetakan nama algoritma ke konstruktor hash-nya.
+var newHashFuncs = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// algoByHexLength menebak algoritma dari panjang digest dalam heksadesimal.
+var algoByHexLength = map[int]string{32: "md5", 40: "sha1", 64: "sha256", 128: "sha512"}
+
+// expectedDigest adalah satu digest yang harus cocok dengan file hasil download.
+type expectedDigest struct {
+	Algo   string // md5, sha1, sha256, atau sha512
+	Sum    []byte
+	Source string // Asal digest, untuk pesan error (misalnya "header Digest")
+}
+
+// normalizeAlgo menyeragamkan penulisan nama algoritma ("SHA-256", "sha256" -> "sha256").
+// Di header Digest (RFC 3230), "SHA" berarti SHA-1.
+func normalizeAlgo(name string) string {
+	a := strings.ToLower(strings.ReplaceAll(name, "-", ""))
+	if a == "sha" {
+		a = "sha1"
+	}
+	return a
+}
+
+var hexDigestPattern = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// parseHexDigest membaca digest heksadesimal. Jika algo kosong, algoritma ditebak dari panjangnya.
+func parseHexDigest(algo, value, source string) (expectedDigest, error) {
+	value = strings.TrimSpace(value)
+	if !hexDigestPattern.MatchString(value) {
+		return expectedDigest{}, fmt.Errorf("checksum '%s' bukan heksadesimal", value)
+	}
+	if algo == "" {
+		algo = algoByHexLength[len(value)]
+		if algo == "" {
+			return expectedDigest{}, fmt.Errorf("panjang checksum %d karakter tidak dikenali", len(value))
+		}
+	}
+	algo = normalizeAlgo(algo)
+	newHash, ok := newHashFuncs[algo]
+	if !ok {
+		return expectedDigest{}, fmt.Errorf("algoritma checksum '%s' tidak didukung", algo)
+	}
+	sum, _ := hex.DecodeString(value)
+	if len(sum) != newHash().Size() {
+		return expectedDigest{}, fmt.Errorf("panjang checksum %s harus %d karakter", algo, newHash().Size()*2)
+	}
+	return expectedDigest{Algo: algo, Sum: sum, Source: source}, nil
+}
+
+// resolveExpectedDigest mengubah nilai checksum dari pengguna menjadi digest yang diharapkan.
+// Format yang diterima:
+//   - "sha256:<hex>", "sha1:<hex>", "md5:<hex>" (atau "sha512:<hex>")
+//   - "<hex>" saja, algoritma ditebak dari panjangnya
+//   - URL atau path file checksum (format sha256sum/md5sum atau gaya BSD "SHA256 (nama) = hex")
+//
+// Nama output dan URL dari 'cfg' dipakai untuk memilih baris yang tepat di file checksum.
+func resolveExpectedDigest(ctx context.Context, cfg downloadConfig) (*expectedDigest, error) {
+	value := strings.TrimSpace(cfg.Checksum)
+	if value == "" {
+		return nil, nil
+	}
+	if strings.Contains(value, "://") {
+		return loadChecksumFile(ctx, value, cfg)
+	}
+	if algo, sum, ok := strings.Cut(value, ":"); ok && newHashFuncs[normalizeAlgo(algo)] != nil {
+		d, err := parseHexDigest(algo, sum, "checksum")
+		return &d, err
+	}
+	if hexDigestPattern.MatchString(value) {
+		d, err := parseHexDigest("", value, "checksum")
+		return &d, err
+	}
+	return loadChecksumFile(ctx, value, cfg)
+}
+
+// loadChecksumFile membaca file checksum dari URL atau path lokal lalu memilih baris yang sesuai.
+// File checksum diunduh dengan client yang sama seperti file utama (proxy, CA, cookie), dan
+// header tambahan (misalnya Authorization) hanya dikirim jika host-nya sama dengan file utama.
+func loadChecksumFile(ctx context.Context, location string, cfg downloadConfig) (*expectedDigest, error) {
+	var data []byte
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		var headers http.Header
+		if sameHost(location, cfg.URL) {
+			headers = cfg.Headers
+		}
+		req, err := newFileRequest(ctx, "GET", location, headers)
+		if err != nil {
+			return nil, fmt.Errorf("URL file checksum tidak valid: %v", err)
+		}
+		resp, err := cfg.httpClient().Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("gagal mengunduh file checksum %s: %v", location, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("server mengembalikan status %d untuk file checksum %s", resp.StatusCode, location)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20)); err != nil {
+			return nil, fmt.Errorf("gagal membaca file checksum %s: %v", location, err)
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(location); err != nil {
+			return nil, fmt.Errorf("gagal membaca file checksum %s: %v", location, err)
+		}
+	}
+
+	// Algoritma bisa ditebak dari ekstensi file (.sha256sum, .sha1, .md5, ...).
+	algoHint := ""
+	ext := strings.ToLower(path.Ext(location))
+	for algo := range newHashFuncs {
+		if ext == "."+algo || ext == "."+algo+"sum" {
+			algoHint = algo
+		}
+	}
+	return pickChecksumLine(data, algoHint, location, checksumCandidates(cfg.Output, cfg.URL))
+}
+
+// sameHost memeriksa apakah dua URL menuju host (dan port) yang sama.
+func sameHost(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	return errA == nil && errB == nil && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// checksumCandidates mengembalikan nama file yang mungkin tercantum di file checksum.
+func checksumCandidates(output, fileURL string) []string {
+	names := []string{filepath.Base(output)}
+	if u, err := url.Parse(fileURL); err == nil && u.Path != "" {
+		names = append(names, path.Base(u.Path))
+	}
+	return names
+}
+
+var bsdChecksumLine = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.+)\) = ([0-9a-fA-F]+)$`)
+
+// pickChecksumLine mencari digest untuk salah satu nama kandidat. Jika file hanya berisi
+// satu digest, digest itu dipakai apa pun namanya.
+func pickChecksumLine(data []byte, algoHint, source string, candidates []string) (*expectedDigest, error) {
+	type entry struct{ algo, sum, name string }
+	var entries []entry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := bsdChecksumLine.FindStringSubmatch(line); m != nil {
+			entries = append(entries, entry{algo: m[1], name: m[2], sum: m[3]})
+			continue
+		}
+		// Format GNU: "<hex>  nama" atau "<hex> *nama" (mode biner), atau hanya "<hex>".
+		sum, name, _ := strings.Cut(line, " ")
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		entries = append(entries, entry{algo: algoHint, sum: sum, name: name})
+	}
+
+	var chosen *entry
+	for i, e := range entries {
+		for _, c := range candidates {
+			if e.name == c || path.Base(e.name) == c {
+				chosen = &entries[i]
+				break
+			}
+		}
+		if chosen != nil {
+			break
+		}
+	}
+	if chosen == nil && len(entries) == 1 {
+		chosen = &entries[0]
+	}
+	if chosen == nil {
+		return nil, fmt.Errorf("file checksum %s tidak berisi digest untuk %s", source, strings.Join(candidates, " / "))
+	}
+	d, err := parseHexDigest(chosen.algo, chosen.sum, source)
+	if err != nil {
+		return nil, fmt.Errorf("file checksum %s: %v", source, err)
+	}
+	return &d, nil
+}
+
+// digestsFromHeaders membaca digest seluruh file dari header respons server:
+// Repr-Digest (RFC 9530), Digest (RFC 3230), dan Content-MD5. Algoritma yang tidak
+// dikenal diabaikan. Hanya dipakai untuk respons penuh (bukan 206), karena pada
+// respons Range header tersebut bisa merujuk ke potongan data saja.
+func digestsFromHeaders(h http.Header) []expectedDigest {
+	var digests []expectedDigest
+	add := func(algo, b64, source string) {
+		algo = normalizeAlgo(algo)
+		newHash, ok := newHashFuncs[algo]
+		if !ok {
+			return
+		}
+		sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64))
+		if err != nil || len(sum) != newHash().Size() {
+			return
+		}
+		digests = append(digests, expectedDigest{Algo: algo, Sum: sum, Source: source})
+	}
+	for _, v := range h.Values("Repr-Digest") {
+		for _, item := range strings.Split(v, ",") {
+			algo, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if ok {
+				add(algo, strings.Trim(value, ":"), "header Repr-Digest")
+			}
+		}
+	}
+	for _, v := range h.Values("Digest") {
+		for _, item := range strings.Split(v, ",") {
+			algo, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if ok {
+				add(algo, value, "header Digest")
+			}
+		}
+	}
+	if v := h.Get("Content-MD5"); v != "" {
+		add("md5", v, "header Content-MD5")
+	}
+	return digests
+}
+
+// verifyDigests membandingkan hasil hash dengan semua digest yang diharapkan.
+func verifyDigests(sums map[string][]byte, expected []expectedDigest) error {
+	for _, e := range expected {
+		got := sums[e.Algo]
+		if !bytes.Equal(got, e.Sum) {
+			return fmt.Errorf("%w: %s dari %s seharusnya %x, tetapi file menghasilkan %x", errChecksumMismatch, e.Algo, e.Source, e.Sum, got)
+		}
+	}
+	return nil
+}
+
+// handleMismatch mengkarantina (rename ke .corrupt) atau menghapus file yang checksum-nya salah.
+func handleMismatch(cfg downloadConfig) {
+	outputFileName := cfg.Output
+	if cfg.OnMismatch == MismatchDelete {
+		os.Remove(outputFileName)
+		cfg.logf("File %s dihapus karena checksum tidak cocok.\n", outputFileName)
+		return
+	}
+	corrupt := outputFileName + ".corrupt"
+	if err := os.Rename(outputFileName, corrupt); err != nil {
+		cfg.logf("Gagal mengkarantina %s: %v\n", outputFileName, err)
+		return
+	}
+	cfg.logf("File dipindahkan ke %s karena checksum tidak cocok.\n", corrupt)
+}
+
+// hashFrontier menghitung hash seluruh file selama download berlangsung, walaupun bagian-bagian
+// diunduh paralel dan tidak berurutan. Hash harus dihitung berurutan dari byte 0, jadi ada
+// sebuah "frontier": offset sampai mana file sudah di-hash.
+//   - Data yang baru ditulis tepat di frontier langsung di-hash dari memori.
+//   - Data di depan frontier sudah ada di disk; begitu celah sebelumnya terisi, Goroutine
+//     pengejar membacanya kembali dari disk (biasanya masih di page cache) dan meng-hash-nya.
+type hashFrontier struct {
+	mu     sync.Mutex
+	w      io.Writer            // MultiWriter ke semua hash
+	hashes map[string]hash.Hash // Hash per algoritma
+	src    io.ReaderAt          // Isi file secara logis (file output atau gabungan file bagian)
+	// avail mengembalikan jumlah byte berurutan mulai dari sebuah offset yang sudah ada di disk.
+	avail  func(off int64) int64
+	offset int64 // Frontier: byte [0, offset) sudah di-hash
+	size   int64
+	busy   bool // Goroutine pengejar sedang membaca dari disk
+	err    error
+
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newHashFrontier membuat hasher untuk algoritma yang diminta. Byte yang sudah ada dari
+// download sebelumnya akan di-hash dari disk oleh Goroutine pengejar.
+func newHashFrontier(algos []string, src io.ReaderAt, size int64, avail func(off int64) int64) *hashFrontier {
+	hf := &hashFrontier{
+		hashes: make(map[string]hash.Hash),
+		src:    src,
+		avail:  avail,
+		size:   size,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+	var writers []io.Writer
+	for _, algo := range algos {
+		h := newHashFuncs[algo]()
+		hf.hashes[algo] = h
+		writers = append(writers, h)
+	}
+	hf.w = io.MultiWriter(writers...)
+	hf.wg.Add(1)
+	go hf.catchUpLoop()
+	hf.signal()
+	return hf
+}
+
+// written dicatat setiap kali sebuah bagian berhasil menulis p di offset 'off'
+// (setelah jumlah byte-nya dicatat di manifest).
+func (hf *hashFrontier) written(off int64, p []byte) {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+	if !hf.busy && hf.err == nil && off == hf.offset {
+		hf.w.Write(p) // Hash tidak pernah mengembalikan error
+		hf.offset += int64(len(p))
+	}
+	if hf.avail(hf.offset) > 0 {
+		hf.signal()
+	}
+}
+
+func (hf *hashFrontier) signal() {
+	select {
+	case hf.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (hf *hashFrontier) catchUpLoop() {
+	defer hf.wg.Done()
+	for {
+		select {
+		case <-hf.wake:
+			hf.catchUp()
+		case <-hf.stop:
+			return
+		}
+	}
+}
+
+// catchUp membaca dari disk semua byte yang sudah tersedia setelah frontier.
+func (hf *hashFrontier) catchUp() {
+	buf := make([]byte, 1<<20)
+	for {
+		hf.mu.Lock()
+		avail := hf.avail(hf.offset)
+		if avail <= 0 || hf.err != nil {
+			hf.mu.Unlock()
+			return
+		}
+		hf.busy = true
+		off := hf.offset
+		hf.mu.Unlock()
+
+		n, err := hf.src.ReadAt(buf[:min(avail, int64(len(buf)))], off)
+		hf.mu.Lock()
+		if n > 0 {
+			hf.w.Write(buf[:n])
+			hf.offset += int64(n)
+		}
+		if err != nil && n == 0 {
+			hf.err = fmt.Errorf("gagal membaca ulang data untuk checksum di offset %d: %v", off, err)
+		}
+		hf.busy = false
+		hf.mu.Unlock()
+	}
+}
+
+// finish menghentikan Goroutine pengejar, meng-hash sisa data, lalu mengembalikan digest per algoritma.
+func (hf *hashFrontier) finish() (map[string][]byte, error) {
+	close(hf.stop)
+	hf.wg.Wait()
+	hf.catchUp()
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+	if hf.err != nil {
+		return nil, hf.err
+	}
+	if hf.offset != hf.size {
+		return nil, fmt.Errorf("checksum hanya mencakup %d dari %d bytes", hf.offset, hf.size)
+	}
+	sums := make(map[string][]byte, len(hf.hashes))
+	for algo, h := range hf.hashes {
+		sums[algo] = h.Sum(nil)
+	}
+	return sums, nil
+}
+
+// close menghentikan Goroutine pengejar tanpa menghitung hasil (misalnya saat download gagal).
+func (hf *hashFrontier) close() {
+	select {
+	case <-hf.stop:
+	default:
+		close(hf.stop)
+	}
+	hf.wg.Wait()
+}
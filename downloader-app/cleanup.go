@@ -0,0 +1,201 @@
+// mini-projects/downloader-app/cleanup.go
+package parallel_downloader_app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// partialFilePattern mengenali file sisa download: file bagian (<output>.partN), file sementara
+// (<output>.download), dan manifest (<output>.manifest.json beserta .tmp-nya). Grup pertama
+// adalah nama file output.
+var partialFilePattern = regexp.MustCompile(`^(.+)\.(?:part\d+|download|manifest\.json(?:\.tmp)?)$`)
+
+// staleDownload adalah sisa download yang tidak selesai untuk satu file output.
+type staleDownload struct {
+	Output    string    // File output yang dituju download tersebut
+	Files     []string  // File bagian, file sementara, dan manifest
+	Size      int64     // Total ukuran semua file di Files
+	Resumable bool      // Masih punya manifest, sehingga sebenarnya bisa dilanjutkan
+	ModTime   time.Time // Waktu perubahan terakhir di antara semua file
+}
+
+// ownedByDownloader melaporkan apakah kelompok file ini hampir pasti dibuat oleh downloader
+// ini: ada manifest-nya, atau susunan filenya sama dengan yang ditulis downloader, yaitu satu
+// file sementara .download saja (mode satu koneksi) atau file bagian .part0, .part1, ...
+// yang berurutan tanpa celah. File lain dengan akhiran serupa (misalnya dari aplikasi lain)
+// hanya dihapus dengan -force.
+func (s staleDownload) ownedByDownloader() bool {
+	if s.Resumable {
+		return true
+	}
+	var parts []int
+	temp := false
+	for _, f := range s.Files {
+		switch suffix := strings.TrimPrefix(f, s.Output); suffix {
+		case ".download":
+			temp = true
+		case ".manifest.json.tmp":
+		default:
+			digits := strings.TrimPrefix(suffix, ".part")
+			n, err := strconv.Atoi(digits)
+			if err != nil || strconv.Itoa(n) != digits {
+				return false
+			}
+			parts = append(parts, n)
+		}
+	}
+	if temp {
+		return len(parts) == 0
+	}
+	sort.Ints(parts)
+	for i, n := range parts {
+		if n != i {
+			return false
+		}
+	}
+	return len(parts) > 0
+}
+
+// findStaleDownloads mencari sisa download di 'dirs' (dan subdirektorinya jika 'recursive')
+// yang tidak berubah selama minimal 'olderThan' sebelum 'now'. Batas umur ini mencegah
+// terhapusnya download yang sedang berjalan.
+func findStaleDownloads(dirs []string, olderThan time.Duration, recursive bool, now time.Time) ([]staleDownload, error) {
+	groups := make(map[string]*staleDownload)
+	for _, root := range dirs {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			match := partialFilePattern.FindStringSubmatch(d.Name())
+			if match == nil || !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil // File sudah hilang sejak dibaca
+			}
+			output := filepath.Join(filepath.Dir(path), match[1])
+			g := groups[output]
+			if g == nil {
+				g = &staleDownload{Output: output}
+				groups[output] = g
+			}
+			g.Files = append(g.Files, path)
+			g.Size += info.Size()
+			if info.ModTime().After(g.ModTime) {
+				g.ModTime = info.ModTime()
+			}
+			if path == manifestPath(output) {
+				g.Resumable = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("gagal memindai %s: %v", root, err)
+		}
+	}
+
+	var stale []staleDownload
+	for _, g := range groups {
+		if now.Sub(g.ModTime) >= olderThan {
+			sort.Strings(g.Files)
+			stale = append(stale, *g)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Output < stale[j].Output })
+	return stale, nil
+}
+
+// RunCleanupCommand mencari dan menghapus sisa download yang tidak selesai:
+//
+//	mini-projects cleanup [-older-than 24h] [-r] [-dry-run] [-force] [DIR...]
+//
+// Direktori default adalah direktori kerja. Kelompok file yang tidak dikenali sebagai sisa
+// downloader ini (lihat ownedByDownloader) dilewati kecuali dengan -force. Nilai yang dikembalikan adalah exit code:
+// 0 berhasil, 2 argumen salah, dan 5 jika ada file yang gagal dihapus.
+func RunCleanupCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	olderThan := flags.Duration("older-than", 24*time.Hour, "hanya hapus sisa download yang tidak berubah selama ini")
+	recursive := flags.Bool("r", false, "periksa subdirektori juga")
+	dryRun := flags.Bool("dry-run", false, "tampilkan saja, jangan hapus apa pun")
+	force := flags.Bool("force", false, "hapus juga file tanpa manifest yang susunannya bukan buatan downloader ini")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Penggunaan: mini-projects cleanup [flag] [DIR...]")
+		flags.PrintDefaults()
+	}
+	dirs, err := parseInterspersed(flags, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	stale, err := findStaleDownloads(dirs, *olderThan, *recursive, time.Now())
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return exitDisk
+	}
+	if len(stale) == 0 {
+		fmt.Fprintln(stdout, "Tidak ada sisa download yang perlu dihapus.")
+		return exitOK
+	}
+
+	code := exitOK
+	var freed int64
+	found, skipped := 0, 0
+	for _, s := range stale {
+		if !*force && !s.ownedByDownloader() {
+			fmt.Fprintf(stdout, "%s: dilewati, tanpa manifest dan bukan susunan file downloader ini (pakai -force untuk menghapus)\n", s.Output)
+			skipped++
+			continue
+		}
+		found++
+		note := ""
+		if s.Resumable {
+			note = ", masih bisa dilanjutkan"
+		}
+		fmt.Fprintf(stdout, "%s: %d file, %s, terakhir berubah %s%s\n",
+			s.Output, len(s.Files), formatBytes(s.Size), s.ModTime.Format("2006-01-02 15:04"), note)
+		if *dryRun {
+			continue
+		}
+		for _, f := range s.Files {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(stderr, "Error: gagal menghapus %s: %v\n", f, err)
+				code = exitDisk
+			}
+		}
+		freed += s.Size
+	}
+	if *dryRun {
+		fmt.Fprintf(stdout, "%d sisa download ditemukan (tidak ada yang dihapus karena -dry-run).\n", found)
+	} else {
+		fmt.Fprintf(stdout, "%d sisa download dihapus, %s dibebaskan.\n", found, formatBytes(freed))
+	}
+	if skipped > 0 {
+		fmt.Fprintf(stdout, "%d kelompok file tidak dikenali dan dilewati.\n", skipped)
+	}
+	return code
+}
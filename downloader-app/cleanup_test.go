@@ -0,0 +1,142 @@
+// mini-projects/downloader-app/cleanup_test.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// assertNoArtifacts memastikan tidak ada file sisa download untuk 'out'.
+func assertNoArtifacts(t *testing.T, out string) {
+	t.Helper()
+	matches, _ := filepath.Glob(out + ".*")
+	if len(matches) > 0 {
+		t.Errorf("sisa download tidak dihapus: %v", matches)
+	}
+}
+
+func TestFailedDownloadRemovesPartialFiles(t *testing.T) {
+	for _, partFiles := range []bool{false, true} {
+		content := randomContent(256*1024, 100)
+		fs, srv := newTestFileServer(t, content, `"v1"`)
+		fs.set(content, `"v1"`, 20*1024) // Setiap GET terputus di tengah
+		out := filepath.Join(t.TempDir(), "file.bin")
+
+		cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4, PartFiles: partFiles, Retry: noRetry, ProgressMode: ProgressOff}
+		if _, err := runDownload(context.Background(), cfg); err == nil {
+			t.Fatal("download seharusnya gagal")
+		}
+		assertNoArtifacts(t, out)
+
+		cfg.KeepPartial = true
+		if _, err := runDownload(context.Background(), cfg); err == nil {
+			t.Fatal("download seharusnya gagal")
+		}
+		if m, _ := loadManifest(manifestPath(out)); m == nil {
+			t.Errorf("part-files=%v: manifest seharusnya disimpan dengan KeepPartial", partFiles)
+		}
+	}
+}
+
+func TestFailedMergeRemovesIncompleteOutput(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "file.bin")
+	m := &downloadManifest{Output: out, Mode: writeModePartFiles, Size: 8, Parts: []partState{
+		{Index: 0, Start: 0, End: 3, Written: 4, File: out + ".part0"},
+		{Index: 1, Start: 4, End: 7, Written: 4, File: out + ".part1"}, // Hilang sebelum digabungkan
+	}}
+	if err := os.WriteFile(m.Parts[0].File, []byte("abcd"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := mergeParts(downloadConfig{Output: out, Log: io.Discard}, m)
+	var de *diskError
+	if !errors.As(err, &de) {
+		t.Fatalf("error = %v, ingin diskError", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("file akhir yang belum lengkap tidak dihapus")
+	}
+}
+
+func TestCleanupCommandRemovesStaleDownloads(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	write := func(name string, modTime time.Time) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	// Sisa download lama tanpa manifest, di samping file yang sudah selesai.
+	done := write("lama.bin", old)
+	oldParts := []string{write("lama.bin.part0", old), write("lama.bin.part1", old)}
+	// Download lama yang masih punya manifest, dan sisa mode satu koneksi (tanpa manifest).
+	oldResumable := []string{write("iso.img.download", old), write("iso.img.manifest.json", old)}
+	oldSingle := write("film.mkv.download", old)
+	// Tanpa manifest dan susunannya bukan buatan downloader ini: hanya dihapus dengan -force.
+	unknown := []string{write("foto.jpg.part3", old), write("arsip.zip.download", old), write("arsip.zip.part0", old)}
+	// Download yang baru saja berjalan tidak boleh dihapus.
+	recent := []string{write("baru.bin.part0", old), write("baru.bin.manifest.json", time.Now())}
+	other := write("catatan.txt", old)
+
+	code, stdout, _ := runCleanup(t, "-dry-run", dir)
+	if code != exitOK || !strings.Contains(stdout, "3 sisa download") || !strings.Contains(stdout, "2 kelompok file tidak dikenali") {
+		t.Fatalf("dry-run: exit %d, output:\n%s", code, stdout)
+	}
+	for _, f := range append(oldParts, oldResumable...) {
+		if _, err := os.Stat(f); err != nil {
+			t.Errorf("-dry-run menghapus %s", f)
+		}
+	}
+
+	if code, stdout, stderr := runCleanup(t, dir); code != exitOK {
+		t.Fatalf("exit %d, stdout:\n%s\nstderr:\n%s", code, stdout, stderr)
+	}
+	for _, f := range append(append(oldParts, oldResumable...), oldSingle) {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("%s tidak dihapus", f)
+		}
+	}
+	for _, f := range append(append(recent, done, other), unknown...) {
+		if _, err := os.Stat(f); err != nil {
+			t.Errorf("%s seharusnya tidak dihapus", f)
+		}
+	}
+
+	if code, stdout, _ := runCleanup(t, "-force", dir); code != exitOK || !strings.Contains(stdout, "2 sisa download dihapus") {
+		t.Fatalf("-force: exit %d, output:\n%s", code, stdout)
+	}
+	for _, f := range unknown {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("-force tidak menghapus %s", f)
+		}
+	}
+	for _, f := range append(recent, done, other) {
+		if _, err := os.Stat(f); err != nil {
+			t.Errorf("-force menghapus %s", f)
+		}
+	}
+
+	if code, _, _ := runCleanup(t, "-older-than", "besok"); code != exitUsage {
+		t.Errorf("durasi tidak valid: exit code = %d, ingin %d", code, exitUsage)
+	}
+}
+
+func runCleanup(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr strings.Builder
+	code := RunCleanupCommand(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
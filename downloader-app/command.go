@@ -0,0 +1,291 @@
+// mini-projects/downloader-app/command.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Exit code perintah download, agar skrip bisa membedakan jenis kegagalan.
+const (
+	exitOK       = 0   // Berhasil, atau dilewati karena file sudah ada
+	exitFailure  = 1   // Kegagalan lain
+	exitUsage    = 2   // Flag atau argumen tidak valid
+	exitNetwork  = 3   // Gagal menghubungi server atau server membalas dengan error
+	exitChecksum = 4   // Checksum file hasil download tidak cocok
+	exitDisk     = 5   // Gagal membaca atau menulis file lokal
+	exitCanceled = 130 // Dihentikan dengan Ctrl+C atau SIGTERM
+)
+
+// stringList adalah flag yang boleh diberikan berulang kali, misalnya -mirror dan -header.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ", ") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// commandSummary adalah ringkasan hasil yang ditulis ke stdout dengan -json.
+type commandSummary struct {
+	Status         string            `json:"status"` // "ok", "skipped", atau "error"
+	URL            string            `json:"url"`
+	Output         string            `json:"output,omitempty"`
+	Size           int64             `json:"size"`
+	Checksums      map[string]string `json:"checksums,omitempty"`
+	Resumed        bool              `json:"resumed"`
+	SingleStream   bool              `json:"single_stream"`
+	ElapsedSeconds float64           `json:"elapsed_seconds"`
+	BytesPerSecond float64           `json:"bytes_per_second"`
+	Mirrors        []mirrorSummary   `json:"mirrors,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	ErrorKind      string            `json:"error_kind,omitempty"` // "network", "checksum", "disk", atau "canceled"
+	ExitCode       int               `json:"exit_code"`
+}
+
+type mirrorSummary struct {
+	URL            string  `json:"url"`
+	Bytes          int64   `json:"bytes"`
+	Requests       int     `json:"requests"`
+	Failures       int     `json:"failures"`
+	Switches       int     `json:"switches"`
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	Disabled       string  `json:"disabled,omitempty"`
+}
+
+// RunDownloadCommand menjalankan download tanpa menu interaktif, untuk skrip dan cron:
+//
+//	mini-projects download URL [MIRROR...] -o out.iso -parts 8 -sha256 <hex> -quiet -json
+//
+// Flag boleh diletakkan sebelum atau sesudah URL. Pesan status dan progres ditulis ke stdout
+// (ke stderr jika -json, agar stdout hanya berisi ringkasan JSON). Nilai yang dikembalikan
+// adalah exit code: 0 berhasil, 2 argumen salah, 3 error jaringan/server, 4 checksum tidak
+// cocok, 5 error disk, 130 dibatalkan, dan 1 untuk kegagalan lain.
+func RunDownloadCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("download", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		output         string
+		mirrors        stringList
+		headers        stringList
+		windows        stringList
+		startAt        string
+		opts           Options
+		sha256Sum      string
+		rate           string
+		user           string
+		quiet, asJSON  bool
+		connectTimeout time.Duration
+	)
+	fs.StringVar(&output, "o", "", "path file hasil download (default: nama dari server atau URL)")
+	fs.StringVar(&output, "output", "", "sama dengan -o")
+	fs.StringVar(&opts.OutputDir, "dir", "", "direktori untuk nama output otomatis")
+	fs.StringVar(&opts.OnExist, "on-exist", ExistOverwrite, "jika file sudah ada: overwrite, skip, atau rename")
+	fs.IntVar(&opts.Parts, "parts", defaultNumParts, "jumlah koneksi paralel")
+	fs.BoolVar(&opts.PartFiles, "part-files", false, "tulis setiap bagian ke <output>.partN lalu gabungkan")
+	fs.BoolVar(&opts.KeepPartial, "keep-partial", false, "simpan file bagian dan manifest jika gagal, agar bisa dilanjutkan")
+	fs.StringVar(&sha256Sum, "sha256", "", "SHA-256 yang diharapkan (hex)")
+	fs.StringVar(&opts.Checksum, "checksum", "", "checksum yang diharapkan: \"algo:hex\", hex, atau URL/path file checksum")
+	fs.StringVar(&opts.OnMismatch, "on-mismatch", MismatchQuarantine, "jika checksum tidak cocok: quarantine (pindahkan ke .corrupt) atau delete")
+	fs.Var(&mirrors, "mirror", "URL mirror untuk file yang sama (boleh berulang)")
+	fs.StringVar(&rate, "rate", "", "batas kecepatan, misalnya 500K atau 2MB")
+	fs.StringVar(&startAt, "start", "", "mulai download pada waktu ini, misalnya 22:00 atau \"2006-01-02 22:00\"")
+	fs.Var(&windows, "window", "hanya download dalam jendela harian, misalnya 22:00-06:00 atau 22:00-06:00@2MB (boleh berulang)")
+	fs.IntVar(&opts.MaxAttempts, "retries", 0, "jumlah percobaan per bagian (default 5)")
+	fs.Var(&headers, "header", "header tambahan \"Nama: nilai\" (boleh berulang)")
+	fs.StringVar(&user, "user", "", "autentikasi Basic \"nama:password\"")
+	fs.StringVar(&opts.BearerToken, "bearer", "", "token untuk header \"Authorization: Bearer\"")
+	fs.StringVar(&opts.Proxy, "proxy", "", "URL proxy (default dari HTTP_PROXY/HTTPS_PROXY)")
+	fs.StringVar(&opts.CAFile, "ca-file", "", "CA bundle (PEM) tambahan")
+	fs.BoolVar(&opts.InsecureSkipVerify, "insecure", false, "jangan verifikasi sertifikat TLS")
+	fs.DurationVar(&opts.Timeout, "timeout", 0, "batas waktu seluruh download (0 = tanpa batas)")
+	fs.DurationVar(&connectTimeout, "connect-timeout", 0, "batas waktu membuka koneksi (default 30s)")
+	fs.DurationVar(&opts.StallTimeout, "stall-timeout", 0, "ulangi bagian yang tidak menerima data selama ini (default 60s)")
+	fs.StringVar(&opts.ProgressMode, "progress", ProgressAuto, "tampilan progres: auto, tty, log, atau off")
+	fs.BoolVar(&quiet, "quiet", false, "jangan tampilkan progres dan pesan status")
+	fs.BoolVar(&asJSON, "json", false, "tulis ringkasan hasil sebagai JSON ke stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Penggunaan: mini-projects download [flag] URL [MIRROR...]")
+		fs.PrintDefaults()
+	}
+
+	urls, err := parseInterspersed(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	usageError := func(format string, args ...any) int {
+		fmt.Fprintf(stderr, "Error: "+format+"\n", args...)
+		fs.Usage()
+		return exitUsage
+	}
+	if len(urls) == 0 {
+		return usageError("URL belum diberikan")
+	}
+	urls = append(urls, mirrors...)
+	for _, u := range urls {
+		if err := validateURL(u); err != nil {
+			return usageError("%v", err)
+		}
+	}
+
+	opts.Output = output
+	opts.ConnectTimeout = connectTimeout
+	if sha256Sum != "" {
+		if opts.Checksum != "" {
+			return usageError("-sha256 dan -checksum tidak bisa dipakai bersamaan")
+		}
+		if b, err := hex.DecodeString(sha256Sum); err != nil || len(b) != 32 {
+			return usageError("-sha256 harus berisi 64 karakter hex")
+		}
+		opts.Checksum = "sha256:" + sha256Sum
+	}
+	if rate != "" {
+		limit, err := parseByteRate(rate)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		opts.RateLimit = limit
+	}
+	if startAt != "" {
+		t, err := parseStartTime(startAt, time.Now())
+		if err != nil {
+			return usageError("%v", err)
+		}
+		opts.Schedule.StartAt = t
+	}
+	for _, spec := range windows {
+		w, err := parseTimeWindow(spec)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		opts.Schedule.Windows = append(opts.Schedule.Windows, w)
+	}
+	if user != "" {
+		name, pass, ok := strings.Cut(user, ":")
+		if !ok {
+			return usageError("-user harus berformat \"nama:password\"")
+		}
+		opts.Username, opts.Password = name, pass
+	}
+	if len(headers) > 0 {
+		opts.Headers = http.Header{}
+		for _, h := range headers {
+			name, value, ok := strings.Cut(h, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				return usageError("header tidak valid: '%s' (contoh: \"User-Agent: skrip/1.0\")", h)
+			}
+			opts.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+	switch {
+	case quiet:
+		opts.ProgressMode, opts.Log = ProgressOff, io.Discard
+	case asJSON:
+		opts.Log = stderr
+	default:
+		opts.Log = stdout
+	}
+
+	d, err := NewDownloader(opts)
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	res, err := d.DownloadFrom(ctx, urls...)
+
+	code, kind := exitOK, ""
+	if err != nil {
+		code, kind = classifyDownloadError(err)
+		if !asJSON {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+		}
+	} else if res.Skipped && !quiet && !asJSON {
+		fmt.Fprintf(stdout, "%s sudah ada, download dilewati.\n", res.Output)
+	}
+	if asJSON {
+		summary := summarizeResult(urls[0], res, err)
+		summary.ErrorKind, summary.ExitCode = kind, code
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			fmt.Fprintf(stderr, "Error: gagal menulis ringkasan JSON: %v\n", err)
+			return exitFailure
+		}
+	}
+	return code
+}
+
+// parseInterspersed membaca flag yang boleh diletakkan sebelum atau sesudah argumen biasa,
+// misalnya "download URL -o out". Paket flag berhenti di argumen pertama yang bukan flag,
+// jadi parsing dilanjutkan setelah setiap argumen. Mengembalikan argumen biasa secara berurutan.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// classifyDownloadError memetakan error download ke exit code dan jenisnya. Error yang bukan
+// checksum, disk, atau pembatalan berasal dari jaringan atau server.
+func classifyDownloadError(err error) (int, string) {
+	var de *diskError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitCanceled, "canceled"
+	case errors.Is(err, errChecksumMismatch):
+		return exitChecksum, "checksum"
+	case errors.As(err, &de):
+		return exitDisk, "disk"
+	}
+	return exitNetwork, "network"
+}
+
+// summarizeResult membuat ringkasan JSON dari hasil DownloadFrom.
+func summarizeResult(fileURL string, res Result, err error) commandSummary {
+	s := commandSummary{
+		Status:         "ok",
+		URL:            fileURL,
+		Output:         res.Output,
+		Size:           res.Size,
+		Checksums:      res.Checksums,
+		Resumed:        res.Resumed,
+		SingleStream:   res.SingleStream,
+		ElapsedSeconds: res.Elapsed.Seconds(),
+	}
+	if res.Elapsed > 0 {
+		s.BytesPerSecond = float64(res.Size) / res.Elapsed.Seconds()
+	}
+	for _, st := range res.Mirrors {
+		s.Mirrors = append(s.Mirrors, mirrorSummary{
+			URL: st.URL, Bytes: st.Bytes, Requests: st.Requests, Failures: st.Failures,
+			Switches: st.Switches, BytesPerSecond: st.Speed, Disabled: st.Disabled,
+		})
+	}
+	switch {
+	case err != nil:
+		s.Status, s.Error = "error", err.Error()
+	case res.Skipped:
+		s.Status = "skipped"
+	}
+	return s
+}
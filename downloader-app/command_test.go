@@ -0,0 +1,118 @@
+// mini-projects/downloader-app/command_test.go
+package parallel_downloader_app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCommand menjalankan RunDownloadCommand dan mengembalikan exit code beserta stdout dan stderr.
+func runCommand(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := RunDownloadCommand(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestDownloadCommandJSON(t *testing.T) {
+	content := randomContent(512*1024, 90)
+	_, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+
+	// Flag sesudah URL juga dibaca.
+	code, stdout, stderr := runCommand(t, srv.URL+"/file.bin", "-o", out, "-parts", "8", "-sha256", sha256Hex(content), "-json")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, ingin 0; stderr:\n%s", code, stderr)
+	}
+	assertFileContent(t, out, content)
+
+	var summary commandSummary
+	if err := json.Unmarshal([]byte(stdout), &summary); err != nil {
+		t.Fatalf("stdout bukan JSON: %v\n%s", err, stdout)
+	}
+	if summary.Status != "ok" || summary.Output != out || summary.Size != int64(len(content)) || summary.ExitCode != 0 {
+		t.Errorf("ringkasan salah: %+v", summary)
+	}
+	if summary.Checksums["sha256"] != sha256Hex(content) {
+		t.Errorf("checksum di ringkasan = %v", summary.Checksums)
+	}
+	if stderr == "" {
+		t.Error("pesan status seharusnya ditulis ke stderr saat -json")
+	}
+
+	// -quiet tidak menulis apa pun jika berhasil, dan -on-exist skip melewati file yang ada.
+	code, stdout, stderr = runCommand(t, "-quiet", "-on-exist", "skip", "-o", out, srv.URL)
+	if code != exitOK || stdout != "" || stderr != "" {
+		t.Errorf("-quiet: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+}
+
+func TestDownloadCommandExitCodes(t *testing.T) {
+	content := randomContent(128*1024, 91)
+	_, srv := newTestFileServer(t, content, `"v1"`)
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	dir := t.TempDir()
+	notDir := filepath.Join(dir, "bukan-direktori")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	wrongSum := strings.Repeat("0", 64)
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+		kind string
+	}{
+		{"checksum", []string{srv.URL, "-o", filepath.Join(dir, "a.bin"), "-sha256", wrongSum}, exitChecksum, "checksum"},
+		{"404", []string{missing.URL + "/a.bin", "-o", filepath.Join(dir, "b.bin"), "-retries", "1"}, exitNetwork, "network"},
+		{"disk", []string{srv.URL, "-o", filepath.Join(notDir, "c.bin")}, exitDisk, "disk"},
+	}
+	for _, tt := range tests {
+		code, stdout, _ := runCommand(t, append(tt.args, "-quiet", "-json")...)
+		if code != tt.want {
+			t.Errorf("%s: exit code = %d, ingin %d", tt.name, code, tt.want)
+			continue
+		}
+		var summary commandSummary
+		if err := json.Unmarshal([]byte(stdout), &summary); err != nil {
+			t.Fatalf("%s: stdout bukan JSON: %v", tt.name, err)
+		}
+		if summary.Status != "error" || summary.ErrorKind != tt.kind || summary.ExitCode != tt.want || summary.Error == "" {
+			t.Errorf("%s: ringkasan salah: %+v", tt.name, summary)
+		}
+	}
+	// Tanpa -on-mismatch file yang salah dikarantina; dengan "delete" file langsung dihapus.
+	assertFileContent(t, filepath.Join(dir, "a.bin.corrupt"), content)
+	deleted := filepath.Join(dir, "d.bin")
+	if code, _, _ := runCommand(t, srv.URL, "-o", deleted, "-sha256", wrongSum, "-on-mismatch", "delete", "-quiet"); code != exitChecksum {
+		t.Errorf("-on-mismatch delete: exit code = %d, ingin %d", code, exitChecksum)
+	}
+	for _, path := range []string{deleted, deleted + ".corrupt"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s masih ada setelah checksum salah dengan -on-mismatch delete", path)
+		}
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"-parts"},
+		{"ftp://example.com/a.bin"},
+		{srv.URL, "-sha256", "bukan-hex"},
+		{srv.URL, "-header", "tanpa titik dua"},
+		{srv.URL, "-window", "22:00"},
+		{srv.URL, "-start", "besok"},
+		{srv.URL, "-on-mismatch", "simpan"},
+	} {
+		if code, _, stderr := runCommand(t, args...); code != exitUsage || stderr == "" {
+			t.Errorf("%q: exit code = %d, ingin %d dengan pesan di stderr", args, code, exitUsage)
+		}
+	}
+}
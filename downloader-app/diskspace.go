@@ -0,0 +1,57 @@
+// mini-projects/downloader-app/diskspace.go
+package parallel_downloader_app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// errNoSpace dikembalikan (dibungkus diskError) jika ruang kosong di disk tujuan lebih kecil
+// dari yang dibutuhkan download.
+var errNoSpace = errors.New("ruang disk tidak cukup")
+
+// freeDiskSpace mengembalikan ruang kosong (bytes) yang bisa dipakai di filesystem 'dir'.
+// Berupa variabel agar bisa diganti di tes.
+var freeDiskSpace = diskFree
+
+// allocatedSize mengembalikan ruang disk yang sudah benar-benar dipakai file 'path' (0 jika
+// tidak ada). File sparse, misalnya hasil Truncate, bisa jauh lebih kecil dari ukurannya.
+func allocatedSize(path string) int64 {
+	st, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return min(allocatedBytes(st), st.Size())
+}
+
+// requiredSpace menghitung tambahan ruang disk yang masih dibutuhkan untuk menyelesaikan
+// download 'm', dengan memperhitungkan data (atau alokasi) yang sudah ada di disk.
+func requiredSpace(m *downloadManifest) int64 {
+	if m.Mode == writeModeDirect {
+		return max(m.Size-allocatedSize(m.TempFile), 0)
+	}
+	var need, largest int64
+	for _, p := range m.Parts {
+		need += max(p.length()-allocatedSize(p.File), 0)
+		largest = max(largest, p.length())
+	}
+	// Saat penggabungan, setiap file bagian baru dihapus setelah disalin ke file akhir,
+	// jadi puncaknya adalah semua bagian ditambah satu bagian terbesar.
+	return need + largest
+}
+
+// checkFreeSpace memastikan filesystem tempat 'output' punya ruang kosong minimal 'need' bytes.
+// Jika ruang kosong tidak bisa diketahui (OS lain atau filesystem khusus), pemeriksaan dilewati.
+func checkFreeSpace(output string, need int64) error {
+	if need <= 0 {
+		return nil
+	}
+	dir := filepath.Dir(output)
+	free, err := freeDiskSpace(dir)
+	if err != nil || need <= free {
+		return nil
+	}
+	return &diskError{err: fmt.Errorf("%w: butuh %s di %s, tersedia %s", errNoSpace, formatBytes(need), dir, formatBytes(free))}
+}
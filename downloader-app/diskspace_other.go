@@ -0,0 +1,20 @@
+// mini-projects/downloader-app/diskspace_other.go
+
+//go:build !linux && !darwin
+
+package parallel_downloader_app
+
+import (
+	"errors"
+	"os"
+)
+
+// diskFree tidak didukung di OS ini; pemeriksaan ruang disk dilewati.
+func diskFree(dir string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// allocatedBytes menganggap file tidak sparse, karena jumlah blok tidak bisa dibaca di OS ini.
+func allocatedBytes(fi os.FileInfo) int64 {
+	return fi.Size()
+}
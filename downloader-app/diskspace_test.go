@@ -0,0 +1,78 @@
+// mini-projects/downloader-app/diskspace_test.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func setFreeDiskSpace(t *testing.T, free int64) {
+	t.Helper()
+	old := freeDiskSpace
+	freeDiskSpace = func(string) (int64, error) { return free, nil }
+	t.Cleanup(func() { freeDiskSpace = old })
+}
+
+func TestPreflightRejectsInsufficientSpace(t *testing.T) {
+	content := randomContent(256*1024, 101)
+	for _, partFiles := range []bool{false, true} {
+		fs, srv := newTestFileServer(t, content, `"v1"`)
+		out := filepath.Join(t.TempDir(), "file.bin")
+		cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4, PartFiles: partFiles, ProgressMode: ProgressOff, Log: io.Discard}
+
+		setFreeDiskSpace(t, int64(len(content))-1)
+		_, err := runDownload(context.Background(), cfg)
+		var de *diskError
+		if !errors.Is(err, errNoSpace) || !errors.As(err, &de) {
+			t.Fatalf("part-files=%v: error = %v, ingin errNoSpace dalam diskError", partFiles, err)
+		}
+		if len(fs.requests) != 0 {
+			t.Errorf("part-files=%v: %d GET terkirim walaupun ruang disk tidak cukup", partFiles, len(fs.requests))
+		}
+		assertNoArtifacts(t, out)
+
+		// Mode file bagian butuh ruang tambahan untuk satu bagian saat penggabungan.
+		need := int64(len(content))
+		if partFiles {
+			need += int64(len(content)) / 4
+		}
+		setFreeDiskSpace(t, need)
+		if _, err := runDownload(context.Background(), cfg); err != nil {
+			t.Fatalf("part-files=%v: download dengan ruang cukup gagal: %v", partFiles, err)
+		}
+		assertFileContent(t, out, content)
+	}
+}
+
+func TestRequiredSpaceCountsAllocatedData(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("jumlah blok file hanya dibaca di Linux dan macOS")
+	}
+	out := filepath.Join(t.TempDir(), "file.bin")
+	m := &downloadManifest{Mode: writeModeDirect, TempFile: tempOutputPath(out), Size: 1 << 20}
+	if got := requiredSpace(m); got != m.Size {
+		t.Errorf("tanpa file sementara: butuh %d, ingin %d", got, m.Size)
+	}
+	// File sparse hasil Truncate berukuran penuh tetapi belum memakai ruang disk.
+	if err := os.WriteFile(m.TempFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(m.TempFile, m.Size); err != nil {
+		t.Fatal(err)
+	}
+	if got := requiredSpace(m); got < m.Size/2 {
+		t.Errorf("file sparse: butuh %d, seharusnya hampir %d", got, m.Size)
+	}
+	// File yang sudah berisi data penuh tidak butuh ruang tambahan.
+	if err := os.WriteFile(m.TempFile, randomContent(int(m.Size), 102), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := requiredSpace(m); got != 0 {
+		t.Errorf("file penuh: butuh %d, ingin 0", got)
+	}
+}
@@ -0,0 +1,27 @@
+// mini-projects/downloader-app/diskspace_unix.go
+
+//go:build linux || darwin
+
+package parallel_downloader_app
+
+import (
+	"os"
+	"syscall"
+)
+
+// diskFree membaca ruang kosong untuk pengguna biasa (bukan root) dengan statfs(2).
+func diskFree(dir string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
+
+// allocatedBytes mengembalikan jumlah blok yang dialokasikan untuk file (dalam satuan 512 byte).
+func allocatedBytes(fi os.FileInfo) int64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return st.Blocks * 512
+	}
+	return fi.Size()
+}
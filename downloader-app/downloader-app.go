@@ -2,146 +2,522 @@
 package parallel_downloader_app // Mendeklarasikan package ini sebagai 'parallel_downloader_app'
 
 import (
-	"bufio"    // Untuk membaca input dari pengguna (misalnya URL, nama file)
-	"fmt"      // Untuk fungsi input/output seperti Println
-	"io"       // Untuk operasi input/output (misalnya membaca dan menulis data stream)
-	"net/http" // Untuk melakukan request HTTP ke server
-	"os"       // Untuk berinteraksi dengan sistem operasi (misalnya membuat/menulis file, menghapus file)
-	"strconv"  // Untuk konversi string ke angka dan sebaliknya
-	"strings"  // Untuk manipulasi string (misalnya, menghapus spasi/newline)
-	"sync"     // Untuk WaitGroup, agar kita bisa menunggu Goroutine selesai
-	// Package 'time' tidak diperlukan di sini karena tidak ada time.Sleep() yang spesifik di logika inti downloadPart.
-	// Jika ada, akan diimport.
+	"bufio"              // Untuk membaca input dari pengguna (misalnya URL, nama file)
+	"context"            // Untuk membatalkan Goroutine lain jika satu bagian mendeteksi file di server berubah
+	"encoding/hex"       // Untuk menampilkan checksum hasil download dalam bentuk hex
+	"errors"             // Untuk membandingkan error khusus (errors.Is)
+	"fmt"                // Untuk fungsi input/output seperti Println
+	"io"                 // Untuk operasi input/output (misalnya membaca dan menulis data stream)
+	"net/http"           // Untuk melakukan request HTTP ke server
+	"net/http/cookiejar" // Untuk menyimpan cookie dari server (opsional)
+	"os"                 // Untuk berinteraksi dengan sistem operasi (misalnya membuat/menulis file, menghapus file)
+	"os/signal"          // Untuk membatalkan download dengan Ctrl+C
+	"path/filepath"      // Untuk menggabungkan direktori output dengan nama file dari server
+	"slices"             // Untuk menyalin nilai header tambahan
+	"sort"               // Untuk mengurutkan segmen berdasarkan posisinya di file
+	"strconv"            // Untuk konversi string ke angka dan sebaliknya
+	"strings"            // Untuk manipulasi string (misalnya, menghapus spasi/newline)
+	"sync"               // Untuk WaitGroup, agar kita bisa menunggu Goroutine selesai
+	"time"               // Untuk interval penyimpanan manifest
 )
 
-// downloadPart adalah fungsi yang akan dijalankan oleh setiap Goroutine
-// untuk mengunduh sebagian kecil dari file.
-// 'url': URL file yang akan diunduh.
-// 'partNum': Nomor bagian (misalnya, bagian ke-0, ke-1, dst.) untuk identifikasi.
-// 'startByte': Byte awal dari bagian yang akan diunduh (termasuk).
-// 'endByte': Byte akhir dari bagian yang akan diunduh (termasuk).
-// 'outputFile': Nama file sementara tempat bagian ini akan disimpan di disk.
+// errRemoteChanged dikirim oleh downloadPart jika server membalas If-Range dengan seluruh
+// file (status 200), artinya file di server sudah berubah sejak download sebelumnya.
+var errRemoteChanged = errors.New("file di server sudah berubah sejak download sebelumnya")
+
+// manifestFlushInterval adalah seberapa sering progres disimpan ke manifest.
+const manifestFlushInterval = time.Second
+
+// downloadConfig berisi parameter untuk satu kali download.
+type downloadConfig struct {
+	URL string // URL file yang akan diunduh
+	// Mirrors (opsional) adalah URL lain untuk file yang sama; bagian-bagian dibagi ke semua mirror.
+	Mirrors []string
+	Output  string // Nama file output; jika kosong, ditentukan dari respons server (lihat outputNameFor)
+	// OutputDir adalah direktori untuk nama output yang ditentukan otomatis.
+	OutputDir string
+	// OnExist menentukan tindakan jika file output sudah ada: ExistOverwrite (default), ExistSkip, atau ExistRename.
+	OnExist  string
+	NumParts int // Jumlah bagian paralel
+	// PartFiles memakai mode lama: setiap bagian ditulis ke <output>.partN lalu digabungkan.
+	// Defaultnya (false) setiap bagian ditulis langsung ke file output yang sudah dialokasikan.
+	PartFiles bool
+	// Checksum (opsional) adalah digest yang diharapkan: "sha256:<hex>", "sha1:<hex>",
+	// "md5:<hex>", hex saja, atau URL/path file .sha256sum.
+	Checksum string
+	// Retry mengatur percobaan ulang setiap bagian yang gagal (nilai nol = default).
+	Retry retryPolicy
+	// ProgressMode memilih tampilan progres: ProgressAuto (default), ProgressTTY, ProgressLog, atau ProgressOff.
+	ProgressMode string
+	// OnProgress (opsional) dipanggil berkala dengan progres download.
+	OnProgress ProgressFunc
+	// OnMismatch menentukan nasib file jika checksum salah: MismatchQuarantine (default) atau MismatchDelete.
+	OnMismatch string
+	// Conns (opsional) membatasi jumlah koneksi bersamaan bersama download lain, misalnya di antrean.
+	Conns *connLimiter
+	// RateLimit (opsional) membatasi kecepatan download ini; SharedRateLimit (opsional) adalah
+	// batas global yang dibagi dengan download lain. Keduanya bisa diubah saat download berjalan.
+	RateLimit       *rateLimiter
+	SharedRateLimit *rateLimiter
+	// Client (opsional) dipakai untuk semua request file; nil = http.DefaultClient.
+	Client *http.Client
+	// Headers (opsional) ditambahkan ke setiap request file (HEAD dan GET).
+	Headers http.Header
+	// StallTimeout (opsional) memutus koneksi yang tidak menerima data selama durasi ini (0 = mati).
+	StallTimeout time.Duration
+	// Log (opsional) menerima pesan status dan tampilan progres; nil = os.Stdout.
+	Log io.Writer
+	// KeepPartial menyimpan file bagian, file sementara, dan manifest jika download gagal,
+	// agar bisa dilanjutkan. Jika false, semuanya dihapus. Download yang dibatalkan lewat ctx
+	// selalu disimpan.
+	KeepPartial bool
+}
+
+// logWriter mengembalikan tujuan pesan status download ini.
+func (cfg downloadConfig) logWriter() io.Writer {
+	if cfg.Log != nil {
+		return cfg.Log
+	}
+	return os.Stdout
+}
+
+// logf mencetak pesan status ke cfg.Log (default stdout).
+func (cfg downloadConfig) logf(format string, args ...any) {
+	fmt.Fprintf(cfg.logWriter(), format, args...)
+}
+
+// httpClient mengembalikan client HTTP yang dipakai konfigurasi ini.
+func (cfg downloadConfig) httpClient() *http.Client {
+	if cfg.Client != nil {
+		return cfg.Client
+	}
+	return http.DefaultClient
+}
+
+// newFileRequest membuat request ke URL file beserta header tambahan dari pengguna.
+func newFileRequest(ctx context.Context, method, fileURL string, headers http.Header) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header[http.CanonicalHeaderKey(k)] = slices.Clone(v)
+	}
+	return req, nil
+}
+
+// writeMode mengembalikan mode penulisan yang dipilih konfigurasi ini.
+func (cfg downloadConfig) writeMode() string {
+	if cfg.PartFiles {
+		return writeModePartFiles
+	}
+	return writeModeDirect
+}
+
+// remoteInfo berisi metadata file di server yang didapat dari HEAD request.
+type remoteInfo struct {
+	Size         int64  // -1 jika server tidak mengirim Content-Length
+	AcceptRanges string // Nilai header Accept-Ranges ("bytes", "none", atau kosong)
+	ETag         string
+	LastModified string
+	Digests      []expectedDigest // Dari header Repr-Digest/Digest/Content-MD5, jika ada
+	// FinalURL adalah URL setelah semua redirect diikuti. Request bagian dikirim langsung ke
+	// URL ini, agar setiap bagian tidak dialihkan ke server yang berbeda-beda.
+	FinalURL string
+	Filename string // Nama file dari Content-Disposition (sudah disanitasi), jika ada
+}
+
+// rangeUnsupportedReason menjelaskan kenapa file tidak bisa diunduh per bagian, atau string
+// kosong jika Range request bisa dicoba. Server yang tidak mengirim Accept-Ranges tetap dicoba;
+// jika ternyata Range diabaikan (status 200), downloader beralih ke satu koneksi.
+func (ri remoteInfo) rangeUnsupportedReason() string {
+	switch {
+	case ri.Size < 0:
+		return "server tidak mengirim Content-Length"
+	case ri.AcceptRanges == "none":
+		return "server tidak mendukung Range request (Accept-Ranges: none)"
+	case ri.Size == 0:
+		return "file kosong"
+	}
+	return ""
+}
+
+// sameRepresentation memeriksa apakah respons 200 masih merujuk ke file yang sama seperti
+// saat probe. Jika ya, server mengirim seluruh file karena mengabaikan Range, bukan karena
+// file berubah. Jika server tidak mengirim ETag/Last-Modified, file dianggap berubah
+// (aman: download diulang dari awal dan server tanpa Range akan terdeteksi di sana).
+func (ri remoteInfo) sameRepresentation(h http.Header) bool {
+	if etag := h.Get("ETag"); etag != "" && ri.ETag != "" {
+		return etag == ri.ETag
+	}
+	if lm := h.Get("Last-Modified"); lm != "" && ri.LastModified != "" {
+		return lm == ri.LastModified
+	}
+	return false
+}
+
+// validator mengembalikan nilai untuk header If-Range: ETag kuat jika ada,
+// atau Last-Modified. ETag lemah (W/"...") tidak boleh dipakai di If-Range.
+func (ri remoteInfo) validator() string {
+	if ri.ETag != "" && !strings.HasPrefix(ri.ETag, "W/") {
+		return ri.ETag
+	}
+	return ri.LastModified
+}
+
+// partWriter meneruskan data ke file bagian dan mencatat jumlah byte yang
+// tertulis ke manifest, sehingga progres bisa dilanjutkan jika download terputus.
+// Penulisan berhenti di ujung segmen saat ini, yang bisa dipendekkan oleh worker lain.
+// Jika hasher tidak nil, data yang sama juga diteruskan untuk perhitungan checksum.
+type partWriter struct {
+	w       io.Writer
+	index   int
+	off     int64 // Offset di file akhir tempat byte berikutnya ditulis
+	tracker *manifestTracker
+	hasher  *hashFrontier
+}
+
+func (pw *partWriter) Write(p []byte) (int, error) {
+	allowed := pw.tracker.reserve(pw.index, int64(len(p)))
+	n, err := pw.w.Write(p[:allowed])
+	pw.tracker.commit(pw.index, int64(n))
+	if n > 0 {
+		if pw.hasher != nil {
+			pw.hasher.written(pw.off, p[:n])
+		}
+		pw.off += int64(n)
+	}
+	if err != nil {
+		return n, &diskError{err: err}
+	}
+	if allowed < int64(len(p)) {
+		return n, errSegmentShrunk
+	}
+	return n, nil
+}
+
+// partJob berisi semua yang sama untuk setiap bagian dari satu file yang sedang diunduh.
+type partJob struct {
+	mirrors  *mirrorSet       // URL file (satu atau beberapa mirror) beserta hasil probe-nya
+	out      partOutput       // Tujuan penulisan data (file output langsung atau file .partN)
+	tracker  *manifestTracker // Manifest tempat progres setiap bagian dicatat
+	hasher   *hashFrontier    // Penghitung checksum seluruh file (nil jika checksum tidak diperiksa)
+	retry    retryPolicy      // Aturan percobaan ulang untuk setiap bagian
+	size     int64            // Ukuran file di server
+	conns    *connLimiter     // Batas koneksi bersama (nil = tanpa batas)
+	limiters []*rateLimiter   // Batas kecepatan download ini dan batas global (boleh nil)
+	client   *http.Client     // Client HTTP untuk request bagian
+	stall    time.Duration    // Batas waktu tanpa data sebelum koneksi dianggap macet (0 = mati)
+	// logf mencetak pesan per bagian lewat pelapor progres, agar tidak merusak tampilan terminal.
+	logf func(format string, args ...any)
+}
+
+// worker adalah fungsi yang dijalankan oleh setiap Goroutine (satu koneksi HTTP).
+// Worker terus mengambil segmen dari tracker (termasuk hasil mencuri separuh segmen worker
+// lain) sampai tidak ada pekerjaan tersisa.
+// 'ctx': Context yang dibatalkan jika bagian lain gagal atau mendeteksi file di server berubah.
 // 'wg': Pointer ke WaitGroup untuk memberi tahu Goroutine utama ketika selesai.
 // 'errorCh': Channel untuk melaporkan error kembali ke Goroutine utama.
-func downloadPart(url string, partNum int, startByte, endByte int64, outputFile string, wg *sync.WaitGroup, errorCh chan error) {
+func (job *partJob) worker(ctx context.Context, wg *sync.WaitGroup, errorCh chan error) {
 	defer wg.Done() // Pastikan wg.Done() dipanggil ketika Goroutine ini selesai, baik sukses atau error.
 
-	fmt.Printf("[Bagian %d] Memulai download dari byte %d sampai %d...\n", partNum, startByte, endByte)
+	for ctx.Err() == nil {
+		part, ok := job.tracker.claim()
+		if !ok {
+			return
+		}
+		err := job.downloadPart(ctx, part.Index)
+		job.tracker.release(part.Index)
+		if err != nil {
+			errorCh <- err
+			return
+		}
+	}
+}
+
+// downloadPart mengunduh satu segmen sampai selesai.
+// Jika percobaan gagal karena error jaringan atau status 5xx/429, segmen ini dicoba lagi
+// (dengan jeda backoff) dari byte terakhir yang sudah tertulis, sampai batas retry.MaxAttempts.
+func (job *partJob) downloadPart(ctx context.Context, partNum int) error {
+	if part := job.tracker.segment(partNum); part.Written > 0 && part.remaining() <= 0 {
+		job.logf("[Bagian %d] Sudah lengkap dari download sebelumnya.\n", partNum)
+		return nil
+	}
+
+	var prev *mirror // Mirror percobaan sebelumnya; percobaan berikutnya memilih mirror lain jika ada
+	for attempt := 1; ; attempt++ {
+		// Ambil status terbaru: lanjutkan dari byte terakhir yang sudah tertulis,
+		// sampai ujung segmen yang mungkin sudah dipendekkan.
+		part := job.tracker.segment(partNum)
+		if part.remaining() <= 0 {
+			return nil
+		}
+		// Slot koneksi hanya dipegang selama request berjalan, tidak selama jeda retry.
+		if err := job.conns.acquire(ctx); err != nil {
+			return fmt.Errorf("bagian %d dibatalkan: %v", partNum, err)
+		}
+		conn := job.mirrors.pick(prev)
+		err := job.fetchPart(ctx, part, conn)
+		job.conns.release()
+		job.mirrors.done(ctx, conn, err)
+		if err == nil {
+			return nil
+		}
+		prev = conn.m
+		if errors.Is(err, errMirrorSlow) {
+			job.logf("[Bagian %d] %v, dilanjutkan dari mirror lain.\n", partNum, err)
+			attempt-- // Bukan kegagalan: tidak mengurangi jatah percobaan
+			continue
+		}
+		var re *retryableError
+		var de *diskError
+		if !errors.As(err, &re) && !errors.As(err, &de) && ctx.Err() == nil && job.mirrors.disable(conn.m, err) {
+			// Error dari satu mirror (misalnya 404 atau file yang berbeda): mirror itu tidak
+			// dipakai lagi dan bagian ini langsung dilanjutkan dari mirror lain.
+			job.logf("[Bagian %d] Mirror %s tidak dipakai lagi: %v\n", partNum, mirrorHost(conn.m.url), err)
+			attempt--
+			continue
+		}
+		delay, retry := job.retry.next(ctx, attempt, err)
+		if retry && job.mirrors.hasAlternative(conn.m) {
+			delay = 0 // Mirror lain tidak perlu menunggu backoff mirror yang gagal
+		}
+		if !retry {
+			if attempt > 1 {
+				err = fmt.Errorf("%w (setelah %d percobaan)", err, attempt)
+			}
+			return err
+		}
+		job.logf("[Bagian %d] Percobaan %d gagal: %v. Mencoba lagi dalam %v...\n", partNum, attempt, err, delay.Round(time.Millisecond))
+		if !sleepContext(ctx, delay) {
+			return fmt.Errorf("bagian %d dibatalkan: %v", partNum, ctx.Err())
+		}
+	}
+}
+
+// fetchPart melakukan satu kali request ke mirror 'conn' untuk sisa byte bagian 'part'
+// dan menulisnya ke tujuan.
+func (job *partJob) fetchPart(ctx context.Context, part partState, conn *mirrorConn) error {
+	partNum := part.Index
+	startByte, endByte := part.Start+part.Written, part.End
+	source := ""
+	if job.mirrors.len() > 1 {
+		source = " dari " + mirrorHost(conn.m.url)
+	}
+	if part.Written > 0 {
+		job.logf("[Bagian %d] Melanjutkan dari byte %d sampai %d%s (%d bytes sudah ada)...\n", partNum, startByte, endByte, source, part.Written)
+	} else {
+		job.logf("[Bagian %d] Memulai download dari byte %d sampai %d%s...\n", partNum, startByte, endByte, source)
+	}
+
+	// Request punya context sendiri agar detektor koneksi macet bisa memutusnya
+	// tanpa membatalkan bagian lain.
+	reqCtx, cancelReq := context.WithCancel(ctx)
+	defer cancelReq()
 
 	// Membuat HTTP Request baru dengan metode GET.
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := newFileRequest(reqCtx, "GET", conn.m.target, conn.m.headers)
 	if err != nil {
 		// Menggunakan %v untuk menampilkan error, menghindari masalah %w jika tidak ada error yang dibungkus.
-		errorCh <- fmt.Errorf("gagal membuat request HTTP untuk bagian %d: %v", partNum, err)
-		return
+		return fmt.Errorf("gagal membuat request HTTP untuk bagian %d: %v", partNum, err)
 	}
 
 	// Menambahkan header "Range" untuk meminta sebagian file saja dari server.
 	// Contoh format: Range: bytes=0-999 (untuk 1000 byte pertama)
 	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", startByte, endByte))
+	// Saat melanjutkan, If-Range meminta server mengirim seluruh file (status 200)
+	// jika file sudah berubah, alih-alih potongan yang tidak cocok dengan data lama.
+	// Validator diambil dari mirror yang sama, karena Last-Modified bisa berbeda antar mirror.
+	validator := conn.m.info.validator()
+	resuming := part.Written > 0 && validator != ""
+	if resuming {
+		req.Header.Set("If-Range", validator)
+	}
 
-	// Melakukan request HTTP menggunakan HTTP client default.
-	client := http.DefaultClient
-	resp, err := client.Do(req)
+	// Melakukan request HTTP menggunakan client dari konfigurasi (default: http.DefaultClient).
+	resp, err := job.client.Do(req)
 	if err != nil {
-		errorCh <- fmt.Errorf("gagal melakukan request HTTP untuk bagian %d: %v", partNum, err)
-		return
+		return &retryableError{err: fmt.Errorf("gagal melakukan request HTTP untuk bagian %d: %w", partNum, err)}
 	}
 	defer resp.Body.Close() // Pastikan body response ditutup setelah selesai membaca.
 
 	// Memeriksa status kode HTTP dari respons server.
 	// Kode 206 (Partial Content) berarti server berhasil mengirim sebagian file.
-	// Kode 200 (OK) bisa terjadi jika server tidak mendukung Range Request dan mengirim seluruh file.
-	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
-		errorCh <- fmt.Errorf("server mengembalikan status %d untuk bagian %d", resp.StatusCode, partNum)
-		return
+	// Kode 200 (OK) berarti server mengirim seluruh file: karena If-Range tidak cocok
+	// (file berubah), atau karena server mengabaikan header Range.
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if err := checkContentRange(resp.Header.Get("Content-Range"), startByte, endByte, job.size); err != nil {
+			return fmt.Errorf("bagian %d: %w", partNum, err)
+		}
+	case http.StatusOK:
+		if resuming && !conn.m.info.sameRepresentation(resp.Header) {
+			return fmt.Errorf("bagian %d: %w", partNum, errRemoteChanged)
+		}
+		// Seluruh file hanya bisa dipakai jika bagian ini memang mencakup seluruh file.
+		if startByte != 0 || endByte != job.size-1 {
+			return fmt.Errorf("bagian %d: %w", partNum, errRangesUnsupported)
+		}
+	default:
+		return statusError(resp, fmt.Errorf("server mengembalikan status %d untuk bagian %d", resp.StatusCode, partNum))
 	}
 
-	// Membuat file sementara di disk untuk menyimpan bagian yang diunduh ini.
-	file, err := os.Create(outputFile)
+	// Membuka tujuan penulisan bagian ini, dimulai tepat setelah byte yang sudah diunduh.
+	file, outputFile, err := job.out.writerFor(part)
 	if err != nil {
-		errorCh <- fmt.Errorf("gagal membuat file %s untuk bagian %d: %v", outputFile, partNum, err)
-		return
+		return &diskError{err: fmt.Errorf("gagal membuka file %s untuk bagian %d: %v", outputFile, partNum, err)}
 	}
 	defer file.Close() // Pastikan file ditutup setelah selesai menulis.
 
-	// Menyalin data yang diunduh dari body response HTTP ke file sementara.
-	bytesWritten, err := io.Copy(file, resp.Body)
+	// Menyalin data yang diunduh dari body response HTTP ke file bagian,
+	// sambil mencatat progres ke manifest.
+	pw := &partWriter{w: file, index: partNum, off: startByte, tracker: job.tracker, hasher: job.hasher}
+	body := conn.reader(watchStall(resp.Body, job.stall, cancelReq))
+	bytesWritten, err := io.Copy(pw, limitReader(ctx, body, job.limiters...))
+	if errors.Is(err, errSegmentShrunk) {
+		// Sisa segmen sudah diambil worker lain; bagian kita sudah lengkap.
+		job.logf("[Bagian %d] Selesai lebih awal di byte %d (sisanya dikerjakan bagian lain).\n", partNum, pw.off-1)
+		return nil
+	}
+	if errors.Is(err, errMirrorSlow) {
+		return fmt.Errorf("bagian %d: %w", partNum, err)
+	}
 	if err != nil {
-		errorCh <- fmt.Errorf("gagal menulis data ke file %s untuk bagian %d: %v", outputFile, partNum, err)
-		return
+		err = fmt.Errorf("gagal menulis data ke file %s untuk bagian %d: %w", outputFile, partNum, err)
+		var de *diskError
+		if errors.As(err, &de) {
+			return err // Error disk (misalnya disk penuh) tidak akan hilang dengan mencoba lagi
+		}
+		return &retryableError{err: err} // Koneksi terputus di tengah jalan
 	}
 
-	fmt.Printf("[Bagian %d] Download selesai. Ukuran: %d bytes. Disimpan di: %s\n", partNum, bytesWritten, outputFile)
+	job.logf("[Bagian %d] Download selesai. Ukuran: %d bytes. Disimpan di: %s\n", partNum, part.Written+bytesWritten, outputFile)
+	return nil
 }
 
-// RunParallelDownloaderCLI adalah fungsi utama yang menjalankan aplikasi Parallel File Downloader CLI.
-// Fungsi ini diekspor (huruf awal kapital 'R') sehingga bisa dipanggil dari package 'main'.
-// Parameter 'reader' diperlukan untuk membaca input dari pengguna (URL, nama file, jumlah bagian).
-func RunParallelDownloaderCLI(reader *bufio.Reader) {
-	fmt.Println("\n--- Go Parallel File Downloader ---")
-
-	// --- Konfigurasi Awal (diambil dari input pengguna) ---
-	fmt.Print("Masukkan URL file (contoh: https://speed.cloudflare.com/__down?bytes=10000000): ")
-	fileURL, _ := reader.ReadString('\n')
-	fileURL = strings.TrimSpace(fileURL)
-
-	fmt.Print("Masukkan nama file output (contoh: downloaded_10MB.bin): ")
-	outputFileName, _ := reader.ReadString('\n')
-	outputFileName = strings.TrimSpace(outputFileName)
-
-	fmt.Print("Masukkan jumlah bagian paralel (contoh: 4): ")
-	numPartsStr, _ := reader.ReadString('\n')
-	numPartsStr = strings.TrimSpace(numPartsStr)
-	numParts, err := strconv.Atoi(numPartsStr)
-	if err != nil || numParts <= 0 {
-		fmt.Println("Jumlah bagian tidak valid atau kosong. Menggunakan default 4.")
-		numParts = 4
+// probeRemote melakukan HEAD request untuk mendapatkan ukuran file dan validator (ETag/Last-Modified)
+// tanpa mengunduh seluruh body. Jika server menolak HEAD (403/405/501), metadata diambil
+// dengan GET satu byte (lihat probeRange).
+func probeRemote(ctx context.Context, cfg downloadConfig) (remoteInfo, error) {
+	req, err := newFileRequest(ctx, "HEAD", cfg.URL, cfg.Headers)
+	if err != nil {
+		return remoteInfo{}, fmt.Errorf("gagal membuat HEAD request: %v", err)
 	}
-
-	fmt.Printf("Mencoba mengunduh file dari: %s\n", fileURL)
-	fmt.Printf("Menggunakan %d Goroutine paralel.\n", numParts)
-
-	// --- Step 1: Mendapatkan Ukuran File Total (Metadata) ---
-	// Melakukan HEAD request untuk mendapatkan header file tanpa mengunduh seluruh body.
-	resp, err := http.Head(fileURL) // http.Head() hanya mengambil header, lebih cepat.
+	resp, err := cfg.httpClient().Do(req) // HEAD hanya mengambil header, lebih cepat.
 	if err != nil {
-		fmt.Printf("Error: Gagal mendapatkan header file: %v\n", err)
-		return // Keluar dari fungsi jika gagal mendapatkan header
+		return remoteInfo{}, fmt.Errorf("gagal mendapatkan header file: %v", err)
 	}
 	defer resp.Body.Close() // Pastikan body response ditutup setelah selesai.
 
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		// Banyak server (dan URL bertanda tangan yang hanya berlaku untuk GET) menolak HEAD.
+		cfg.logf("Server menolak HEAD (status %d). Mencoba GET dengan Range: bytes=0-0...\n", resp.StatusCode)
+		return probeRange(ctx, cfg)
+	}
+
 	// Memeriksa apakah server mengembalikan status OK (200).
 	// Ini penting untuk memastikan file ditemukan sebelum mencoba mengunduh.
 	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Error: Server mengembalikan status %d. File mungkin tidak ditemukan atau tidak dapat diakses.\n", resp.StatusCode)
-		return // Keluar jika status bukan OK
+		return remoteInfo{}, fmt.Errorf("server mengembalikan status %d. File mungkin tidak ditemukan atau tidak dapat diakses", resp.StatusCode)
 	}
 
-	// Mendapatkan ukuran file dari header "Content-Length".
-	contentLengthStr := resp.Header.Get("Content-Length")
-	if contentLengthStr == "" {
-		fmt.Println("Error: Header Content-Length tidak ditemukan. Tidak bisa menentukan ukuran file.")
-		return // Keluar jika ukuran file tidak diketahui
+	// Mendapatkan ukuran file dari header "Content-Length". Jika tidak ada (misalnya
+	// respons chunked), ukuran dianggap tidak diketahui dan file diunduh dengan satu koneksi.
+	fileSize, err := contentLength(resp.Header)
+	if err != nil {
+		return remoteInfo{}, err
+	}
+	return remoteInfoFrom(resp, fileSize, resp.Header.Get("Accept-Ranges")), nil
+}
+
+// probeRange mengambil metadata file dengan GET "Range: bytes=0-0" untuk server yang menolak
+// HEAD. Ukuran file dibaca dari Content-Range respons 206 ("bytes 0-0/<ukuran>"). Body satu
+// byte itu dibaca habis agar koneksinya kembali ke pool dan dipakai ulang oleh bagian pertama.
+// Jika server membalas 200 (mengabaikan Range), file diunduh dengan satu koneksi.
+func probeRange(ctx context.Context, cfg downloadConfig) (remoteInfo, error) {
+	req, err := newFileRequest(ctx, "GET", cfg.URL, cfg.Headers)
+	if err != nil {
+		return remoteInfo{}, fmt.Errorf("gagal membuat GET request: %v", err)
 	}
-	fileSize, err := strconv.ParseInt(contentLengthStr, 10, 64) // Konversi string ke integer 64-bit
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := cfg.httpClient().Do(req)
 	if err != nil {
-		fmt.Printf("Error: Gagal mengkonversi Content-Length ke angka: %v\n", err)
-		return // Keluar jika konversi gagal
+		return remoteInfo{}, fmt.Errorf("gagal mendapatkan header file: %v", err)
 	}
-	fmt.Printf("Ukuran file total: %d bytes\n", fileSize)
+	defer resp.Body.Close()
 
-	// --- Step 2: Menghitung Rentang Byte untuk Setiap Bagian ---
-	partSize := fileSize / int64(numParts) // Ukuran ideal setiap bagian
-	var wg sync.WaitGroup                  // WaitGroup untuk menunggu semua Goroutine pengunduh selesai
-	// Channel buffered untuk mengumpulkan error dari Goroutine.
-	// Buffer sebesar 'numParts' agar Goroutine tidak blocking saat mengirim error.
-	errorCh := make(chan error, numParts)
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, end, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != 0 || end != 0 {
+			return remoteInfo{}, fmt.Errorf("%w: diminta bytes 0-0, diterima '%s'", errBadContentRange, resp.Header.Get("Content-Range"))
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		// Content-MD5 pada respons 206 adalah hash potongan satu byte itu, bukan seluruh file.
+		resp.Header.Del("Content-MD5")
+		return remoteInfoFrom(resp, size, "bytes"), nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		// File kosong: tidak ada byte 0. Server mengirim "Content-Range: bytes */0".
+		if total, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes */"); ok && strings.TrimSpace(total) == "0" {
+			return remoteInfoFrom(resp, 0, "bytes"), nil
+		}
+	case http.StatusOK:
+		// Server mengabaikan Range dan mulai mengirim seluruh file. Body tidak dibaca; koneksi
+		// ini ditutup dan file diunduh ulang dengan satu koneksi.
+		fileSize, err := contentLength(resp.Header)
+		if err != nil {
+			return remoteInfo{}, err
+		}
+		return remoteInfoFrom(resp, fileSize, "none"), nil
+	}
+	return remoteInfo{}, fmt.Errorf("server mengembalikan status %d. File mungkin tidak ditemukan atau tidak dapat diakses", resp.StatusCode)
+}
 
-	// Membuat slice (array dinamis) untuk menyimpan nama file sementara dari setiap bagian.
-	tempFiles := make([]string, numParts)
+// contentLength membaca header Content-Length. Mengembalikan -1 jika header tidak ada.
+func contentLength(h http.Header) (int64, error) {
+	value := h.Get("Content-Length")
+	if value == "" {
+		return -1, nil
+	}
+	size, err := strconv.ParseInt(value, 10, 64) // Konversi string ke integer 64-bit
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("Content-Length '%s' tidak valid", value)
+	}
+	return size, nil
+}
 
-	// Memulai Goroutine untuk setiap bagian file
+// remoteInfoFrom menyusun metadata file dari header respons probe.
+func remoteInfoFrom(resp *http.Response, size int64, acceptRanges string) remoteInfo {
+	return remoteInfo{
+		Size:         size,
+		AcceptRanges: strings.ToLower(strings.TrimSpace(acceptRanges)),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Digests:      digestsFromHeaders(resp.Header),
+		FinalURL:     resp.Request.URL.String(),
+		Filename:     filenameFromContentDisposition(resp.Header.Get("Content-Disposition")),
+	}
+}
+
+// newManifest menghitung rentang byte untuk setiap bagian dan membuat manifest baru.
+func newManifest(cfg downloadConfig, info remoteInfo) *downloadManifest {
+	m := &downloadManifest{
+		URL:          cfg.URL,
+		Output:       cfg.Output,
+		Mode:         cfg.writeMode(),
+		Size:         info.Size,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+	}
+	if m.Mode == writeModeDirect {
+		m.TempFile = tempOutputPath(cfg.Output)
+	}
+	numParts := cfg.NumParts
+	if int64(numParts) > info.Size {
+		numParts = int(max(info.Size, 1)) // File sangat kecil: tidak perlu lebih banyak bagian daripada byte
+	}
+	partSize := info.Size / int64(numParts) // Ukuran ideal setiap bagian
 	for i := 0; i < numParts; i++ {
 		startByte := int64(i) * partSize
 		endByte := startByte + partSize - 1 // Byte akhir bagian ini (inklusif)
@@ -149,17 +525,354 @@ func RunParallelDownloaderCLI(reader *bufio.Reader) {
 		// Untuk bagian terakhir, pastikan mencakup sisa byte yang mungkin ada
 		// agar tidak ada data yang terlewat.
 		if i == numParts-1 {
-			endByte = fileSize - 1
+			endByte = info.Size - 1
+		}
+		part := partState{Index: i, Start: startByte, End: endByte}
+		if m.Mode == writeModePartFiles {
+			// Nama file sementara untuk setiap bagian (misal: my_file.zip.part0)
+			part.File = fmt.Sprintf("%s.part%d", cfg.Output, i)
+		}
+		m.Parts = append(m.Parts, part)
+	}
+	return m
+}
+
+// removeArtifacts menghapus semua file bagian, file sementara, dan manifest milik sebuah download.
+func removeArtifacts(m *downloadManifest, mPath string) {
+	for _, p := range m.Parts {
+		if p.File != "" {
+			os.Remove(p.File)
+		}
+	}
+	if m.TempFile != "" {
+		os.Remove(m.TempFile)
+	}
+	os.Remove(mPath)
+}
+
+// removePartial menghapus sisa download yang gagal: file bagian dan manifest (dari 'm' jika
+// ada, atau dari manifest di disk) serta file sementara <output>.download.
+func removePartial(cfg downloadConfig, m *downloadManifest) {
+	mPath := manifestPath(cfg.Output)
+	if m != nil {
+		removeArtifacts(m, mPath)
+	} else {
+		discardManifest(mPath)
+	}
+	os.Remove(tempOutputPath(cfg.Output))
+	cfg.logf("File sementara download yang gagal dihapus.\n")
+}
+
+// prepareManifest memuat manifest lama jika masih cocok dengan file di server, atau
+// membuat manifest baru. Jumlah byte per bagian dicocokkan dengan ukuran file bagian di
+// disk, karena manifest disimpan berkala dan bisa sedikit tertinggal dari isi file.
+func prepareManifest(cfg downloadConfig, info remoteInfo) (m *downloadManifest, resumed bool) {
+	mPath := manifestPath(cfg.Output)
+	old, err := loadManifest(mPath)
+	if err != nil {
+		cfg.logf("Peringatan: %v. Memulai download dari awal.\n", err)
+	}
+	if old == nil {
+		return newManifest(cfg, info), false
+	}
+	if !old.matches(cfg.URL, info) {
+		cfg.logf("File di server berbeda dengan download sebelumnya. Memulai ulang dari awal.\n")
+		removeArtifacts(old, mPath)
+		return newManifest(cfg, info), false
+	}
+	if old.Mode != cfg.writeMode() {
+		cfg.logf("Download sebelumnya memakai mode '%s', sekarang '%s'. Memulai ulang dari awal.\n", old.Mode, cfg.writeMode())
+		removeArtifacts(old, mPath)
+		return newManifest(cfg, info), false
+	}
+	if info.validator() == "" {
+		cfg.logf("Peringatan: server tidak mengirim ETag/Last-Modified; perubahan file hanya dicek dari ukurannya.\n")
+	}
+
+	if old.Mode == writeModeDirect {
+		// Pada mode direct semua bagian berbagi satu file, jadi progres hanya bisa diambil
+		// dari manifest. Manifest disimpan setelah data di-sync ke disk, sehingga angka
+		// Written tidak pernah melebihi data yang benar-benar ada (paling-paling tertinggal).
+		if st, err := os.Stat(old.TempFile); err != nil || st.Size() != old.Size {
+			cfg.logf("File sementara hilang atau ukurannya berbeda. Memulai ulang dari awal.\n")
+			for i := range old.Parts {
+				old.Parts[i].Written = 0
+			}
+			return old, false
+		}
+		for i := range old.Parts {
+			p := &old.Parts[i]
+			p.Written = min(max(p.Written, 0), p.length())
+		}
+		return old, true
+	}
+
+	for i := range old.Parts {
+		p := &old.Parts[i]
+		p.Written = 0
+		if st, err := os.Stat(p.File); err == nil {
+			p.Written = min(st.Size(), p.length())
+			if st.Size() > p.length() {
+				os.Truncate(p.File, p.length()) // Buang byte berlebih di luar rentang bagian
+			}
+		}
+	}
+	return old, true
+}
+
+// runDownload mengunduh file secara paralel dan melanjutkan download sebelumnya jika
+// manifest-nya ada. Jika gagal setelah mulai menulis ke disk, file bagian, file sementara,
+// dan manifest dihapus, kecuali cfg.KeepPartial atau ctx dibatalkan (agar bisa dilanjutkan).
+func runDownload(ctx context.Context, cfg downloadConfig) (res Result, err error) {
+	started := time.Now()
+	res = Result{URL: cfg.URL, Output: cfg.Output}
+	var (
+		mirrors *mirrorSet
+		m       *downloadManifest
+		writing bool // Sudah mulai menulis ke disk; sisa download perlu dibersihkan jika gagal
+	)
+	defer func() {
+		if err != nil && writing && !cfg.KeepPartial && ctx.Err() == nil {
+			removePartial(cfg, m)
+		}
+	}()
+
+	// finish memeriksa checksum file yang sudah lengkap lalu melengkapi hasil download.
+	finish := func(sums map[string][]byte, expected []expectedDigest) (Result, error) {
+		if err := verifyOutput(cfg, sums, expected); err != nil {
+			return res, err
+		}
+		res.Size = fileSizeOf(cfg.Output)
+		res.Checksums = make(map[string]string, len(sums))
+		for algo, sum := range sums {
+			res.Checksums[algo] = hex.EncodeToString(sum)
 		}
+		if !res.SingleStream {
+			res.Mirrors = mirrors.stats()
+		}
+		res.Elapsed = time.Since(started)
+		return res, nil
+	}
+	// singleStream mengunduh seluruh file dengan satu koneksi dari mirror acuan.
+	singleStream := func(info remoteInfo, expected []expectedDigest) (Result, error) {
+		res.SingleStream = true
+		if err := checkFreeSpace(cfg.Output, info.Size); err != nil {
+			return res, err
+		}
+		writing = true
+		streamCfg := cfg
+		streamCfg.URL, streamCfg.Headers = mirrors.primary().target, mirrors.primary().headers
+		sums, err := downloadSingleStream(ctx, streamCfg, info.Size, digestAlgos(expected))
+		if err != nil {
+			return res, err
+		}
+		writing = false // File output sudah lengkap; checksum yang salah ditangani handleMismatch
+		return finish(sums, expected)
+	}
+
+	// --- Step 1: Mendapatkan Ukuran File Total (Metadata) ---
+	mirrors, info, err := probeMirrors(ctx, cfg)
+	if err != nil {
+		return res, err
+	}
+	res.ETag = info.ETag
+	if cfg.Output == "" {
+		cfg.Output = filepath.Join(cfg.OutputDir, outputNameFor(cfg.URL, info))
+		cfg.logf("Nama file output: %s\n", cfg.Output)
+	}
+	output, skip, err := resolveCollision(cfg.Output, cfg.URL, cfg.OnExist)
+	if err != nil {
+		return res, err
+	}
+	if output != cfg.Output {
+		cfg.logf("File %s sudah ada. Disimpan sebagai %s.\n", cfg.Output, output)
+	}
+	cfg.Output, res.Output = output, output
+	if skip {
+		cfg.logf("File %s sudah ada. Download dilewati.\n", cfg.Output)
+		res.Skipped = true
+		res.Size = fileSizeOf(cfg.Output)
+		res.Elapsed = time.Since(started)
+		return res, nil
+	}
+	if info.Size >= 0 {
+		cfg.logf("Ukuran file total: %d bytes\n", info.Size)
+	}
+
+	expected, err := expectedDigests(ctx, cfg, info)
+	if err != nil {
+		return res, err
+	}
+
+	mPath := manifestPath(cfg.Output)
+	if reason := info.rangeUnsupportedReason(); reason != "" {
+		cfg.logf("Mengunduh dengan satu koneksi: %s.\n", reason)
+		discardManifest(mPath)
+		return singleStream(info, expected)
+	}
+
+	var resumed bool
+	m, resumed = prepareManifest(cfg, info)
+	if resumed {
+		var done int64
+		for _, p := range m.Parts {
+			done += p.Written
+		}
+		res.Resumed = true
+		cfg.logf("Melanjutkan download sebelumnya: %d dari %d bytes sudah ada.\n", done, info.Size)
+	}
+	if err := checkFreeSpace(cfg.Output, requiredSpace(m)); err != nil {
+		return res, err
+	}
+	writing = true
+
+	// Jika file di server berubah di tengah jalan, ulangi sekali dari awal.
+	var sums map[string][]byte
+	for attempt := 0; ; attempt++ {
+		sums, err = downloadAllParts(ctx, cfg, mirrors, m, mPath, digestAlgos(expected))
+		if errors.Is(err, errRemoteChanged) && attempt == 0 {
+			cfg.logf("\nFile di server berubah. Menghapus bagian lama dan memulai ulang dari awal...\n")
+			removeArtifacts(m, mPath)
+			res.Resumed = false
+			if mirrors, info, err = probeMirrors(ctx, cfg); err != nil {
+				return res, err
+			}
+			res.ETag = info.ETag
+			if expected, err = expectedDigests(ctx, cfg, info); err != nil {
+				return res, err
+			}
+			if reason := info.rangeUnsupportedReason(); reason != "" {
+				cfg.logf("Mengunduh dengan satu koneksi: %s.\n", reason)
+				return singleStream(info, expected)
+			}
+			m = newManifest(cfg, info)
+			if err := checkFreeSpace(cfg.Output, requiredSpace(m)); err != nil {
+				return res, err
+			}
+			continue
+		}
+		if errors.Is(err, errRangesUnsupported) {
+			cfg.logf("\nServer mengabaikan Range request. Beralih ke download dengan satu koneksi...\n")
+			removeArtifacts(m, mPath)
+			return singleStream(info, expected)
+		}
+		if err != nil {
+			return res, err
+		}
+		break
+	}
+
+	if m.Mode == writeModeDirect {
+		// Semua byte sudah berada di posisinya; cukup ganti nama file sementara.
+		if err := os.Rename(m.TempFile, cfg.Output); err != nil {
+			return res, &diskError{err: fmt.Errorf("gagal mengganti nama %s menjadi %s: %v", m.TempFile, cfg.Output, err)}
+		}
+		cfg.logf("\nSemua bagian berhasil diunduh langsung ke file output.\n")
+	} else {
+		cfg.logf("\nSemua bagian berhasil diunduh. Memulai penggabungan...\n")
+		if err := mergeParts(cfg, m); err != nil {
+			return res, err
+		}
+	}
+	os.Remove(mPath) // Download selesai, manifest tidak diperlukan lagi
+	writing = false
+	return finish(sums, expected)
+}
+
+// verifyOutput memeriksa checksum file output yang sudah lengkap (jika ada digest yang diharapkan).
+func verifyOutput(cfg downloadConfig, sums map[string][]byte, expected []expectedDigest) error {
+	if len(expected) > 0 {
+		if err := verifyDigests(sums, expected); err != nil {
+			handleMismatch(cfg)
+			return err
+		}
+		for _, e := range expected {
+			cfg.logf("Checksum %s cocok (%s).\n", e.Algo, e.Source)
+		}
+	}
+	return nil
+}
+
+// expectedDigests menggabungkan checksum dari pengguna dengan digest dari header server.
+func expectedDigests(ctx context.Context, cfg downloadConfig, info remoteInfo) ([]expectedDigest, error) {
+	var expected []expectedDigest
+	user, err := resolveExpectedDigest(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		expected = append(expected, *user)
+	}
+	return append(expected, info.Digests...), nil
+}
+
+// digestAlgos mengembalikan daftar algoritma unik yang perlu dihitung.
+func digestAlgos(expected []expectedDigest) []string {
+	var algos []string
+	seen := make(map[string]bool)
+	for _, e := range expected {
+		if !seen[e.Algo] {
+			seen[e.Algo] = true
+			algos = append(algos, e.Algo)
+		}
+	}
+	return algos
+}
+
+// downloadAllParts menjalankan cfg.NumParts worker yang mengunduh semua segmen yang belum
+// lengkap dan menunggu semuanya selesai. Progres disimpan ke manifest secara berkala.
+// Jika 'algos' tidak kosong, hash seluruh file dihitung selama download dan dikembalikan per algoritma.
+func downloadAllParts(ctx context.Context, cfg downloadConfig, mirrors *mirrorSet, m *downloadManifest, mPath string, algos []string) (map[string][]byte, error) {
+	tracker := &manifestTracker{path: mPath, m: m, dirty: true}
+	out, err := openPartOutput(m, tracker)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close() // Ditutup setelah penyimpanan manifest terakhir di bawah (defer LIFO)
+
+	tracker.syncData = out.sync
+	if err := tracker.flush(); err != nil {
+		return nil, err
+	}
+	stopFlush := make(chan struct{})
+	go tracker.autoFlush(manifestFlushInterval, stopFlush)
+	defer func() {
+		close(stopFlush)
+		tracker.flush() // Simpan progres terakhir, baik sukses maupun gagal
+	}()
+
+	var hasher *hashFrontier
+	if len(algos) > 0 {
+		hasher = newHashFrontier(algos, out, m.Size, tracker.availableAt)
+		defer hasher.close()
+	}
+
+	progress := newProgressReporter(cfg, m.Size, tracker.progress)
+	tracker.logf = progress.logf
+	job := &partJob{
+		mirrors: mirrors, out: out, tracker: tracker, hasher: hasher, retry: cfg.Retry, size: m.Size, conns: cfg.Conns,
+		limiters: []*rateLimiter{cfg.RateLimit, cfg.SharedRateLimit}, client: cfg.httpClient(),
+		stall: cfg.StallTimeout, logf: progress.logf,
+	}
 
-		// Membuat nama file sementara untuk setiap bagian (misal: my_file.zip.part0)
-		tempFileName := fmt.Sprintf("%s.part%d", outputFileName, i)
-		tempFiles[i] = tempFileName // Simpan nama file sementara di slice
+	// Context dibatalkan begitu satu bagian gagal, agar bagian lain tidak membuang waktu.
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
+	var wg sync.WaitGroup // WaitGroup untuk menunggu semua Goroutine pengunduh selesai
+	workers := max(cfg.NumParts, 1)
+	// Channel buffered untuk mengumpulkan error dari Goroutine.
+	// Buffer sebesar jumlah worker agar Goroutine tidak blocking saat mengirim error.
+	errorCh := make(chan error, workers)
+
+	// Memulai Goroutine untuk setiap worker
+	progress.start()
+	for i := 0; i < workers; i++ {
 		wg.Add(1) // Menambahkan 1 ke WaitGroup untuk setiap Goroutine yang akan dibuat
-		// Menjalankan fungsi downloadPart sebagai Goroutine.
-		// Setiap Goroutine akan mengunduh bagiannya secara paralel.
-		go downloadPart(fileURL, i, startByte, endByte, tempFileName, &wg, errorCh)
+		// Menjalankan worker sebagai Goroutine.
+		// Setiap worker mengunduh segmen-segmen secara paralel.
+		go job.worker(ctx, &wg, errorCh)
 	}
 
 	// Goroutine terpisah untuk menutup channel error.
@@ -170,59 +883,291 @@ func RunParallelDownloaderCLI(reader *bufio.Reader) {
 		close(errorCh) // Setelah semua selesai, tutup channel error
 	}()
 
-	// --- Step 3: Menunggu Semua Goroutine Selesai & Menangani Error ---
-	// Menerima error dari Goroutine melalui channel.
-	// Loop ini akan berjalan sampai channel 'errorCh' ditutup.
+	// --- Menunggu Semua Goroutine Selesai & Menangani Error ---
 	var downloadErrors []error // Slice untuk menyimpan semua error yang terjadi
 	for err := range errorCh { // Menerima error dari channel
 		downloadErrors = append(downloadErrors, err) // Tambahkan error ke slice
+		cancel()
 	}
-
-	// Jika ada error yang terkumpul, tampilkan dan keluar.
-	if len(downloadErrors) > 0 {
-		fmt.Println("\nError saat mengunduh bagian:")
-		for _, err := range downloadErrors {
-			fmt.Println("-", err) // Tampilkan setiap error
+	progress.finish()
+	if len(downloadErrors) == 0 {
+		if hasher == nil {
+			return nil, nil
 		}
-		fmt.Println("Download gagal karena error pada satu atau lebih bagian.")
-		// Hapus file-file sementara yang mungkin sudah terunduh sebagian
-		for _, f := range tempFiles {
-			os.Remove(f) // Hapus file sementara
+		return hasher.finish()
+	}
+	for _, err := range downloadErrors {
+		if errors.Is(err, errRemoteChanged) || errors.Is(err, errRangesUnsupported) {
+			return nil, err
 		}
-		return // Keluar dari fungsi jika ada error
 	}
 
-	fmt.Println("\nSemua bagian berhasil diunduh. Memulai penggabungan...")
+	if parent.Err() != nil {
+		// Dihentikan dari luar (misalnya job antrean dijeda): error setiap bagian hanya akibatnya.
+		return nil, parent.Err()
+	}
+
+	cfg.logf("\nError saat mengunduh bagian:\n")
+	for _, err := range downloadErrors {
+		cfg.logf("- %v\n", err) // Tampilkan setiap error
+	}
+	return nil, fmt.Errorf("download gagal karena error pada %d bagian: %w", len(downloadErrors), downloadErrors[0])
+}
 
-	// --- Step 4: Menggabungkan Bagian-bagian File ---
+// mergeParts menggabungkan file-file bagian secara berurutan menjadi file output. Jika gagal
+// di tengah jalan, file akhir yang belum lengkap dihapus; bagian yang sudah digabungkan (dan
+// dihapus) akan diunduh ulang jika download dilanjutkan.
+func mergeParts(cfg downloadConfig, m *downloadManifest) (err error) {
+	outputFileName := cfg.Output
 	// Membuat file akhir yang akan berisi gabungan semua bagian.
 	finalFile, err := os.Create(outputFileName)
 	if err != nil {
-		fmt.Printf("Error: Gagal membuat file akhir %s: %v\n", outputFileName, err)
-		return // Keluar jika gagal membuat file akhir
+		return &diskError{err: fmt.Errorf("gagal membuat file akhir %s: %v", outputFileName, err)}
 	}
-	defer finalFile.Close() // Pastikan file akhir ditutup setelah selesai.
+	defer func() {
+		// Error saat menutup (misalnya disk penuh saat data terakhir ditulis) juga berarti gagal.
+		if cerr := finalFile.Close(); cerr != nil && err == nil {
+			err = &diskError{err: fmt.Errorf("gagal menyimpan file akhir %s: %v", outputFileName, cerr)}
+		}
+		if err != nil {
+			os.Remove(outputFileName) // File setengah jadi tidak boleh terlihat seperti hasil yang lengkap
+		}
+	}()
 
-	// Menggabungkan setiap bagian file secara berurutan.
-	for i := 0; i < numParts; i++ {
-		partFileName := tempFiles[i]
+	// Menggabungkan setiap bagian file secara berurutan. Urutan di manifest belum tentu
+	// urutan di file, karena segmen hasil pemecahan ditambahkan di akhir daftar.
+	parts := append([]partState(nil), m.Parts...)
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Start < parts[j].Start })
+	for _, part := range parts {
+		if part.length() == 0 {
+			continue // File kosong: bagian tidak punya data
+		}
+		partFileName := part.File
 		partFile, err := os.Open(partFileName) // Membuka file bagian sementara
 		if err != nil {
-			fmt.Printf("Error: Gagal membuka bagian %s: %v\n", partFileName, err)
-			return // Keluar jika gagal membuka bagian
+			return &diskError{err: fmt.Errorf("gagal membuka bagian %s: %v", partFileName, err)}
 		}
-		defer partFile.Close() // Pastikan file bagian ditutup
 
-		// Menyalin isi dari file bagian ke file akhir.
+		// Menyalin isi dari file bagian ke file akhir. File bagian langsung ditutup agar
+		// bisa dihapus (di Windows file yang masih terbuka tidak bisa dihapus).
 		bytesCopied, err := io.Copy(finalFile, partFile)
+		partFile.Close()
 		if err != nil {
-			fmt.Printf("Error: Gagal menyalin data dari bagian %s ke file akhir: %v\n", partFileName, err)
-			return // Keluar jika gagal menyalin data
+			return &diskError{err: fmt.Errorf("gagal menyalin data dari bagian %s ke file akhir: %v", partFileName, err)}
 		}
-		fmt.Printf("Menggabungkan %s (%d bytes)...\n", partFileName, bytesCopied)
+		cfg.logf("Menggabungkan %s (%d bytes)...\n", partFileName, bytesCopied)
 		os.Remove(partFileName) // Hapus file bagian setelah berhasil digabungkan
 	}
+	return nil
+}
 
-	fmt.Printf("\n--- File '%s' berhasil diunduh dan digabungkan! ---\n", outputFileName)
-	fmt.Printf("Total ukuran file: %d bytes\n", fileSize) // Tampilkan ukuran total file yang diunduh
+// RunParallelDownloaderCLI adalah fungsi utama yang menjalankan aplikasi Parallel File Downloader CLI.
+// Fungsi ini diekspor (huruf awal kapital 'R') sehingga bisa dipanggil dari package 'main'.
+// Parameter 'reader' diperlukan untuk membaca input dari pengguna (URL, nama file, jumlah bagian).
+func RunParallelDownloaderCLI(reader *bufio.Reader) {
+	fmt.Println("\n--- Go Parallel File Downloader ---")
+
+	// --- Konfigurasi Awal (diambil dari input pengguna) ---
+	fmt.Print("Masukkan URL file (contoh: https://speed.cloudflare.com/__down?bytes=10000000): ")
+	fileURL, _ := reader.ReadString('\n')
+	fileURL = strings.TrimSpace(fileURL)
+
+	// Mirror opsional: URL lain untuk file yang sama, misalnya server internal di lokasi berbeda.
+	fmt.Print("Mirror tambahan untuk file yang sama (opsional, pisahkan dengan spasi): ")
+	mirrorsStr, _ := reader.ReadString('\n')
+	urls := append([]string{fileURL}, strings.Fields(mirrorsStr)...)
+
+	fmt.Print("Masukkan nama file output (contoh: downloaded_10MB.bin, kosong = nama dari server/URL): ")
+	outputFileName, _ := reader.ReadString('\n')
+	outputFileName = strings.TrimSpace(outputFileName)
+
+	// Default mengganti nama agar file yang sudah ada tidak tertimpa tanpa sengaja.
+	fmt.Print("Jika file output sudah ada: (t)impa, (l)ewati, atau (g)anti nama otomatis? [g]: ")
+	onExistStr, _ := reader.ReadString('\n')
+	onExist := ExistRename
+	switch strings.ToLower(strings.TrimSpace(onExistStr)) {
+	case "t":
+		onExist = ExistOverwrite
+	case "l":
+		onExist = ExistSkip
+	}
+
+	fmt.Print("Masukkan jumlah bagian paralel (contoh: 4): ")
+	numPartsStr, _ := reader.ReadString('\n')
+	numPartsStr = strings.TrimSpace(numPartsStr)
+	numParts, err := strconv.Atoi(numPartsStr)
+	if err != nil || numParts <= 0 {
+		fmt.Println("Jumlah bagian tidak valid atau kosong. Menggunakan default 4.")
+		numParts = 4
+	}
+
+	// Mode file bagian (.partN) hanya opsi; defaultnya bagian ditulis langsung ke file output.
+	fmt.Print("Simpan setiap bagian ke file .partN terpisah lalu gabungkan? (y/N): ")
+	partFilesStr, _ := reader.ReadString('\n')
+	partFiles := strings.EqualFold(strings.TrimSpace(partFilesStr), "y")
+
+	// Checksum bersifat opsional. Digest dari header server (Digest/Repr-Digest/Content-MD5)
+	// tetap diperiksa walaupun pengguna tidak mengisi apa pun.
+	fmt.Print("Masukkan checksum (opsional, contoh: sha256:<hex>, md5:<hex>, atau URL/path file .sha256sum): ")
+	checksum, _ := reader.ReadString('\n')
+	checksum = strings.TrimSpace(checksum)
+
+	fmt.Print("Jika checksum tidak cocok: (k)arantina ke .corrupt atau (h)apus file? [k]: ")
+	onMismatchStr, _ := reader.ReadString('\n')
+	onMismatch := MismatchQuarantine
+	if strings.EqualFold(strings.TrimSpace(onMismatchStr), "h") {
+		onMismatch = MismatchDelete
+	}
+
+	fmt.Print("Batas kecepatan download (opsional, contoh: 500K atau 2MB per detik): ")
+	rateStr, _ := reader.ReadString('\n')
+	var rateLimit int64
+	if rateStr = strings.TrimSpace(rateStr); rateStr != "" {
+		if rateLimit, err = parseByteRate(rateStr); err != nil {
+			fmt.Printf("%v. Download tanpa batas kecepatan.\n", err)
+			rateLimit = 0
+		} else if rateLimit > 0 {
+			fmt.Printf("Kecepatan dibatasi %s.\n", formatRate(rateLimit))
+		}
+	}
+
+	opts := Options{
+		Output: outputFileName, OnExist: onExist, Parts: numParts, PartFiles: partFiles, Checksum: checksum, OnMismatch: onMismatch, RateLimit: rateLimit,
+		KeepPartial: true, // Download yang gagal bisa dilanjutkan dengan menjalankannya lagi
+	}
+	fmt.Print("Atur koneksi lanjutan (header, autentikasi, proxy, TLS, timeout)? (y/N): ")
+	advancedStr, _ := reader.ReadString('\n')
+	if strings.EqualFold(strings.TrimSpace(advancedStr), "y") {
+		promptConnectionOptions(reader, &opts)
+	}
+	downloader, err := NewDownloader(opts)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Mencoba mengunduh file dari: %s\n", fileURL)
+	if len(urls) > 1 {
+		fmt.Printf("Dengan %d mirror tambahan.\n", len(urls)-1)
+	}
+	fmt.Printf("Menggunakan %d Goroutine paralel.\n", numParts)
+
+	// Ctrl+C selama download hanya membatalkan download ini, bukan seluruh aplikasi.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Hasil download dicatat di riwayat; URL yang sudah pernah diunduh dengan ETag yang sama
+	// perlu dikonfirmasi dulu.
+	history, err := openDownloadHistory(historyFilePath)
+	if err != nil {
+		fmt.Printf("Peringatan: %v. Download ini tidak dicatat di riwayat.\n", err)
+	} else if !confirmRedownload(ctx, reader, history, downloader, fileURL) {
+		fmt.Println("Download dibatalkan.")
+		return
+	}
+
+	fmt.Println("Tekan Ctrl+C untuk menghentikan download (progres tetap disimpan).")
+	res, err := downloader.DownloadFrom(ctx, urls...)
+	if history != nil && !res.Skipped {
+		if _, err := history.record(urls, res, err); err != nil {
+			fmt.Printf("Peringatan: %v\n", err)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		if errors.Is(err, errChecksumMismatch) {
+			return
+		}
+		if _, statErr := os.Stat(manifestPath(res.Output)); res.Output != "" && statErr == nil {
+			fmt.Println("Progres disimpan. Jalankan lagi dengan URL dan nama file yang sama untuk melanjutkan.")
+		}
+		return
+	}
+
+	if res.Skipped {
+		fmt.Printf("\n--- File '%s' sudah ada (%d bytes), tidak diunduh ulang. ---\n", res.Output, res.Size)
+		return
+	}
+	fmt.Printf("\n--- File '%s' berhasil diunduh! ---\n", res.Output)
+	fmt.Printf("Total ukuran file: %d bytes (%s)\n", res.Size, formatDuration(res.Elapsed)) // Tampilkan ukuran total file yang diunduh
+	if len(res.Mirrors) > 1 {
+		printMirrorStats(res.Mirrors)
+	}
+}
+
+// printMirrorStats menampilkan kontribusi dan kinerja setiap mirror setelah download selesai.
+func printMirrorStats(stats []MirrorStats) {
+	fmt.Println("\nStatistik mirror:")
+	for _, st := range stats {
+		status := "aktif"
+		if st.Disabled != "" {
+			status = "dihentikan: " + st.Disabled
+		}
+		fmt.Printf("- %s\n  %s, %d request, %d gagal, %d dipindah karena lambat, %s per koneksi (%s)\n",
+			st.URL, formatBytes(st.Bytes), st.Requests, st.Failures, st.Switches, formatBytes(int64(st.Speed))+"/s", status)
+	}
+}
+
+// promptConnectionOptions menanyakan pengaturan koneksi lanjutan. Input kosong berarti memakai default.
+func promptConnectionOptions(reader *bufio.Reader, opts *Options) {
+	readLine := func(prompt string) string {
+		fmt.Print(prompt)
+		line, _ := reader.ReadString('\n')
+		return strings.TrimSpace(line)
+	}
+
+	fmt.Println("Header tambahan, satu per baris dengan format 'Nama: nilai' (baris kosong untuk selesai):")
+	for {
+		line := readLine("  header> ")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			fmt.Println("  Format header harus 'Nama: nilai'.")
+			continue
+		}
+		if opts.Headers == nil {
+			opts.Headers = make(http.Header)
+		}
+		opts.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	// "user:password" untuk Basic auth, atau "Bearer <token>".
+	if auth := readLine("Autentikasi (user:password atau 'Bearer <token>', opsional): "); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			opts.BearerToken = strings.TrimSpace(token)
+		} else {
+			opts.Username, opts.Password, _ = strings.Cut(auth, ":")
+		}
+	}
+	if strings.EqualFold(readLine("Simpan dan kirim ulang cookie dari server? (y/N): "), "y") {
+		opts.CookieJar, _ = cookiejar.New(nil)
+	}
+	opts.Proxy = readLine("URL proxy (opsional, contoh: http://proxy:3128): ")
+	opts.CAFile = readLine("Path CA bundle PEM tambahan (opsional): ")
+	opts.InsecureSkipVerify = strings.EqualFold(readLine("Lewati verifikasi sertifikat TLS? Tidak aman (y/N): "), "y")
+
+	readSeconds := func(prompt string) time.Duration {
+		secs, err := strconv.Atoi(readLine(prompt))
+		if err != nil {
+			return 0 // Default
+		}
+		if secs <= 0 {
+			return -1 // Tanpa batas
+		}
+		return time.Duration(secs) * time.Second
+	}
+	opts.ConnectTimeout = readSeconds("Timeout koneksi dalam detik (default 30, 0 = tanpa batas): ")
+	opts.ResponseHeaderTimeout = readSeconds("Timeout menunggu respons server dalam detik (default 60, 0 = tanpa batas): ")
+	opts.StallTimeout = readSeconds("Putus dan ulangi bagian yang tidak menerima data selama ... detik (default 60, 0 = mati): ")
+}
+
+// fileSizeOf mengembalikan ukuran file di disk, atau 0 jika file tidak bisa dibaca.
+func fileSizeOf(path string) int64 {
+	st, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return st.Size()
 }
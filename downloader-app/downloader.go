@@ -0,0 +1,214 @@
+// mini-projects/downloader-app/downloader.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultNumParts adalah jumlah bagian paralel jika Options.Parts tidak diisi.
+const defaultNumParts = 4
+
+// Options mengatur cara Downloader mengunduh file. Semua field opsional.
+type Options struct {
+	// Output adalah path file hasil download. Jika kosong, nama file diambil dari header
+	// Content-Disposition atau path URL (setelah redirect), disanitasi, lalu disimpan di
+	// direktori OutputDir (atau direktori kerja).
+	Output    string
+	OutputDir string
+	// OnExist menentukan tindakan jika file output sudah ada: ExistOverwrite (default),
+	// ExistSkip, atau ExistRename ("file (1).zip", "file (2).zip", ...).
+	OnExist string
+	// Parts adalah jumlah koneksi paralel (default 4).
+	Parts int
+	// Headers ditambahkan ke setiap request, misalnya Authorization atau User-Agent.
+	Headers http.Header
+	// Username/Password mengirim autentikasi Basic; BearerToken mengirim "Authorization: Bearer".
+	Username    string
+	Password    string
+	BearerToken string
+	// Client dipakai untuk semua request file. Jika nil, client dibuat dari pengaturan
+	// koneksi di bawah; jika diisi, pengaturan tersebut diabaikan.
+	Client *http.Client
+	// CookieJar menyimpan cookie dari server (misalnya sesi login) untuk request berikutnya.
+	CookieJar http.CookieJar
+	// Proxy adalah URL proxy, misalnya "http://proxy.kantor:3128". Jika kosong, variabel
+	// lingkungan HTTP_PROXY/HTTPS_PROXY/NO_PROXY yang dipakai.
+	Proxy string
+	// CAFile adalah path CA bundle (PEM) tambahan untuk server dengan sertifikat internal.
+	CAFile string
+	// InsecureSkipVerify mematikan verifikasi sertifikat TLS. Hanya untuk pengujian.
+	InsecureSkipVerify bool
+	// Batas waktu koneksi (nol = default 30 detik, 60 detik, dan 90 detik; negatif = tanpa batas).
+	ConnectTimeout        time.Duration // Membuka koneksi TCP
+	ResponseHeaderTimeout time.Duration // Menunggu header respons setelah request terkirim
+	IdleTimeout           time.Duration // Koneksi keep-alive yang menganggur sebelum ditutup
+	// StallTimeout memutus dan mencoba ulang bagian yang tidak menerima data sama sekali
+	// selama durasi ini (nol = default 60 detik, negatif = mati).
+	StallTimeout time.Duration
+	// Timeout membatasi lama seluruh download, termasuk percobaan ulang (0 = tanpa batas).
+	Timeout time.Duration
+	// MaxAttempts adalah jumlah percobaan per bagian sebelum download dianggap gagal (default 5).
+	MaxAttempts int
+	// PartFiles menulis setiap bagian ke <output>.partN lalu menggabungkannya,
+	// alih-alih menulis langsung ke file output.
+	PartFiles bool
+	// KeepPartial menyimpan file bagian dan manifest jika download gagal, agar bisa dilanjutkan
+	// dengan memanggil Download lagi. Defaultnya dihapus. Download yang dibatalkan lewat ctx
+	// (atau Timeout) selalu disimpan.
+	KeepPartial bool
+	// Checksum yang diharapkan: "sha256:<hex>", hex saja, atau URL/path file checksum.
+	Checksum string
+	// OnMismatch menentukan nasib file output jika checksum tidak cocok: MismatchQuarantine
+	// (default, dipindahkan ke <output>.corrupt) atau MismatchDelete.
+	OnMismatch string
+	// Schedule membatasi kapan download berjalan: Download menunggu sampai jadwal aktif,
+	// berhenti saat jendela waktu ditutup (progres disimpan ke manifest), dan melanjutkan saat
+	// jendela berikutnya dibuka. Timeout juga menghitung waktu menunggu ini.
+	Schedule Schedule
+	// RateLimit membatasi total kecepatan semua download Downloader ini (bytes/detik, 0 = tanpa batas).
+	// Bisa diubah saat download berjalan dengan SetRateLimit.
+	RateLimit int64
+	// ProgressMode memilih tampilan progres di stdout: ProgressAuto (default), ProgressTTY,
+	// ProgressLog, atau ProgressOff.
+	ProgressMode string
+	// OnProgress dipanggil berkala dengan progres download.
+	OnProgress ProgressFunc
+	// Log menerima pesan status dan tampilan progres (default os.Stdout; io.Discard untuk diam).
+	Log io.Writer
+}
+
+// Result adalah ringkasan download yang berhasil.
+type Result struct {
+	URL          string
+	Output       string
+	Size         int64             // Ukuran file output dalam bytes
+	Checksums    map[string]string // Checksum yang diperiksa, per algoritma (hex)
+	Resumed      bool              // Melanjutkan download sebelumnya dari manifest
+	Skipped      bool              // File output sudah ada dan OnExist adalah ExistSkip
+	SingleStream bool              // Diunduh dengan satu koneksi (server tanpa dukungan Range)
+	ETag         string            // ETag file di server (kosong jika server tidak mengirimnya)
+	// Mirrors berisi statistik setiap URL yang ikut diunduh per bagian (kosong pada SingleStream).
+	Mirrors []MirrorStats
+	Elapsed time.Duration
+}
+
+// Downloader mengunduh file dengan beberapa koneksi paralel. Satu Downloader aman dipakai
+// bersamaan dari beberapa Goroutine; semua download-nya berbagi batas kecepatan RateLimit.
+type Downloader struct {
+	opts    Options
+	client  *http.Client
+	headers http.Header
+	rate    *rateLimiter
+}
+
+// NewDownloader membuat Downloader dengan opsi yang diberikan. Error dikembalikan jika
+// pengaturan koneksi tidak valid, misalnya URL proxy salah atau CA bundle tidak terbaca.
+func NewDownloader(opts Options) (*Downloader, error) {
+	switch opts.OnExist {
+	case "", ExistOverwrite, ExistSkip, ExistRename:
+	default:
+		return nil, fmt.Errorf("OnExist tidak dikenal: '%s' (pilih %s, %s, atau %s)", opts.OnExist, ExistOverwrite, ExistSkip, ExistRename)
+	}
+	switch opts.OnMismatch {
+	case "", MismatchQuarantine, MismatchDelete:
+	default:
+		return nil, fmt.Errorf("OnMismatch tidak dikenal: '%s' (pilih %s atau %s)", opts.OnMismatch, MismatchQuarantine, MismatchDelete)
+	}
+	client, err := buildHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Downloader{opts: opts, client: client, headers: requestHeaders(opts), rate: newRateLimiter(opts.RateLimit)}, nil
+}
+
+// SetRateLimit mengubah batas kecepatan (bytes/detik, 0 = tanpa batas), termasuk untuk download yang sedang berjalan.
+func (d *Downloader) SetRateLimit(bytesPerSec int64) {
+	d.rate.setRate(bytesPerSec)
+}
+
+// Download mengunduh fileURL. Jika ctx dibatalkan (atau Timeout habis), semua koneksi
+// dihentikan, file yang terbuka ditutup, dan progres disimpan ke manifest sebelum Download
+// kembali dengan error yang memenuhi errors.Is(err, context.Canceled) atau
+// context.DeadlineExceeded. Memanggil Download lagi dengan URL dan Output yang sama akan
+// melanjutkan download tersebut.
+func (d *Downloader) Download(ctx context.Context, fileURL string) (Result, error) {
+	return d.DownloadFrom(ctx, fileURL)
+}
+
+// DownloadFrom mengunduh satu file dari beberapa mirror sekaligus. URL pertama adalah URL
+// utama (dipakai untuk nama file output dan manifest). Mirror yang ukuran atau ETag-nya
+// berbeda tidak dipakai, bagian-bagian dibagi ke mirror yang tersisa, dan bagian dari mirror
+// yang gagal atau jauh lebih lambat dipindahkan ke mirror lain. Result.Mirrors berisi
+// statistik setiap mirror.
+func (d *Downloader) DownloadFrom(ctx context.Context, urls ...string) (Result, error) {
+	if len(urls) == 0 {
+		return Result{}, errors.New("tidak ada URL yang diberikan")
+	}
+	for _, fileURL := range urls {
+		if err := validateURL(fileURL); err != nil {
+			return Result{}, err
+		}
+	}
+	fileURL := urls[0]
+	if d.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.opts.Timeout)
+		defer cancel()
+	}
+
+	parts := d.opts.Parts
+	if parts <= 0 {
+		parts = defaultNumParts
+	}
+	cfg := downloadConfig{
+		URL:             fileURL,
+		Mirrors:         urls[1:],
+		Output:          d.opts.Output,
+		OutputDir:       d.opts.OutputDir,
+		OnExist:         d.opts.OnExist,
+		NumParts:        parts,
+		PartFiles:       d.opts.PartFiles,
+		KeepPartial:     d.opts.KeepPartial,
+		Checksum:        d.opts.Checksum,
+		OnMismatch:      d.opts.OnMismatch,
+		Retry:           retryPolicy{MaxAttempts: d.opts.MaxAttempts},
+		ProgressMode:    d.opts.ProgressMode,
+		OnProgress:      d.opts.OnProgress,
+		SharedRateLimit: d.rate,
+		Client:          d.client,
+		Headers:         d.headers,
+		StallTimeout:    orDefault(d.opts.StallTimeout, defaultStallTimeout),
+		Log:             d.opts.Log,
+	}
+	res, err := runScheduled(ctx, cfg, d.opts.Schedule)
+	if err != nil && ctx.Err() != nil {
+		// Error dari bagian yang terputus hanyalah akibat pembatalan; laporkan penyebabnya.
+		if errors.Is(err, ctx.Err()) {
+			err = fmt.Errorf("download dibatalkan: %w", err)
+		} else {
+			err = fmt.Errorf("download dibatalkan: %w (%v)", ctx.Err(), err)
+		}
+	}
+	return res, err
+}
+
+// remoteETag mengambil ETag fileURL di server saat ini tanpa mengunduh isinya.
+func (d *Downloader) remoteETag(ctx context.Context, fileURL string) (string, error) {
+	info, err := probeRemote(ctx, downloadConfig{URL: fileURL, Client: d.client, Headers: d.headers, Log: io.Discard})
+	return info.ETag, err
+}
+
+// validateURL memastikan fileURL adalah URL http/https yang lengkap.
+func validateURL(fileURL string) error {
+	u, err := url.Parse(fileURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("URL tidak valid: '%s'", fileURL)
+	}
+	return nil
+}
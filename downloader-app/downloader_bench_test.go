@@ -0,0 +1,109 @@
+// mini-projects/downloader-app/downloader_bench_test.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Ukuran file untuk benchmark. Default 2 GiB agar perbedaan I/O antara mode direct dan
+// mode file bagian terlihat; pakai nilai kecil untuk percobaan cepat, misalnya:
+//
+//	go test ./downloader-app -run '^$' -bench Download -bench-size 67108864
+var benchSize = flag.Int64("bench-size", 2<<30, "ukuran file (bytes) untuk benchmark download")
+
+var benchParts = flag.Int("bench-parts", 8, "jumlah bagian paralel untuk benchmark download")
+
+// patternReader adalah io.ReadSeeker berisi data sintetis yang ditentukan oleh offset,
+// sehingga server bisa menyajikan file multi-GB tanpa menyimpannya di memori atau disk.
+type patternReader struct {
+	size int64
+	off  int64
+}
+
+func (pr *patternReader) Read(p []byte) (int, error) {
+	if pr.off >= pr.size {
+		return 0, io.EOF
+	}
+	n := int(min(int64(len(p)), pr.size-pr.off))
+	for i := 0; i < n; i++ {
+		pos := pr.off + int64(i)
+		p[i] = byte(pos*31 + pos>>12)
+	}
+	pr.off += int64(n)
+	return n, nil
+}
+
+func (pr *patternReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += pr.off
+	case io.SeekEnd:
+		offset += pr.size
+	}
+	if offset < 0 {
+		return 0, errors.New("offset negatif")
+	}
+	pr.off = offset
+	return offset, nil
+}
+
+func newPatternServer(b *testing.B, size int64) *httptest.Server {
+	b.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"bench"`)
+		http.ServeContent(w, r, "bench.bin", time.Unix(1700000000, 0), &patternReader{size: size})
+	}))
+	b.Cleanup(srv.Close)
+	return srv
+}
+
+// silenceStdout membuang output progres runDownload selama benchmark berjalan.
+func silenceStdout(b *testing.B) {
+	b.Helper()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	b.Cleanup(func() {
+		os.Stdout = stdout
+		devNull.Close()
+	})
+}
+
+func benchmarkDownload(b *testing.B, partFiles bool) {
+	size := *benchSize
+	srv := newPatternServer(b, size)
+	dir := b.TempDir()
+	silenceStdout(b)
+
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out := filepath.Join(dir, "bench.bin")
+		cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: *benchParts, PartFiles: partFiles}
+		if _, err := runDownload(context.Background(), cfg); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		if got := fileSizeOf(out); got != size {
+			b.Fatalf("ukuran file %d, ingin %d", got, size)
+		}
+		os.Remove(out)
+		b.StartTimer()
+	}
+}
+
+func BenchmarkDownloadDirect(b *testing.B)    { benchmarkDownload(b, false) }
+func BenchmarkDownloadPartFiles(b *testing.B) { benchmarkDownload(b, true) }
@@ -0,0 +1,1142 @@
+// mini-projects/downloader-app/downloader_test.go
+package parallel_downloader_app
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// noRetry mematikan percobaan ulang, sehingga koneksi yang diputus server benar-benar
+// menggagalkan download (mensimulasikan program yang terhenti di tengah jalan).
+var noRetry = retryPolicy{MaxAttempts: 1}
+
+// testFileServer menyajikan satu file di memori dengan dukungan Range/If-Range
+// (lewat http.ServeContent) dan bisa diatur untuk memutus koneksi di tengah jalan.
+type testFileServer struct {
+	mu        sync.Mutex
+	content   []byte
+	etag      string
+	failAfter int64       // Jika > 0, setiap GET diputus setelah sekian byte
+	headers   http.Header // Header tambahan di setiap respons
+	// failures berisi status yang dikirim (satu per GET, berurutan) sebelum server melayani normal.
+	failures   []int
+	retryAfter string // Nilai header Retry-After untuk respons di failures
+	// abortCount membatasi failAfter hanya untuk sejumlah GET pertama (0 = semua GET).
+	abortCount int
+	// throttle (opsional) menentukan kecepatan (bytes/detik) untuk sebuah request; 0 = tanpa batas.
+	throttle func(r *http.Request) int64
+	// Simulasi server yang tidak lengkap: mengabaikan Range, tanpa Content-Length (chunked),
+	// atau mengirim Content-Range yang salah.
+	ignoreRange  bool
+	chunked      bool
+	badRange     bool
+	acceptRanges string // Jika diisi, menggantikan header Accept-Ranges
+	headStatus   int    // Jika diisi, setiap HEAD dibalas dengan status ini (server yang menolak HEAD)
+	requests     []http.Header
+}
+
+func newTestFileServer(t *testing.T, content []byte, etag string) (*testFileServer, *httptest.Server) {
+	t.Helper()
+	fs := &testFileServer{content: content, etag: etag}
+	srv := httptest.NewServer(fs)
+	t.Cleanup(srv.Close)
+	return fs, srv
+}
+
+func (fs *testFileServer) set(content []byte, etag string, failAfter int64) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.content, fs.etag, fs.failAfter = content, etag, failAfter
+	fs.requests = nil
+}
+
+func (fs *testFileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fs.mu.Lock()
+	content, etag, failAfter, headers := fs.content, fs.etag, fs.failAfter, fs.headers
+	failStatus := 0
+	if r.Method == "GET" {
+		fs.requests = append(fs.requests, r.Header.Clone())
+		if len(fs.failures) > 0 {
+			failStatus, fs.failures = fs.failures[0], fs.failures[1:]
+		} else if failAfter > 0 && fs.abortCount > 0 {
+			if fs.abortCount--; fs.abortCount == 0 {
+				fs.failAfter = 0
+			}
+		}
+	}
+	retryAfter, throttle := fs.retryAfter, fs.throttle
+	ignoreRange, chunked, badRange, acceptRanges := fs.ignoreRange, fs.chunked, fs.badRange, fs.acceptRanges
+	if r.Method == "HEAD" && fs.headStatus != 0 {
+		failStatus = fs.headStatus
+	}
+	fs.mu.Unlock()
+
+	if failStatus != 0 {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		http.Error(w, http.StatusText(failStatus), failStatus)
+		return
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	for k, v := range headers {
+		w.Header()[k] = v
+	}
+	if throttle != nil && r.Method == "GET" {
+		if rate := throttle(r); rate > 0 {
+			w = &throttledWriter{ResponseWriter: w, rate: rate}
+		}
+	}
+	if failAfter > 0 && r.Method == "GET" {
+		w = &abortingWriter{ResponseWriter: w, remaining: failAfter}
+	}
+	if chunked {
+		// Tanpa Content-Length: Flush sebelum menulis memaksa Transfer-Encoding: chunked.
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "GET" {
+			w.(http.Flusher).Flush()
+			w.Write(content)
+		}
+		return
+	}
+	if ignoreRange {
+		r.Header.Del("Range")
+		r.Header.Del("If-Range")
+	}
+	if acceptRanges != "" || badRange {
+		w = &headerRewriter{ResponseWriter: w, acceptRanges: acceptRanges, badRange: badRange}
+	}
+	http.ServeContent(w, r, "file.bin", time.Unix(1700000000, 0), bytes.NewReader(content))
+}
+
+// headerRewriter mengubah header respons tepat sebelum dikirim.
+type headerRewriter struct {
+	http.ResponseWriter
+	acceptRanges string
+	badRange     bool
+}
+
+func (hw *headerRewriter) WriteHeader(code int) {
+	if hw.acceptRanges != "" {
+		hw.Header().Set("Accept-Ranges", hw.acceptRanges)
+	}
+	if cr := hw.Header().Get("Content-Range"); hw.badRange && cr != "" {
+		// Geser rentang satu byte: server "salah hitung".
+		start, end, size, _ := parseContentRange(cr)
+		hw.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start+1, end, size))
+	}
+	hw.ResponseWriter.WriteHeader(code)
+}
+
+// abortingWriter memutus koneksi setelah sejumlah byte body terkirim.
+type abortingWriter struct {
+	http.ResponseWriter
+	remaining int64
+}
+
+func (aw *abortingWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > aw.remaining {
+		aw.ResponseWriter.Write(p[:aw.remaining])
+		aw.remaining = 0
+		if f, ok := aw.ResponseWriter.(http.Flusher); ok {
+			f.Flush()
+		}
+		panic(http.ErrAbortHandler)
+	}
+	aw.remaining -= int64(len(p))
+	return aw.ResponseWriter.Write(p)
+}
+
+// throttledWriter membatasi kecepatan pengiriman body sebuah respons.
+type throttledWriter struct {
+	http.ResponseWriter
+	rate int64 // bytes per detik
+}
+
+func (tw *throttledWriter) Write(p []byte) (int, error) {
+	const chunk = 4 * 1024
+	written := 0
+	for written < len(p) {
+		n, err := tw.ResponseWriter.Write(p[written:min(written+chunk, len(p))])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		time.Sleep(time.Duration(int64(n) * int64(time.Second) / tw.rate))
+	}
+	return written, nil
+}
+
+func randomContent(size int, seed int64) []byte {
+	buf := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(buf)
+	return buf
+}
+
+func assertFileContent(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("file output tidak bisa dibaca: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("isi file output berbeda (ukuran %d, ingin %d)", len(got), len(want))
+	}
+}
+
+// ifRangeRequests menghitung GET yang membawa If-Range, yaitu request yang melanjutkan
+// bagian dari manifest.
+func (fs *testFileServer) ifRangeRequests() int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n := 0
+	for _, h := range fs.requests {
+		if h.Get("If-Range") != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// assertManifestKept memastikan download pertama yang gagal meninggalkan manifest
+// untuk dilanjutkan.
+func assertManifestKept(t *testing.T, out string) {
+	t.Helper()
+	if m, err := loadManifest(manifestPath(out)); err != nil || m == nil {
+		t.Fatalf("manifest tidak tersimpan setelah download gagal: %v", err)
+	}
+}
+
+func TestDownloadResumesFromManifest(t *testing.T) {
+	t.Run("direct", func(t *testing.T) { testDownloadResumes(t, false) })
+	t.Run("part-files", func(t *testing.T) { testDownloadResumes(t, true) })
+}
+
+func testDownloadResumes(t *testing.T, partFiles bool) {
+	content := randomContent(256*1024, 1)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4, PartFiles: partFiles, Retry: noRetry, KeepPartial: true}
+
+	// Percobaan pertama terputus di tengah setiap bagian.
+	fs.set(content, `"v1"`, 20*1024)
+	if _, err := runDownload(context.Background(), cfg); err == nil {
+		t.Fatal("download pertama seharusnya gagal")
+	}
+	m, err := loadManifest(manifestPath(out))
+	if err != nil || m == nil {
+		t.Fatalf("manifest tidak tersimpan: %v", err)
+	}
+	if m.ETag != `"v1"` || m.Size != int64(len(content)) || len(m.Parts) != 4 || m.Mode != cfg.writeMode() {
+		t.Fatalf("isi manifest tidak sesuai: %+v", m)
+	}
+
+	// Percobaan kedua melanjutkan setiap bagian dengan Range + If-Range.
+	fs.set(content, `"v1"`, 0)
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download lanjutan gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+	// Bagian yang sudah sempat terisi harus dilanjutkan (Range tidak lagi dimulai di
+	// awal bagian) dan membawa If-Range; bagian yang belum terisi boleh mulai dari awal.
+	partStarts := make(map[string]bool)
+	for _, p := range m.Parts {
+		partStarts[fmt.Sprintf("bytes=%d-%d", p.Start, p.End)] = true
+	}
+	resumed := 0
+	for _, h := range fs.requests {
+		if partStarts[h.Get("Range")] {
+			continue
+		}
+		resumed++
+		if h.Get("If-Range") != `"v1"` {
+			t.Errorf("request lanjutan tanpa If-Range: Range=%s", h.Get("Range"))
+		}
+	}
+	if resumed == 0 {
+		t.Errorf("tidak ada bagian yang dilanjutkan dari manifest")
+	}
+	if _, err := os.Stat(manifestPath(out)); !os.IsNotExist(err) {
+		t.Errorf("manifest tidak dihapus setelah selesai")
+	}
+	for _, leftover := range []string{out + ".part0", tempOutputPath(out)} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s tidak dihapus setelah selesai", leftover)
+		}
+	}
+}
+
+func TestDownloadRestartsWhenRemoteChanged(t *testing.T) {
+	oldContent := randomContent(128*1024, 2)
+	newContent := randomContent(128*1024, 3)
+	fs, srv := newTestFileServer(t, oldContent, `"lama"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, Retry: noRetry, KeepPartial: true}
+
+	fs.set(oldContent, `"lama"`, 10*1024)
+	if _, err := runDownload(context.Background(), cfg); err == nil {
+		t.Fatal("download pertama seharusnya gagal")
+	}
+	assertManifestKept(t, out)
+
+	// File di server diganti dengan ukuran sama tetapi ETag berbeda: manifest lama harus
+	// dibuang sebelum request pertama, bukan dilanjutkan lalu ditolak lewat If-Range.
+	fs.set(newContent, `"baru"`, 0)
+	res, err := runDownload(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("download ulang gagal: %v", err)
+	}
+	if res.Resumed || fs.ifRangeRequests() != 0 {
+		t.Errorf("download dilanjutkan dari manifest file lama (Resumed=%v, %d request If-Range)", res.Resumed, fs.ifRangeRequests())
+	}
+	assertFileContent(t, out, newContent)
+}
+
+func TestDownloadRestartsWhenWriteModeChanged(t *testing.T) {
+	content := randomContent(64*1024, 4)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+
+	fs.set(content, `"v1"`, 8*1024)
+	partsCfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, PartFiles: true, Retry: noRetry, KeepPartial: true}
+	if _, err := runDownload(context.Background(), partsCfg); err == nil {
+		t.Fatal("download pertama seharusnya gagal")
+	}
+	assertManifestKept(t, out)
+	if _, err := os.Stat(out + ".part0"); err != nil {
+		t.Fatalf("file bagian dari download pertama tidak ada: %v", err)
+	}
+
+	// Dilanjutkan dengan mode direct: file bagian lama harus dibuang.
+	fs.set(content, `"v1"`, 0)
+	directCfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2}
+	res, err := runDownload(context.Background(), directCfg)
+	if err != nil {
+		t.Fatalf("download ulang gagal: %v", err)
+	}
+	if res.Resumed || fs.ifRangeRequests() != 0 {
+		t.Errorf("download dilanjutkan dari manifest mode lama (Resumed=%v, %d request If-Range)", res.Resumed, fs.ifRangeRequests())
+	}
+	assertFileContent(t, out, content)
+	if _, err := os.Stat(out + ".part0"); !os.IsNotExist(err) {
+		t.Errorf("file bagian dari mode lama tidak dihapus")
+	}
+}
+
+func TestDirectOutputTruncatesStaleTempFile(t *testing.T) {
+	content := randomContent(8000, 24)
+	stale := randomContent(100000, 25)
+
+	t.Run("tanpa-manifest", func(t *testing.T) {
+		_, srv := newTestFileServer(t, content, `"v1"`)
+		out := filepath.Join(t.TempDir(), "file.bin")
+		if err := os.WriteFile(tempOutputPath(out), stale, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := runDownload(context.Background(), downloadConfig{URL: srv.URL, Output: out, NumParts: 2}); err != nil {
+			t.Fatalf("download gagal: %v", err)
+		}
+		assertFileContent(t, out, content)
+	})
+
+	// Manifest ada, tetapi ukuran file sementaranya berbeda: download dimulai ulang
+	// dengan file yang sama, yang harus dipotong ke ukuran file di server.
+	t.Run("ukuran-berbeda", func(t *testing.T) {
+		fs, srv := newTestFileServer(t, content, `"v1"`)
+		out := filepath.Join(t.TempDir(), "file.bin")
+		cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, Retry: noRetry, KeepPartial: true}
+		fs.set(content, `"v1"`, 1000)
+		if _, err := runDownload(context.Background(), cfg); err == nil {
+			t.Fatal("download pertama seharusnya gagal")
+		}
+		if err := os.WriteFile(tempOutputPath(out), stale, 0644); err != nil {
+			t.Fatal(err)
+		}
+		fs.set(content, `"v1"`, 0)
+		res, err := runDownload(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("download ulang gagal: %v", err)
+		}
+		if res.Resumed {
+			t.Error("file sementara dengan ukuran berbeda tidak boleh dilanjutkan")
+		}
+		assertFileContent(t, out, content)
+	})
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestChecksumVerified(t *testing.T) {
+	content := randomContent(200*1024, 5)
+	for _, partFiles := range []bool{false, true} {
+		_, srv := newTestFileServer(t, content, `"v1"`)
+		out := filepath.Join(t.TempDir(), "file.bin")
+		cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 5, PartFiles: partFiles, Checksum: "sha256:" + sha256Hex(content)}
+		if _, err := runDownload(context.Background(), cfg); err != nil {
+			t.Fatalf("partFiles=%v: download gagal: %v", partFiles, err)
+		}
+		assertFileContent(t, out, content)
+	}
+}
+
+func TestChecksumCoversResumedBytes(t *testing.T) {
+	content := randomContent(256*1024, 6)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4, Checksum: sha256Hex(content), Retry: noRetry, KeepPartial: true}
+
+	fs.set(content, `"v1"`, 30*1024)
+	if _, err := runDownload(context.Background(), cfg); err == nil {
+		t.Fatal("download pertama seharusnya gagal")
+	}
+	assertManifestKept(t, out)
+	// Byte dari percobaan pertama harus ikut di-hash (dibaca ulang dari disk).
+	fs.set(content, `"v1"`, 0)
+	res, err := runDownload(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("download lanjutan gagal: %v", err)
+	}
+	if !res.Resumed || fs.ifRangeRequests() == 0 {
+		t.Errorf("download tidak dilanjutkan (Resumed=%v, %d request If-Range)", res.Resumed, fs.ifRangeRequests())
+	}
+	assertFileContent(t, out, content)
+}
+
+func TestChecksumMismatchQuarantinesOutput(t *testing.T) {
+	content := randomContent(64*1024, 7)
+	_, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, Checksum: "md5:" + strings.Repeat("0", 32)}
+
+	_, err := runDownload(context.Background(), cfg)
+	if !errors.Is(err, errChecksumMismatch) {
+		t.Fatalf("error = %v, ingin errChecksumMismatch", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("file output dengan checksum salah masih ada")
+	}
+	assertFileContent(t, out+".corrupt", content)
+
+	if _, err := NewDownloader(Options{OnMismatch: "simpan"}); err == nil {
+		t.Error("OnMismatch yang tidak dikenal seharusnya ditolak")
+	}
+}
+
+func TestChecksumFromResponseHeaders(t *testing.T) {
+	content := randomContent(64*1024, 8)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, OnMismatch: MismatchDelete}
+
+	sum := sha256.Sum256(content)
+	md := md5.Sum(content)
+	fs.headers = http.Header{
+		"Repr-Digest": {"sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"},
+		"Content-Md5": {base64.StdEncoding.EncodeToString(md[:])},
+	}
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download dengan digest header yang benar gagal: %v", err)
+	}
+
+	// Header Digest yang salah harus menggagalkan download dan menghapus file.
+	os.Remove(out)
+	fs.headers = http.Header{"Digest": {"SHA-256=" + base64.StdEncoding.EncodeToString(make([]byte, 32))}}
+	if _, err := runDownload(context.Background(), cfg); !errors.Is(err, errChecksumMismatch) {
+		t.Fatalf("error = %v, ingin errChecksumMismatch", err)
+	}
+	for _, p := range []string{out, out + ".corrupt"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s seharusnya dihapus", p)
+		}
+	}
+}
+
+func TestChecksumFile(t *testing.T) {
+	content := randomContent(32*1024, 9)
+	_, srv := newTestFileServer(t, content, "")
+	dir := t.TempDir()
+	out := filepath.Join(dir, "file.bin")
+	sumFile := filepath.Join(dir, "SHA256SUMS.sha256sum")
+	lines := strings.Repeat("0", 64) + "  lain.bin\n" + sha256Hex(content) + " *file.bin\n"
+	if err := os.WriteFile(sumFile, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 3, Checksum: sumFile}
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+}
+
+func TestResolveExpectedDigest(t *testing.T) {
+	tests := []struct {
+		value, algo string
+		wantErr     bool
+	}{
+		{value: "sha256:" + strings.Repeat("ab", 32), algo: "sha256"},
+		{value: "SHA-1:" + strings.Repeat("ab", 20), algo: "sha1"},
+		{value: strings.Repeat("ab", 16), algo: "md5"},
+		{value: "sha256:" + strings.Repeat("ab", 16), wantErr: true},
+		{value: "md5:xyz", wantErr: true},
+		{value: "tidak-ada.sha256sum", wantErr: true},
+	}
+	for _, tt := range tests {
+		d, err := resolveExpectedDigest(context.Background(), downloadConfig{Checksum: tt.value, Output: "file.bin", URL: "http://contoh/file.bin"})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err == nil && d.Algo != tt.algo {
+			t.Errorf("%s: algo = %s, ingin %s", tt.value, d.Algo, tt.algo)
+		}
+	}
+}
+
+// fastRetry memakai jeda sangat pendek agar test percobaan ulang tidak lambat.
+var fastRetry = retryPolicy{MaxAttempts: 10, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestPartRetriesResumeFromLastByte(t *testing.T) {
+	content := randomContent(256*1024, 10)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+
+	// Enam GET pertama diputus setelah 16 KB; setiap percobaan ulang harus melanjutkan
+	// dari byte terakhir, bukan dari awal bagian.
+	fs.mu.Lock()
+	fs.failAfter, fs.abortCount = 16*1024, 6
+	fs.mu.Unlock()
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, Retry: fastRetry, Checksum: sha256Hex(content)}
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal walaupun ada percobaan ulang: %v", err)
+	}
+	assertFileContent(t, out, content)
+
+	resumed := 0
+	for _, h := range fs.requests {
+		if h.Get("If-Range") != "" {
+			resumed++
+		}
+	}
+	if resumed < 4 {
+		t.Errorf("hanya %d request lanjutan, ingin minimal 4", resumed)
+	}
+}
+
+func TestPartRetriesOnServerErrors(t *testing.T) {
+	content := randomContent(32*1024, 11)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+
+	fs.failures = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusBadGateway}
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 1, Retry: fastRetry}
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+	if len(fs.requests) != 4 {
+		t.Errorf("jumlah GET = %d, ingin 4", len(fs.requests))
+	}
+}
+
+func TestPartRetryHonorsRetryAfter(t *testing.T) {
+	content := randomContent(8*1024, 12)
+	fs, srv := newTestFileServer(t, content, "")
+	out := filepath.Join(t.TempDir(), "file.bin")
+
+	fs.failures, fs.retryAfter = []int{http.StatusTooManyRequests}, "1"
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 1, Retry: fastRetry}
+	start := time.Now()
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Retry-After: 1 diabaikan, download selesai dalam %v", elapsed)
+	}
+}
+
+func TestPartRetryGivesUp(t *testing.T) {
+	content := randomContent(8*1024, 13)
+	fs, srv := newTestFileServer(t, content, "")
+	out := filepath.Join(t.TempDir(), "file.bin")
+
+	// 404 bukan error sementara: tidak dicoba ulang sama sekali.
+	fs.failures = []int{http.StatusNotFound}
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 1, Retry: fastRetry}
+	if _, err := runDownload(context.Background(), cfg); err == nil {
+		t.Fatal("download seharusnya gagal pada status 404")
+	}
+	if len(fs.requests) != 1 {
+		t.Errorf("status 404 dicoba ulang %d kali", len(fs.requests)-1)
+	}
+
+	// Error sementara berhenti setelah MaxAttempts percobaan.
+	fs.set(content, "", 0)
+	fs.failures = []int{500, 500, 500, 500, 500}
+	cfg.Retry.MaxAttempts = 3
+	if _, err := runDownload(context.Background(), cfg); err == nil {
+		t.Fatal("download seharusnya gagal setelah 3 percobaan")
+	}
+	if len(fs.requests) != 3 {
+		t.Errorf("jumlah GET = %d, ingin 3", len(fs.requests))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-5", 0},
+		{"Mon, 01 Jan 2024 12:00:10 GMT", 10 * time.Second},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0},
+		{"99999", maxRetryAfter},
+		{"besok", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, ingin %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func setMinSegmentSize(t *testing.T, size int64) {
+	t.Helper()
+	old := minSegmentSize
+	minSegmentSize = size
+	t.Cleanup(func() { minSegmentSize = old })
+}
+
+func TestWorkStealingBalancesSlowConnection(t *testing.T) {
+	setMinSegmentSize(t, 32*1024)
+	content := randomContent(1024*1024, 14)
+
+	for _, partFiles := range []bool{false, true} {
+		fs, srv := newTestFileServer(t, content, `"v1"`)
+		// Hanya koneksi yang mengunduh awal file yang lambat (128 KB/detik). Tanpa work
+		// stealing, segmen pertama (256 KB) saja butuh sekitar 2 detik.
+		fs.throttle = func(r *http.Request) int64 {
+			if strings.HasPrefix(r.Header.Get("Range"), "bytes=0-") {
+				return 128 * 1024
+			}
+			return 0
+		}
+		out := filepath.Join(t.TempDir(), "file.bin")
+		cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4, PartFiles: partFiles, Checksum: sha256Hex(content)}
+
+		start := time.Now()
+		if _, err := runDownload(context.Background(), cfg); err != nil {
+			t.Fatalf("partFiles=%v: download gagal: %v", partFiles, err)
+		}
+		elapsed := time.Since(start)
+		assertFileContent(t, out, content)
+		if elapsed > 1200*time.Millisecond {
+			t.Errorf("partFiles=%v: download butuh %v; koneksi lambat tidak dibantu worker lain", partFiles, elapsed)
+		}
+		if len(fs.requests) <= 4 {
+			t.Errorf("partFiles=%v: hanya %d request, tidak ada segmen yang dipecah", partFiles, len(fs.requests))
+		}
+		if _, err := os.Stat(out + ".part4"); !os.IsNotExist(err) {
+			t.Errorf("partFiles=%v: file segmen hasil pemecahan tidak dihapus", partFiles)
+		}
+	}
+}
+
+func TestClaimSplitsLargestActiveSegment(t *testing.T) {
+	setMinSegmentSize(t, 64*1024)
+	m := &downloadManifest{Output: "file.bin", Mode: writeModePartFiles, Size: 1 << 20, Parts: []partState{
+		{Index: 0, Start: 0, End: 1<<20 - 1, Written: 0, File: "file.bin.part0"},
+	}}
+	tracker := &manifestTracker{m: m}
+
+	first, ok := tracker.claim()
+	if !ok || first.Index != 0 {
+		t.Fatalf("claim pertama = %+v, %v; ingin segmen 0", first, ok)
+	}
+	tracker.commit(0, 100*1024)
+
+	stolen, ok := tracker.claim()
+	if !ok {
+		t.Fatal("claim kedua seharusnya memecah segmen 0")
+	}
+	// Sisa segmen 0 = 1 MiB - 100 KiB; separuh belakangnya diambil alih.
+	wantMid := int64(100*1024) + (1<<20-100*1024)/2
+	if stolen.Start != wantMid || stolen.End != 1<<20-1 || stolen.File != "file.bin.part1" {
+		t.Errorf("segmen curian = %+v, ingin mulai di %d", stolen, wantMid)
+	}
+	if got := tracker.segment(0).End; got != wantMid-1 {
+		t.Errorf("ujung segmen 0 = %d, ingin %d", got, wantMid-1)
+	}
+
+	// Penulis segmen 0 harus berhenti di ujung barunya.
+	var buf bytes.Buffer
+	tracker.commit(0, wantMid-1-100*1024) // Tinggal 1 byte lagi di segmen 0
+	pw := &partWriter{w: &buf, index: 0, off: wantMid - 1, tracker: tracker}
+	n, err := pw.Write(make([]byte, 10))
+	if n != 1 || !errors.Is(err, errSegmentShrunk) {
+		t.Errorf("Write = %d, %v; ingin 1, errSegmentShrunk", n, err)
+	}
+
+	// Segmen yang sisanya terlalu kecil tidak dipecah lagi.
+	tracker.commit(1, stolen.length()-100*1024)
+	if p, ok := tracker.claim(); ok {
+		t.Errorf("segmen kecil tetap dipecah: %+v", p)
+	}
+}
+
+func TestManifestFlushDoesNotBlockWorkersDuringSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.bin.manifest.json")
+	m := &downloadManifest{Output: "file.bin", Mode: writeModeDirect, Size: 1000, Parts: []partState{
+		{Index: 0, Start: 0, End: 999},
+	}}
+	syncing, release := make(chan struct{}), make(chan struct{})
+	tracker := &manifestTracker{path: path, m: m, syncData: func() error {
+		close(syncing)
+		<-release
+		return nil
+	}}
+	tracker.commit(0, 100)
+
+	done := make(chan error, 1)
+	go func() { done <- tracker.flush() }()
+	<-syncing
+	// Selama fsync berjalan, worker tetap bisa memesan dan mencatat byte.
+	committed := make(chan struct{})
+	go func() {
+		tracker.reserve(0, 50)
+		tracker.commit(0, 50)
+		close(committed)
+	}()
+	select {
+	case <-committed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("commit tertahan oleh fsync manifest")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("flush gagal: %v", err)
+	}
+
+	// Manifest hanya mencatat byte yang sudah ada sebelum fsync dimulai.
+	saved, err := loadManifest(path)
+	if err != nil || saved == nil {
+		t.Fatalf("manifest tidak tersimpan: %v", err)
+	}
+	if got := saved.Parts[0].Written; got != 100 {
+		t.Errorf("Written di manifest = %d, ingin 100", got)
+	}
+	if !tracker.dirty {
+		t.Error("byte yang dicatat selama fsync harus disimpan pada flush berikutnya")
+	}
+}
+
+func TestFallbackWhenServerIgnoresRange(t *testing.T) {
+	content := randomContent(300*1024, 15)
+	for _, partFiles := range []bool{false, true} {
+		fs, srv := newTestFileServer(t, content, `"v1"`)
+		fs.ignoreRange = true
+		out := filepath.Join(t.TempDir(), "file.bin")
+		cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4, PartFiles: partFiles, Checksum: sha256Hex(content)}
+		if _, err := runDownload(context.Background(), cfg); err != nil {
+			t.Fatalf("partFiles=%v: download gagal: %v", partFiles, err)
+		}
+		// File tidak boleh berisi seluruh isi berulang kali (N kali lebih besar).
+		assertFileContent(t, out, content)
+		for _, leftover := range []string{out + ".part1", tempOutputPath(out), manifestPath(out)} {
+			if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+				t.Errorf("partFiles=%v: %s tidak dihapus", partFiles, leftover)
+			}
+		}
+	}
+}
+
+func TestSingleStreamWithoutContentLength(t *testing.T) {
+	content := randomContent(100*1024, 16)
+	fs, srv := newTestFileServer(t, content, "")
+	fs.chunked = true
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4, Checksum: "sha256:" + sha256Hex(content)}
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+	if len(fs.requests) != 1 || fs.requests[0].Get("Range") != "" {
+		t.Errorf("ingin tepat satu GET tanpa Range, dapat %d request", len(fs.requests))
+	}
+}
+
+func TestSingleStreamWhenAcceptRangesNone(t *testing.T) {
+	content := randomContent(64*1024, 17)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	fs.acceptRanges = "none"
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4}
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+	if len(fs.requests) != 1 {
+		t.Errorf("ingin satu GET, dapat %d", len(fs.requests))
+	}
+}
+
+func TestBadContentRangeAborts(t *testing.T) {
+	content := randomContent(64*1024, 18)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	fs.badRange = true
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, Retry: fastRetry}
+	_, err := runDownload(context.Background(), cfg)
+	if !errors.Is(err, errBadContentRange) {
+		t.Fatalf("error = %v, ingin errBadContentRange", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("file output tidak boleh dibuat dari rentang yang salah")
+	}
+}
+
+func TestResumeDistinguishesIgnoredRangeFromChangedFile(t *testing.T) {
+	content := randomContent(128*1024, 19)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, Retry: noRetry, KeepPartial: true}
+
+	fs.set(content, `"v1"`, 10*1024)
+	if _, err := runDownload(context.Background(), cfg); err == nil {
+		t.Fatal("download pertama seharusnya gagal")
+	}
+	assertManifestKept(t, out)
+
+	// File sama (ETag sama), tetapi server kini mengabaikan Range: respons 200 untuk
+	// If-Range bukan tanda file berubah, melainkan tanda harus beralih ke satu koneksi.
+	fs.set(content, `"v1"`, 0)
+	fs.mu.Lock()
+	fs.ignoreRange = true
+	fs.mu.Unlock()
+	res, err := runDownload(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("download ulang gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+	if fs.ifRangeRequests() == 0 || !res.SingleStream {
+		t.Errorf("ingin lanjutan dengan If-Range lalu satu koneksi (%d request If-Range, SingleStream=%v)", fs.ifRangeRequests(), res.SingleStream)
+	}
+	if n := len(fs.requests); n > 3 {
+		t.Errorf("%d GET; ingin paling banyak 2 request bagian + 1 satu koneksi", n)
+	}
+}
+
+func TestProbeFallsBackToRangedGet(t *testing.T) {
+	content := randomContent(512*1024, 90)
+	for _, status := range []int{http.StatusForbidden, http.StatusMethodNotAllowed} {
+		fs := &testFileServer{content: content, etag: `"v1"`, headStatus: status}
+		srv := httptest.NewUnstartedServer(fs)
+		var conns atomic.Int64
+		srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				conns.Add(1)
+			}
+		}
+		srv.Start()
+		defer srv.Close()
+
+		info, err := probeRemote(context.Background(), downloadConfig{URL: srv.URL})
+		if err != nil {
+			t.Fatalf("status %d: probe gagal: %v", status, err)
+		}
+		if info.Size != int64(len(content)) || info.AcceptRanges != "bytes" || info.ETag != `"v1"` {
+			t.Errorf("status %d: info = %+v", status, info)
+		}
+		if got := fs.requests[0].Get("Range"); got != "bytes=0-0" {
+			t.Errorf("status %d: probe GET memakai Range %q, ingin bytes=0-0", status, got)
+		}
+
+		// Dengan satu bagian dan pool koneksi yang baru, HEAD, GET probe, dan GET bagian
+		// memakai satu koneksi yang sama.
+		conns.Store(0)
+		client := &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+		out := filepath.Join(t.TempDir(), "file.bin")
+		if _, err := runDownload(context.Background(), downloadConfig{URL: srv.URL, Output: out, NumParts: 1, Client: client}); err != nil {
+			t.Fatalf("status %d: download gagal: %v", status, err)
+		}
+		assertFileContent(t, out, content)
+		if n := conns.Load(); n != 1 {
+			t.Errorf("status %d: %d koneksi dibuka, ingin 1 (koneksi probe dipakai ulang)", status, n)
+		}
+	}
+}
+
+func TestRangedProbeWithoutRangeSupport(t *testing.T) {
+	content := randomContent(256*1024, 91)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	fs.headStatus = http.StatusMethodNotAllowed
+	fs.ignoreRange = true
+	out := filepath.Join(t.TempDir(), "file.bin")
+	res, err := runDownload(context.Background(), downloadConfig{URL: srv.URL, Output: out, NumParts: 4})
+	if err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+	if !res.SingleStream {
+		t.Error("server yang mengabaikan Range pada probe seharusnya diunduh dengan satu koneksi")
+	}
+
+	// File kosong: server membalas 416 dengan "Content-Range: bytes */0".
+	fs.set(nil, `"v2"`, 0)
+	fs.ignoreRange = false
+	info, err := probeRemote(context.Background(), downloadConfig{URL: srv.URL})
+	if err != nil || info.Size != 0 {
+		t.Errorf("probe file kosong: info=%+v err=%v", info, err)
+	}
+
+	fs.headStatus = http.StatusNotFound
+	if _, err := probeRemote(context.Background(), downloadConfig{URL: srv.URL}); err == nil {
+		t.Error("HEAD 404 tidak boleh dicoba ulang dengan GET")
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value            string
+		start, end, size int64
+		ok               bool
+	}{
+		{"bytes 0-99/1000", 0, 99, 1000, true},
+		{"bytes 100-199/*", 100, 199, -1, true},
+		{"bytes */1000", 0, 0, 0, false},
+		{"bytes 5-1/10", 0, 0, 0, false},
+		{"items 0-1/2", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, end, size, ok := parseContentRange(tt.value)
+		if ok != tt.ok || (ok && (start != tt.start || end != tt.end || size != tt.size)) {
+			t.Errorf("parseContentRange(%q) = %d, %d, %d, %v", tt.value, start, end, size, ok)
+		}
+	}
+}
+
+func TestProgressCallback(t *testing.T) {
+	content := randomContent(512*1024, 20)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	// Cukup lambat (sekitar 0,5 detik) agar ada beberapa laporan sebelum selesai.
+	fs.throttle = func(*http.Request) int64 { return 512 * 1024 }
+	out := filepath.Join(t.TempDir(), "file.bin")
+
+	var mu sync.Mutex
+	var reports []Progress
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, ProgressMode: ProgressOff,
+		OnProgress: func(p Progress) {
+			mu.Lock()
+			reports = append(reports, p)
+			mu.Unlock()
+		}}
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reports) < 2 {
+		t.Fatalf("ingin beberapa laporan progres, dapat %d", len(reports))
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].Downloaded < reports[i-1].Downloaded {
+			t.Errorf("progres mundur: %d lalu %d", reports[i-1].Downloaded, reports[i].Downloaded)
+		}
+	}
+	last := reports[len(reports)-1]
+	if !last.Done || last.Downloaded != int64(len(content)) || last.Total != int64(len(content)) {
+		t.Errorf("laporan terakhir = %+v, ingin Done dengan %d bytes", last, len(content))
+	}
+	if last.Percent() != 100 || last.Speed <= 0 {
+		t.Errorf("laporan terakhir: %.1f%%, %.0f B/s", last.Percent(), last.Speed)
+	}
+}
+
+func TestProgressCallbackSingleStream(t *testing.T) {
+	content := randomContent(64*1024, 21)
+	fs, srv := newTestFileServer(t, content, "")
+	fs.chunked = true
+	out := filepath.Join(t.TempDir(), "file.bin")
+
+	var last Progress
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4, ProgressMode: ProgressOff,
+		OnProgress: func(p Progress) { last = p }}
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	if !last.Done || last.Downloaded != int64(len(content)) || last.Total != -1 || last.Percent() != -1 {
+		t.Errorf("laporan terakhir = %+v", last)
+	}
+}
+
+func TestProgressLogMode(t *testing.T) {
+	var downloaded atomic.Int64
+	pr := newProgressReporter(downloadConfig{URL: "http://example.com/f", ProgressMode: ProgressLog}, 2048,
+		func() (int64, []PartProgress) { return downloaded.Load(), nil })
+	var buf bytes.Buffer
+	pr.out = &buf
+	pr.start()
+	downloaded.Store(2048)
+	pr.logf("pesan\n")
+	pr.finish()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != "pesan" {
+		t.Fatalf("output = %q", buf.String())
+	}
+	if !strings.Contains(lines[1], "100.0%") || !strings.Contains(lines[1], "selesai") || strings.Contains(lines[1], "\x1b") {
+		t.Errorf("baris akhir = %q", lines[1])
+	}
+}
+
+func TestProgressFormatting(t *testing.T) {
+	bytesTests := map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KB", 5 << 20: "5.0 MB", 3 << 30: "3.0 GB"}
+	for n, want := range bytesTests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, ingin %q", n, got, want)
+		}
+	}
+	durationTests := map[time.Duration]string{
+		45 * time.Second: "45s", 185 * time.Second: "3m05s", 62 * time.Minute: "1h02m",
+	}
+	for d, want := range durationTests {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%v) = %q, ingin %q", d, got, want)
+		}
+	}
+	if got := progressBar(50, 10); got != "[#####-----]" {
+		t.Errorf("progressBar(50, 10) = %q", got)
+	}
+	if got := progressBar(150, 4); got != "[####]" {
+		t.Errorf("progressBar(150, 4) = %q", got)
+	}
+}
+
+func TestDownloaderOptionsAndResult(t *testing.T) {
+	content := randomContent(200*1024, 50)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	dir := t.TempDir()
+
+	var requests atomic.Int64
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	d := mustNewDownloader(t, Options{
+		OutputDir: dir, Parts: 3, Headers: http.Header{"X-Token": {"rahasia"}}, Client: client,
+		Checksum: sha256Hex(content), ProgressMode: ProgressOff,
+	})
+	res, err := d.Download(context.Background(), srv.URL+"/files/data.bin?v=1")
+	if err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+
+	wantOutput := filepath.Join(dir, "data.bin")
+	if res.Output != wantOutput || res.Size != int64(len(content)) || res.Resumed || res.SingleStream {
+		t.Errorf("hasil = %+v", res)
+	}
+	if res.Checksums["sha256"] != sha256Hex(content) {
+		t.Errorf("checksum hasil = %v", res.Checksums)
+	}
+	assertFileContent(t, wantOutput, content)
+	if requests.Load() == 0 {
+		t.Error("client dari Options tidak dipakai")
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, h := range fs.requests {
+		if h.Get("X-Token") != "rahasia" {
+			t.Errorf("header tambahan tidak dikirim: %v", h)
+		}
+	}
+}
+
+// roundTripFunc mengubah fungsi menjadi http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestDownloaderCancelStopsPromptlyAndResumes(t *testing.T) {
+	content := randomContent(512*1024, 51)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	fs.throttle = func(*http.Request) int64 { return 64 * 1024 }
+	out := filepath.Join(t.TempDir(), "file.bin")
+	d := mustNewDownloader(t, Options{Output: out, Parts: 4, ProgressMode: ProgressOff})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(300*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := d.Download(ctx, srv.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, ingin context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Download baru berhenti setelah %v", elapsed)
+	}
+	m, err := loadManifest(manifestPath(out))
+	if err != nil || m == nil {
+		t.Fatalf("manifest harus tersimpan setelah dibatalkan: %v", err)
+	}
+
+	fs.mu.Lock()
+	fs.throttle = nil
+	fs.mu.Unlock()
+	res, err := d.Download(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("download ulang gagal: %v", err)
+	}
+	if !res.Resumed {
+		t.Error("download ulang seharusnya melanjutkan dari manifest")
+	}
+	assertFileContent(t, out, content)
+}
+
+func TestDownloaderTimeout(t *testing.T) {
+	content := randomContent(256*1024, 52)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	fs.throttle = func(*http.Request) int64 { return 32 * 1024 }
+	out := filepath.Join(t.TempDir(), "file.bin")
+	d := mustNewDownloader(t, Options{Output: out, Timeout: 200 * time.Millisecond, ProgressMode: ProgressOff})
+	if _, err := d.Download(context.Background(), srv.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, ingin context.DeadlineExceeded", err)
+	}
+	if _, err := d.Download(context.Background(), "ftp://example.com/x"); err == nil {
+		t.Error("URL selain http/https seharusnya ditolak")
+	}
+}
+
+func mustNewDownloader(t *testing.T, opts Options) *Downloader {
+	t.Helper()
+	d, err := NewDownloader(opts)
+	if err != nil {
+		t.Fatalf("NewDownloader: %v", err)
+	}
+	return d
+}
@@ -0,0 +1,235 @@
+// mini-projects/downloader-app/extract.go
+package parallel_downloader_app
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format arsip yang bisa diekstrak.
+const (
+	archiveZip   = "zip"
+	archiveTar   = "tar"
+	archiveTarGz = "tar.gz"
+)
+
+// archiveFormat menentukan format arsip dari nama file, atau dari isinya jika ekstensinya
+// tidak dikenal (misalnya file yang diunduh tanpa ekstensi).
+func archiveFormat(path string) (string, error) {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return archiveZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return archiveTarGz, nil
+	case strings.HasSuffix(name, ".tar"):
+		return archiveTar, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 262)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return archiveZip, nil
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return archiveTarGz, nil
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return archiveTar, nil
+	}
+	return "", fmt.Errorf("%s bukan arsip zip, tar, atau tar.gz", path)
+}
+
+// defaultExtractDir adalah direktori tujuan ekstrak default: nama arsip tanpa ekstensinya,
+// misalnya "data.tar.gz" -> "data".
+func defaultExtractDir(archive string) string {
+	lower := strings.ToLower(archive)
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip", ".tar"} {
+		if strings.HasSuffix(lower, ext) {
+			return archive[:len(archive)-len(ext)]
+		}
+	}
+	return archive + ".d"
+}
+
+// extractArchive mengekstrak arsip zip, tar, atau tar.gz ke 'dest'. Semua file ditulis lewat
+// os.Root, jadi tidak ada yang bisa keluar dari 'dest'. Arsip yang berisi path absolut atau
+// ".." ditolak seluruhnya. Symlink, hard link, dan file khusus tidak dibuat, hanya dihitung
+// di 'skipped'.
+func extractArchive(archive, dest string) (files, skipped int, err error) {
+	format, err := archiveFormat(archive)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return 0, 0, fmt.Errorf("gagal membuat direktori %s: %v", dest, err)
+	}
+	root, err := os.OpenRoot(dest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("gagal membuka direktori %s: %v", dest, err)
+	}
+	defer root.Close()
+
+	x := &extractor{root: root}
+	switch format {
+	case archiveZip:
+		err = x.zip(archive)
+	default:
+		err = x.tar(archive, format == archiveTarGz)
+	}
+	return x.files, x.skipped, err
+}
+
+// extractor menulis isi arsip ke dalam satu direktori root.
+type extractor struct {
+	root    *os.Root
+	files   int
+	skipped int
+}
+
+func (x *extractor) zip(archive string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("gagal membuka arsip %s: %v", archive, err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := x.dir(f.Name); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("gagal membaca %s dari arsip: %v", f.Name, err)
+			}
+			err = x.file(f.Name, mode, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			if _, err := entryPath(f.Name); err != nil {
+				return err
+			}
+			x.skipped++
+		}
+	}
+	return nil
+}
+
+func (x *extractor) tar(archive string, gzipped bool) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("gagal membuka arsip %s: %v", archive, err)
+	}
+	defer f.Close()
+	var r io.Reader = bufio.NewReader(f)
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("arsip %s bukan gzip yang valid: %v", archive, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("arsip %s rusak: %v", archive, err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(hdr.Name)
+		case tar.TypeReg:
+			err = x.file(hdr.Name, hdr.FileInfo().Mode(), tr)
+		default:
+			_, err = entryPath(hdr.Name)
+			x.skipped++
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// entryPath mengubah nama entri arsip menjadi path relatif yang aman, atau error jika nama
+// tersebut absolut atau keluar dari direktori tujuan lewat "..".
+func entryPath(name string) (string, error) {
+	p := filepath.FromSlash(strings.TrimSuffix(name, "/"))
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("arsip berisi path berbahaya '%s'; ekstrak dibatalkan", name)
+	}
+	return filepath.Clean(p), nil
+}
+
+// dir membuat direktori 'name' beserta induknya di dalam root.
+func (x *extractor) dir(name string) error {
+	p, err := entryPath(name)
+	if err != nil {
+		return err
+	}
+	return x.mkdirAll(p)
+}
+
+func (x *extractor) mkdirAll(p string) error {
+	if p == "." {
+		return nil
+	}
+	if err := x.mkdirAll(filepath.Dir(p)); err != nil {
+		return err
+	}
+	if err := x.root.Mkdir(p, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("gagal membuat direktori %s: %v", p, err)
+	}
+	return nil
+}
+
+// file menulis satu file biasa dari arsip, menimpa file lama dengan nama yang sama.
+func (x *extractor) file(name string, mode fs.FileMode, r io.Reader) error {
+	p, err := entryPath(name)
+	if err != nil {
+		return err
+	}
+	if err := x.mkdirAll(filepath.Dir(p)); err != nil {
+		return err
+	}
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	out, err := x.root.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("gagal membuat %s: %v", p, err)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return fmt.Errorf("gagal mengekstrak %s: %v", p, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("gagal menulis %s: %v", p, err)
+	}
+	x.files++
+	return nil
+}
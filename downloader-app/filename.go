@@ -0,0 +1,192 @@
+// mini-projects/downloader-app/filename.go
+package parallel_downloader_app
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kebijakan jika file output sudah ada.
+const (
+	ExistOverwrite = "overwrite" // Timpa file lama (default)
+	ExistSkip      = "skip"      // Jangan unduh; kembalikan file lama dengan Result.Skipped
+	ExistRename    = "rename"    // Simpan dengan nama baru, misalnya "file (1).zip"
+)
+
+// maxFilenameBytes adalah panjang nama file terbesar yang didukung kebanyakan filesystem.
+const maxFilenameBytes = 255
+
+// windowsReservedNames tidak boleh dipakai sebagai nama file di Windows, dengan ekstensi apa pun.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeFilename mengubah nama file dari server (Content-Disposition atau URL) menjadi nama
+// yang aman disimpan di direktori output: komponen direktori dibuang (mencegah "../../etc/passwd"),
+// karakter kontrol dan karakter yang dilarang di Windows diganti '_', spasi/titik di ujung
+// dihapus, nama perangkat Windows diberi awalan '_', dan panjangnya dibatasi 255 byte dengan
+// ekstensi tetap utuh. Mengembalikan string kosong jika tidak ada nama yang tersisa.
+func sanitizeFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), strings.ContainsRune(`<>:"|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return ""
+	}
+	if stem, _, _ := strings.Cut(name, "."); windowsReservedNames[strings.ToUpper(stem)] {
+		name = "_" + name
+	}
+	if len(name) > maxFilenameBytes {
+		ext := path.Ext(name)
+		if len(ext) > 16 {
+			ext = "" // Bukan ekstensi sungguhan, potong saja
+		}
+		stem := name[:len(name)-len(ext)]
+		cut := maxFilenameBytes - len(ext)
+		for cut > 0 && !utf8.RuneStart(stem[cut]) {
+			cut-- // Jangan memotong di tengah karakter UTF-8
+		}
+		name = stem[:cut] + ext
+	}
+	return name
+}
+
+// lenientFilenameParam mengambil filename dari Content-Disposition yang tidak sesuai standar
+// (misalnya tanpa tanda kutip padahal berisi '/'), yang ditolak oleh mime.ParseMediaType.
+var lenientFilenameParam = regexp.MustCompile(`(?i)(?:^|;)\s*filename\s*=\s*"?([^";]+)"?`)
+
+// filenameFromContentDisposition membaca nama file dari header Content-Disposition.
+// filename* (RFC 5987, nama UTF-8 yang di-encode persen) diutamakan daripada filename.
+// Nama yang dikembalikan sudah disanitasi; string kosong jika tidak ada.
+func filenameFromContentDisposition(value string) string {
+	if value == "" {
+		return ""
+	}
+	if _, params, err := mime.ParseMediaType(value); err == nil {
+		return sanitizeFilename(params["filename"])
+	}
+	if m := lenientFilenameParam.FindStringSubmatch(value); m != nil {
+		return sanitizeFilename(strings.TrimSpace(m[1]))
+	}
+	return ""
+}
+
+// filenameFromURL mengambil bagian terakhir path URL sebagai nama file yang sudah disanitasi.
+func filenameFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return sanitizeFilename(path.Base(u.Path))
+}
+
+// outputNameFor menentukan nama file output jika pengguna tidak mengisinya, berurutan dari:
+// Content-Disposition server, nama di URL akhir setelah redirect, lalu nama di URL asli.
+// Nama dengan ekstensi lebih diutamakan (misalnya "/unduh?id=3" yang dialihkan ke
+// "/files/laporan.pdf"). Jika tidak ada nama sama sekali, dipakai "download.bin".
+func outputNameFor(originalURL string, info remoteInfo) string {
+	if info.Filename != "" {
+		return info.Filename
+	}
+	var candidates []string
+	for _, u := range []string{info.FinalURL, originalURL} {
+		if name := filenameFromURL(u); name != "" {
+			candidates = append(candidates, name)
+		}
+	}
+	for _, name := range candidates {
+		if path.Ext(name) != "" {
+			return name
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return "download.bin"
+}
+
+// resolveCollision menerapkan kebijakan 'policy' jika 'output' sudah ada. Mengembalikan path
+// yang dipakai dan apakah download dilewati. Output yang masih punya manifest dianggap download
+// yang belum selesai (bukan bentrok), sehingga tetap bisa dilanjutkan.
+func resolveCollision(output, fileURL, policy string) (string, bool, error) {
+	if !outputExists(output) {
+		return output, false, nil
+	}
+	switch policy {
+	case "", ExistOverwrite:
+		return output, false, nil
+	case ExistSkip:
+		return output, true, nil
+	case ExistRename:
+		ext := filepath.Ext(output)
+		stem := strings.TrimSuffix(output, ext)
+		for i := 1; i < 10000; i++ {
+			candidate := fmt.Sprintf("%s (%d)%s", stem, i, ext)
+			if _, err := os.Stat(candidate); err == nil {
+				continue
+			}
+			// Nama yang sedang dipakai download lain yang belum selesai dilewati, tetapi download
+			// sebelumnya dari URL yang sama dengan nama ini dilanjutkan.
+			if m, _ := loadManifest(manifestPath(candidate)); m == nil || m.URL == fileURL {
+				return candidate, false, nil
+			}
+		}
+		return "", false, fmt.Errorf("tidak menemukan nama bebas untuk %s", output)
+	}
+	return "", false, fmt.Errorf("kebijakan file yang sudah ada tidak dikenal: '%s' (pilih %s, %s, atau %s)", policy, ExistOverwrite, ExistSkip, ExistRename)
+}
+
+// outputExists memeriksa apakah file output selesai sudah ada. File yang masih punya
+// manifest adalah download yang belum selesai.
+func outputExists(output string) bool {
+	if _, err := os.Stat(output); err != nil {
+		return false
+	}
+	_, err := os.Stat(manifestPath(output))
+	return err != nil
+}
+
+// sensitiveRedirectHeaders tidak dikirim ke host lain setelah redirect, sama seperti aturan
+// http.Client saat mengikuti redirect.
+var sensitiveRedirectHeaders = []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"}
+
+// pinnedHeaders mengembalikan header untuk request langsung ke URL akhir hasil redirect.
+// Jika URL akhir berada di host lain (bukan host asli atau subdomainnya), header kredensial
+// dibuang agar tidak bocor ke server lain.
+func pinnedHeaders(headers http.Header, originalURL, finalURL string) http.Header {
+	if headers == nil || finalURL == originalURL {
+		return headers
+	}
+	orig, err1 := url.Parse(originalURL)
+	final, err2 := url.Parse(finalURL)
+	if err1 == nil && err2 == nil {
+		oh, fh := strings.ToLower(orig.Hostname()), strings.ToLower(final.Hostname())
+		if fh == oh || strings.HasSuffix(fh, "."+oh) {
+			return headers
+		}
+	}
+	h := headers.Clone()
+	for _, k := range sensitiveRedirectHeaders {
+		h.Del(k)
+	}
+	return h
+}
@@ -0,0 +1,167 @@
+// mini-projects/downloader-app/filename_test.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	long := strings.Repeat("a", 300) + ".tar.gz"
+	tests := []struct {
+		in, want string
+	}{
+		{"laporan.pdf", "laporan.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`..\..\Windows\system.ini`, "system.ini"},
+		{"a<b>c:d\"e|f?g*h.txt", "a_b_c_d_e_f_g_h.txt"},
+		{"baris\nbaru\x00.txt", "baris_baru_.txt"},
+		{"  nama. ", "nama"},
+		{"..", ""},
+		{"/", ""},
+		{"CON.txt", "_CON.txt"},
+		{"console.txt", "console.txt"},
+		{"été.txt", "été.txt"},
+		{long, strings.Repeat("a", 255-len(".gz")) + ".gz"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFilename(tt.in); got != tt.want {
+			t.Errorf("sanitizeFilename(%q) = %q, ingin %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFilenameFromContentDisposition(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`attachment; filename="laporan 2024.pdf"`, "laporan 2024.pdf"},
+		{`attachment; filename="a.txt"; filename*=UTF-8''%C3%A9t%C3%A9.txt`, "été.txt"},
+		{`attachment; filename=../../evil.sh`, "evil.sh"},
+		{`attachment; filename="/etc/cron.d/job"`, "job"},
+		{`inline`, ""},
+		{``, ""},
+	}
+	for _, tt := range tests {
+		if got := filenameFromContentDisposition(tt.in); got != tt.want {
+			t.Errorf("filenameFromContentDisposition(%q) = %q, ingin %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// redirectingServer mengalihkan setiap request ke 'target' dan menghitung request yang diterimanya.
+func redirectingServer(t *testing.T, target string, hits *atomic.Int64) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Redirect(w, r, target, http.StatusFound)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRedirectPinnedAndOutputNamedFromServer(t *testing.T) {
+	content := randomContent(512*1024, 80)
+	fs, files := newTestFileServer(t, content, `"v1"`)
+	var hits atomic.Int64
+	redirect := redirectingServer(t, files.URL+"/files/laporan.pdf", &hits)
+
+	dir := t.TempDir()
+	d := mustNewDownloader(t, Options{OutputDir: dir, Parts: 4, ProgressMode: ProgressOff})
+	res, err := d.Download(context.Background(), redirect.URL+"/unduh?id=3")
+	if err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	if want := filepath.Join(dir, "laporan.pdf"); res.Output != want {
+		t.Errorf("output = %s, ingin %s (nama dari URL akhir)", res.Output, want)
+	}
+	assertFileContent(t, res.Output, content)
+	// Hanya HEAD yang melewati redirect; semua GET bagian langsung ke URL akhir.
+	if hits.Load() != 1 {
+		t.Errorf("server redirect menerima %d request, ingin 1", hits.Load())
+	}
+	if len(fs.requests) < 4 {
+		t.Errorf("hanya %d GET bagian sampai ke URL akhir", len(fs.requests))
+	}
+
+	// Content-Disposition lebih diutamakan dan disanitasi agar tetap di dalam OutputDir.
+	fs.headers = http.Header{"Content-Disposition": {`attachment; filename="../../rahasia.bin"`}}
+	res, err = d.Download(context.Background(), files.URL+"/files/laporan.pdf")
+	if err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	if want := filepath.Join(dir, "rahasia.bin"); res.Output != want {
+		t.Errorf("output = %s, ingin %s (nama dari Content-Disposition)", res.Output, want)
+	}
+	assertFileContent(t, res.Output, content)
+}
+
+func TestRedirectToOtherHostDropsCredentials(t *testing.T) {
+	content := randomContent(256*1024, 81)
+	fs, files := newTestFileServer(t, content, `"v1"`)
+	var hits atomic.Int64
+	// Host "localhost" berbeda dengan "127.0.0.1" milik server redirect.
+	target := strings.Replace(files.URL, "127.0.0.1", "localhost", 1) + "/file.bin"
+	redirect := redirectingServer(t, target, &hits)
+
+	out := filepath.Join(t.TempDir(), "file.bin")
+	d := mustNewDownloader(t, Options{Output: out, BearerToken: "rahasia", Headers: http.Header{"X-Klien": {"uji"}}, ProgressMode: ProgressOff})
+	if _, err := d.Download(context.Background(), redirect.URL); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+	for _, h := range fs.requests {
+		if h.Get("Authorization") != "" {
+			t.Error("header Authorization terkirim ke host lain setelah redirect")
+		}
+		if h.Get("X-Klien") != "uji" {
+			t.Error("header tambahan yang bukan kredensial seharusnya tetap dikirim")
+		}
+	}
+}
+
+func TestOnExistPolicies(t *testing.T) {
+	content := randomContent(128*1024, 82)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "file.bin")
+	if err := os.WriteFile(out, []byte("lama"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	skip := mustNewDownloader(t, Options{Output: out, OnExist: ExistSkip, ProgressMode: ProgressOff})
+	res, err := skip.Download(context.Background(), srv.URL)
+	if err != nil || !res.Skipped || len(fs.requests) != 0 {
+		t.Fatalf("skip: res=%+v err=%v, %d GET", res, err, len(fs.requests))
+	}
+	assertFileContent(t, out, []byte("lama"))
+
+	rename := mustNewDownloader(t, Options{Output: out, OnExist: ExistRename, ProgressMode: ProgressOff})
+	for i, want := range []string{"file (1).bin", "file (2).bin"} {
+		res, err := rename.Download(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("rename %d: %v", i, err)
+		}
+		if res.Output != filepath.Join(dir, want) {
+			t.Errorf("rename %d: output = %s, ingin %s", i, res.Output, want)
+		}
+		assertFileContent(t, res.Output, content)
+	}
+	assertFileContent(t, out, []byte("lama"))
+
+	overwrite := mustNewDownloader(t, Options{Output: out, OnExist: ExistOverwrite, ProgressMode: ProgressOff})
+	if _, err := overwrite.Download(context.Background(), srv.URL); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	assertFileContent(t, out, content)
+
+	if _, err := NewDownloader(Options{OnExist: "tanya"}); err == nil {
+		t.Error("OnExist yang tidak dikenal seharusnya ditolak")
+	}
+}
@@ -0,0 +1,451 @@
+// mini-projects/downloader-app/history.go
+package parallel_downloader_app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// historyFilePath adalah file tempat riwayat download disimpan.
+const historyFilePath = "download-history.json"
+
+// Status entri riwayat.
+const (
+	historyOK     = "ok"     // Download selesai
+	historyFailed = "failed" // Download gagal atau dibatalkan
+)
+
+// defaultHistoryLimit adalah jumlah entri yang ditampilkan 'list' tanpa limit=N.
+const defaultHistoryLimit = 20
+
+// historyEntry adalah satu download yang tercatat di riwayat.
+type historyEntry struct {
+	ID             int               `json:"id"`
+	URL            string            `json:"url"`
+	Mirrors        []string          `json:"mirrors,omitempty"`
+	Output         string            `json:"output,omitempty"`
+	Status         string            `json:"status"`
+	Size           int64             `json:"size"`
+	ElapsedSeconds float64           `json:"elapsed_seconds"`
+	BytesPerSecond float64           `json:"bytes_per_second"`
+	Checksums      map[string]string `json:"checksums,omitempty"`
+	ETag           string            `json:"etag,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	FinishedAt     time.Time         `json:"finished_at"`
+}
+
+// historyFile adalah isi file riwayat di disk.
+type historyFile struct {
+	NextID  int            `json:"next_id"`
+	Entries []historyEntry `json:"entries"`
+}
+
+// downloadHistory menyimpan riwayat download yang selesai dan gagal. Setiap entri baru
+// langsung disimpan ke disk, sama seperti antrean download.
+type downloadHistory struct {
+	mu      sync.Mutex
+	path    string
+	nextID  int
+	entries []historyEntry // Urut dari yang paling lama
+}
+
+// historyFilter memilih entri riwayat untuk ditampilkan. Nilai nol berarti semua entri.
+type historyFilter struct {
+	Query  string    // Teks di URL atau nama file output (tanpa membedakan huruf besar/kecil)
+	Status string    // historyOK atau historyFailed
+	Since  time.Time // Hanya download yang selesai sejak waktu ini
+	Limit  int       // Jumlah entri maksimal (0 = semua)
+}
+
+// historyStats adalah ringkasan seluruh riwayat.
+type historyStats struct {
+	Total, OK, Failed int
+	Bytes             int64   // Total ukuran download yang berhasil
+	BytesPerSecond    float64 // Kecepatan rata-rata download yang berhasil
+	Hosts             []hostStats
+}
+
+// hostStats adalah jumlah download berhasil dan ukurannya per host.
+type hostStats struct {
+	Host      string
+	Downloads int
+	Bytes     int64
+}
+
+// openDownloadHistory memuat riwayat dari 'path' (atau riwayat kosong jika file belum ada).
+func openDownloadHistory(path string) (*downloadHistory, error) {
+	h := &downloadHistory{path: path, nextID: 1}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca riwayat %s: %v", path, err)
+	}
+	var f historyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("riwayat %s rusak: %v", path, err)
+	}
+	h.entries = f.Entries
+	h.nextID = max(f.NextID, 1)
+	for _, e := range h.entries {
+		h.nextID = max(h.nextID, e.ID+1)
+	}
+	return h, nil
+}
+
+// saveLocked menulis riwayat secara atomik, sama seperti manifest. h.mu harus dipegang.
+func (h *downloadHistory) saveLocked() error {
+	data, err := json.MarshalIndent(historyFile{NextID: h.nextID, Entries: h.entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("gagal mengkodekan riwayat: %v", err)
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("gagal menulis riwayat: %v", err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return fmt.Errorf("gagal menyimpan riwayat: %v", err)
+	}
+	return nil
+}
+
+// record mencatat hasil download dari 'urls' (URL utama lalu mirror). Download yang dilewati
+// karena file sudah ada tidak dicatat.
+func (h *downloadHistory) record(urls []string, res Result, err error) (historyEntry, error) {
+	e := historyEntry{
+		URL: urls[0], Mirrors: urls[1:], Output: res.Output, Status: historyOK, Size: res.Size,
+		ElapsedSeconds: res.Elapsed.Seconds(), Checksums: res.Checksums, ETag: res.ETag, FinishedAt: time.Now(),
+	}
+	if res.Elapsed > 0 {
+		e.BytesPerSecond = float64(res.Size) / res.Elapsed.Seconds()
+	}
+	if err != nil {
+		e.Status, e.Error = historyFailed, err.Error()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e.ID = h.nextID
+	h.nextID++
+	h.entries = append(h.entries, e)
+	return e, h.saveLocked()
+}
+
+// find mencari entri berdasarkan ID.
+func (h *downloadHistory) find(id int) (historyEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, e := range h.entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return historyEntry{}, fmt.Errorf("riwayat #%d tidak ditemukan", id)
+}
+
+// search mengembalikan entri yang cocok dengan 'f', dari yang paling baru.
+func (h *downloadHistory) search(f historyFilter) []historyEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	query := strings.ToLower(f.Query)
+	var found []historyEntry
+	for _, e := range slices.Backward(h.entries) {
+		switch {
+		case f.Status != "" && e.Status != f.Status:
+		case e.FinishedAt.Before(f.Since):
+		case query != "" && !strings.Contains(strings.ToLower(e.URL), query) && !strings.Contains(strings.ToLower(e.Output), query):
+		default:
+			found = append(found, e)
+		}
+		if f.Limit > 0 && len(found) == f.Limit {
+			break
+		}
+	}
+	return found
+}
+
+// lastFetched mencari download berhasil terakhir dari 'fileURL' dengan ETag 'etag'. Jika
+// 'etag' kosong, ETag apa pun cocok asalkan tercatat.
+func (h *downloadHistory) lastFetched(fileURL, etag string) (historyEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, e := range slices.Backward(h.entries) {
+		if e.Status == historyOK && e.URL == fileURL && e.ETag != "" && (etag == "" || e.ETag == etag) {
+			return e, true
+		}
+	}
+	return historyEntry{}, false
+}
+
+// stats menghitung ringkasan seluruh riwayat.
+func (h *downloadHistory) stats() historyStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var s historyStats
+	var elapsed float64
+	hosts := make(map[string]*hostStats)
+	for _, e := range h.entries {
+		s.Total++
+		if e.Status != historyOK {
+			s.Failed++
+			continue
+		}
+		s.OK++
+		s.Bytes += e.Size
+		elapsed += e.ElapsedSeconds
+		host := e.URL
+		if u, err := url.Parse(e.URL); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		hs := hosts[host]
+		if hs == nil {
+			hs = &hostStats{Host: host}
+			hosts[host] = hs
+		}
+		hs.Downloads++
+		hs.Bytes += e.Size
+	}
+	if elapsed > 0 {
+		s.BytesPerSecond = float64(s.Bytes) / elapsed
+	}
+	for _, hs := range hosts {
+		s.Hosts = append(s.Hosts, *hs)
+	}
+	sort.Slice(s.Hosts, func(i, j int) bool {
+		if s.Hosts[i].Bytes != s.Hosts[j].Bytes {
+			return s.Hosts[i].Bytes > s.Hosts[j].Bytes
+		}
+		return s.Hosts[i].Host < s.Hosts[j].Host
+	})
+	return s
+}
+
+// parseHistoryFilter membaca filter dari argumen perintah: "status=ok|failed",
+// "since=2006-01-02" atau "since=72h", "limit=N", dan sisanya sebagai teks pencarian.
+func parseHistoryFilter(args []string, now time.Time) (historyFilter, error) {
+	f := historyFilter{Limit: defaultHistoryLimit}
+	var query []string
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		switch {
+		case ok && key == "status":
+			if value != historyOK && value != historyFailed {
+				return historyFilter{}, fmt.Errorf("status tidak dikenal: '%s' (pilih %s atau %s)", value, historyOK, historyFailed)
+			}
+			f.Status = value
+		case ok && key == "since":
+			if d, err := time.ParseDuration(value); err == nil {
+				f.Since = now.Add(-d)
+			} else if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+				f.Since = t
+			} else {
+				return historyFilter{}, fmt.Errorf("waktu tidak valid: '%s' (contoh: since=2006-01-02 atau since=24h)", value)
+			}
+		case ok && key == "limit":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return historyFilter{}, fmt.Errorf("limit tidak valid: '%s'", value)
+			}
+			f.Limit = n
+		default:
+			query = append(query, arg)
+		}
+	}
+	f.Query = strings.Join(query, " ")
+	return f, nil
+}
+
+// confirmRedownload memeriksa riwayat sebelum download: jika 'fileURL' pernah diunduh dan
+// ETag-nya di server masih sama, pengguna ditanya apakah tetap ingin mengunduh. Server hanya
+// dihubungi jika URL tersebut pernah tercatat dengan ETag. Mengembalikan false jika
+// pengguna membatalkan.
+func confirmRedownload(ctx context.Context, reader *bufio.Reader, h *downloadHistory, d *Downloader, fileURL string) bool {
+	if _, ok := h.lastFetched(fileURL, ""); !ok {
+		return true
+	}
+	etag, err := d.remoteETag(ctx, fileURL)
+	if err != nil || etag == "" {
+		return true // Error sebenarnya dilaporkan oleh download itu sendiri
+	}
+	prev, ok := h.lastFetched(fileURL, etag)
+	if !ok {
+		return true
+	}
+	fmt.Printf("Peringatan: file ini (ETag %s) sudah diunduh pada %s ke %s (riwayat #%d).\n",
+		etag, prev.FinishedAt.Format("2006-01-02 15:04"), prev.Output, prev.ID)
+	fmt.Print("Tetap unduh lagi? (y/N): ")
+	answer, _ := reader.ReadString('\n')
+	return strings.EqualFold(strings.TrimSpace(answer), "y")
+}
+
+// RunDownloadHistoryCLI menampilkan riwayat download secara interaktif: daftar dengan
+// pencarian dan filter, detail, statistik, dan download ulang.
+func RunDownloadHistoryCLI(reader *bufio.Reader) {
+	fmt.Println("\n--- Riwayat Download ---")
+	h, err := openDownloadHistory(historyFilePath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	printHistoryHelp()
+	for {
+		fmt.Print("\nriwayat> ")
+		line, err := reader.ReadString('\n')
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			if err != nil {
+				return // stdin ditutup
+			}
+			continue
+		}
+
+		cmd, args := strings.ToLower(fields[0]), fields[1:]
+		switch cmd {
+		case "list", "ls", "search":
+			f, err := parseHistoryFilter(args, time.Now())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			printHistory(h.search(f))
+		case "show":
+			id, err := parseJobID(args)
+			if err != nil {
+				fmt.Println("Penggunaan: show <id>")
+				continue
+			}
+			e, err := h.find(id)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			printHistoryEntry(e)
+		case "redownload":
+			id, err := parseJobID(args)
+			if err != nil {
+				fmt.Println("Penggunaan: redownload <id>")
+				continue
+			}
+			e, err := h.find(id)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			redownload(h, e)
+		case "stats":
+			printHistoryStats(h.stats())
+		case "help":
+			printHistoryHelp()
+		case "exit", "quit", "back":
+			return
+		default:
+			fmt.Println("Perintah tidak dikenal. Ketik 'help' untuk daftar perintah.")
+		}
+	}
+}
+
+// redownload mengunduh ulang URL (dan mirror) dari entri riwayat ke file output yang sama,
+// lalu mencatat hasilnya sebagai entri baru.
+func redownload(h *downloadHistory, e historyEntry) {
+	d, err := NewDownloader(Options{Output: e.Output, OnExist: ExistOverwrite, KeepPartial: true})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Mengunduh ulang %s...\n", e.URL)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	urls := append([]string{e.URL}, e.Mirrors...)
+	res, err := d.DownloadFrom(ctx, urls...)
+	entry, recErr := h.record(urls, res, err)
+	if recErr != nil {
+		fmt.Printf("Peringatan: %v\n", recErr)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("\n--- File '%s' berhasil diunduh ulang (%s, riwayat #%d). ---\n", res.Output, formatBytes(res.Size), entry.ID)
+	if old, cur := e.Checksums["sha256"], res.Checksums["sha256"]; old != "" && cur != "" && old != cur {
+		fmt.Println("Isi file berbeda dari download sebelumnya (SHA-256 berubah).")
+	}
+}
+
+func printHistoryHelp() {
+	fmt.Println("Perintah:")
+	fmt.Println("  list [teks] [filter]   Tampilkan riwayat terbaru; teks dicari di URL dan nama file")
+	fmt.Println("                         filter: status=ok|failed, since=2006-01-02 atau since=24h, limit=N (0 = semua)")
+	fmt.Println("  show <id>              Tampilkan detail satu download")
+	fmt.Println("  redownload <id>        Unduh ulang URL dari riwayat ke file yang sama")
+	fmt.Println("  stats                  Tampilkan statistik seluruh riwayat")
+	fmt.Println("  exit                   Kembali ke menu utama")
+}
+
+// printHistory menampilkan entri riwayat dalam bentuk tabel.
+func printHistory(entries []historyEntry) {
+	if len(entries) == 0 {
+		fmt.Println("Tidak ada riwayat yang cocok.")
+		return
+	}
+	fmt.Printf("%-4s %-6s %-16s %-10s %-12s %s\n", "ID", "STATUS", "WAKTU", "UKURAN", "KECEPATAN", "OUTPUT")
+	for _, e := range entries {
+		fmt.Printf("%-4d %-6s %-16s %-10s %-12s %s\n", e.ID, e.Status, e.FinishedAt.Format("2006-01-02 15:04"),
+			formatBytes(e.Size), formatBytes(int64(e.BytesPerSecond))+"/s", e.Output)
+		fmt.Printf("     %s\n", e.URL)
+		if e.Error != "" {
+			fmt.Printf("     error: %s\n", e.Error)
+		}
+	}
+}
+
+// printHistoryEntry menampilkan semua data satu entri riwayat.
+func printHistoryEntry(e historyEntry) {
+	fmt.Printf("Riwayat #%d (%s)\n", e.ID, e.Status)
+	fmt.Printf("URL:       %s\n", e.URL)
+	for _, m := range e.Mirrors {
+		fmt.Printf("Mirror:    %s\n", m)
+	}
+	fmt.Printf("File:      %s\n", e.Output)
+	fmt.Printf("Selesai:   %s\n", e.FinishedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Ukuran:    %s (%d bytes)\n", formatBytes(e.Size), e.Size)
+	fmt.Printf("Durasi:    %s, rata-rata %s/s\n", formatDuration(time.Duration(e.ElapsedSeconds*float64(time.Second))), formatBytes(int64(e.BytesPerSecond)))
+	if e.ETag != "" {
+		fmt.Printf("ETag:      %s\n", e.ETag)
+	}
+	algos := make([]string, 0, len(e.Checksums))
+	for algo := range e.Checksums {
+		algos = append(algos, algo)
+	}
+	sort.Strings(algos)
+	for _, algo := range algos {
+		fmt.Printf("%-10s %s\n", algo+":", e.Checksums[algo])
+	}
+	if e.Error != "" {
+		fmt.Printf("Error:     %s\n", e.Error)
+	}
+}
+
+// printHistoryStats menampilkan ringkasan riwayat dan host dengan data terbanyak.
+func printHistoryStats(s historyStats) {
+	fmt.Printf("Total download: %d (%d berhasil, %d gagal)\n", s.Total, s.OK, s.Failed)
+	fmt.Printf("Total data: %s, kecepatan rata-rata %s/s\n", formatBytes(s.Bytes), formatBytes(int64(s.BytesPerSecond)))
+	if len(s.Hosts) > 0 {
+		fmt.Println("Host teratas:")
+		for _, hs := range s.Hosts[:min(len(s.Hosts), 5)] {
+			fmt.Printf("- %s: %d download, %s\n", hs.Host, hs.Downloads, formatBytes(hs.Bytes))
+		}
+	}
+}
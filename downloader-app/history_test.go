@@ -0,0 +1,151 @@
+// mini-projects/downloader-app/history_test.go
+package parallel_downloader_app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryRecordSearchAndStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	h, err := openDownloadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []struct {
+		url, output string
+		size        int64
+		err         error
+	}{
+		{"https://a.example/linux.iso", "linux.iso", 4 << 20, nil},
+		{"https://b.example/data.zip", "data.zip", 0, errors.New("server mengembalikan status 404")},
+		{"https://a.example/docs.tar.gz", "docs.tar.gz", 1 << 20, nil},
+	}
+	for _, r := range records {
+		res := Result{URL: r.url, Output: r.output, Size: r.size, Elapsed: time.Second, ETag: `"v1"`}
+		if _, err := h.record([]string{r.url}, res, r.err); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Riwayat tersimpan di disk dan dibaca ulang dengan ID berikutnya yang benar.
+	h, err = openDownloadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(entries []historyEntry) []int {
+		var out []int
+		for _, e := range entries {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+	now := time.Now()
+	tests := []struct {
+		args []string
+		want []int
+	}{
+		{nil, []int{3, 2, 1}}, // Terbaru dulu
+		{[]string{"A.EXAMPLE"}, []int{3, 1}},
+		{[]string{"status=failed"}, []int{2}},
+		{[]string{"status=ok", "limit=1"}, []int{3}},
+		{[]string{"iso", "since=1h"}, []int{1}},
+		{[]string{"since=" + now.Add(48*time.Hour).Format("2006-01-02")}, nil},
+	}
+	for _, tt := range tests {
+		f, err := parseHistoryFilter(tt.args, now)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.args, err)
+		}
+		if got := ids(h.search(f)); !slices.Equal(got, tt.want) {
+			t.Errorf("%q: ID %v, ingin %v", tt.args, got, tt.want)
+		}
+	}
+	for _, args := range [][]string{{"status=selesai"}, {"since=kemarin"}, {"limit=-1"}} {
+		if _, err := parseHistoryFilter(args, now); err == nil {
+			t.Errorf("%q seharusnya ditolak", args)
+		}
+	}
+
+	e, err := h.record([]string{"https://c.example/x.bin"}, Result{Output: "x.bin"}, nil)
+	if err != nil || e.ID != 4 {
+		t.Fatalf("entri baru #%d, err %v; ingin #4", e.ID, err)
+	}
+	s := h.stats()
+	if s.Total != 4 || s.OK != 3 || s.Failed != 1 || s.Bytes != 5<<20 {
+		t.Errorf("statistik salah: %+v", s)
+	}
+	if len(s.Hosts) != 2 || s.Hosts[0].Host != "a.example" || s.Hosts[0].Downloads != 2 {
+		t.Errorf("host teratas salah: %+v", s.Hosts)
+	}
+}
+
+func TestConfirmRedownloadWarnsOnSameETag(t *testing.T) {
+	content := randomContent(64*1024, 50)
+	fs, srv := newTestFileServer(t, content, `"v1"`)
+	h, err := openDownloadHistory(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDownloader(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirm := func(input string) bool {
+		return confirmRedownload(context.Background(), bufio.NewReader(strings.NewReader(input)), h, d, srv.URL)
+	}
+
+	// URL yang belum pernah diunduh tidak perlu dikonfirmasi.
+	if !confirm("") {
+		t.Error("URL baru seharusnya langsung diunduh")
+	}
+	if _, err := h.record([]string{srv.URL}, Result{Output: "file.bin", Size: int64(len(content)), ETag: `"v1"`}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if confirm("\n") {
+		t.Error("ETag sama: download seharusnya dibatalkan tanpa konfirmasi 'y'")
+	}
+	if !confirm("y\n") {
+		t.Error("ETag sama: download seharusnya diteruskan setelah dikonfirmasi")
+	}
+
+	// File di server berubah (ETag baru): tidak ada peringatan.
+	fs.set(content, `"v2"`, 0)
+	if !confirm("") {
+		t.Error("ETag berbeda seharusnya tidak memerlukan konfirmasi")
+	}
+}
+
+func TestQueueRecordsHistory(t *testing.T) {
+	content := randomContent(128*1024, 51)
+	_, srv := newTestFileServer(t, content, `"v1"`)
+	dir := t.TempDir()
+	q, err := openDownloadQueue(filepath.Join(dir, "queue.json"), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.history, err = openDownloadHistory(filepath.Join(dir, "history.json")); err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.add(srv.URL+"/file.bin", filepath.Join(dir, "file.bin"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.start(context.Background())
+	defer q.stop()
+	waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobDone })
+
+	entries := q.history.search(historyFilter{})
+	if len(entries) != 1 {
+		t.Fatalf("%d entri riwayat, ingin 1", len(entries))
+	}
+	if e := entries[0]; e.Status != historyOK || e.Size != int64(len(content)) || e.ETag != `"v1"` || e.Output != job.Output {
+		t.Errorf("entri riwayat salah: %+v", e)
+	}
+}
@@ -0,0 +1,137 @@
+// mini-projects/downloader-app/httpclient.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Nilai default batas waktu koneksi. Nilai negatif pada Options mematikan batas tersebut.
+const (
+	defaultConnectTimeout        = 30 * time.Second
+	defaultResponseHeaderTimeout = 60 * time.Second
+	defaultIdleConnTimeout       = 90 * time.Second
+	defaultStallTimeout          = 60 * time.Second
+)
+
+// errStalled dikembalikan jika tidak ada satu byte pun yang tiba selama StallTimeout.
+// Bagian yang macet dicoba ulang dengan koneksi baru, dilanjutkan dari byte terakhir.
+var errStalled = errors.New("koneksi macet: tidak ada data yang diterima")
+
+// orDefault mengembalikan 'def' jika d nol, 0 jika d negatif (batas dimatikan), atau d.
+func orDefault(d, def time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return def
+	case d < 0:
+		return 0
+	}
+	return d
+}
+
+// buildHTTPClient membuat client HTTP dari Options: proxy, CA bundle, verifikasi TLS,
+// cookie jar, dan batas waktu koneksi. Jika opts.Client diisi, client itu dipakai apa adanya
+// (pengaturan transport di Options diabaikan).
+func buildHTTPClient(opts Options) (*http.Client, error) {
+	if opts.Client != nil {
+		return opts.Client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: orDefault(opts.ConnectTimeout, defaultConnectTimeout), KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.ResponseHeaderTimeout = orDefault(opts.ResponseHeaderTimeout, defaultResponseHeaderTimeout)
+	transport.IdleConnTimeout = orDefault(opts.IdleTimeout, defaultIdleConnTimeout)
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("URL proxy tidak valid: '%s'", opts.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.CAFile != "" || opts.InsecureSkipVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+		if opts.CAFile != "" {
+			pem, err := os.ReadFile(opts.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("gagal membaca CA bundle %s: %v", opts.CAFile, err)
+			}
+			// CA tambahan melengkapi CA sistem, bukan menggantikannya.
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("CA bundle %s tidak berisi sertifikat PEM yang valid", opts.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{Transport: transport, Jar: opts.CookieJar}, nil
+}
+
+// requestHeaders menggabungkan Options.Headers dengan header autentikasi (Basic atau Bearer).
+func requestHeaders(opts Options) http.Header {
+	headers := opts.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	switch {
+	case opts.BearerToken != "":
+		headers.Set("Authorization", "Bearer "+opts.BearerToken)
+	case opts.Username != "" || opts.Password != "":
+		credentials := base64.StdEncoding.EncodeToString([]byte(opts.Username + ":" + opts.Password))
+		headers.Set("Authorization", "Basic "+credentials)
+	}
+	return headers
+}
+
+// stallWatcher membatalkan request jika satu Read dari body tidak menghasilkan data selama
+// 'timeout'. Waktu di luar Read (misalnya menunggu pembatas kecepatan atau menulis ke disk)
+// tidak dihitung, jadi download yang sengaja diperlambat tidak dianggap macet.
+type stallWatcher struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	stalled atomic.Bool
+}
+
+// watchStall membungkus body respons. 'cancel' membatalkan request yang sedang berjalan.
+// Jika timeout 0, body dikembalikan apa adanya.
+func watchStall(body io.Reader, timeout time.Duration, cancel context.CancelFunc) io.Reader {
+	if timeout <= 0 {
+		return body
+	}
+	sw := &stallWatcher{r: body, timeout: timeout}
+	sw.timer = time.AfterFunc(timeout, func() {
+		sw.stalled.Store(true)
+		cancel()
+	})
+	sw.timer.Stop()
+	return sw
+}
+
+func (sw *stallWatcher) Read(p []byte) (int, error) {
+	sw.timer.Reset(sw.timeout)
+	n, err := sw.r.Read(p)
+	sw.timer.Stop()
+	if err != nil && sw.stalled.Load() {
+		err = fmt.Errorf("%w selama %v", errStalled, sw.timeout)
+	}
+	return n, err
+}
@@ -0,0 +1,229 @@
+// mini-projects/downloader-app/httpclient_test.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloaderAuthentication(t *testing.T) {
+	content := randomContent(64*1024, 60)
+	tests := []struct {
+		name string
+		opts Options
+		want func(r *http.Request) bool
+	}{
+		{"basic", Options{Username: "budi", Password: "rahasia"}, func(r *http.Request) bool {
+			user, pass, ok := r.BasicAuth()
+			return ok && user == "budi" && pass == "rahasia"
+		}},
+		{"bearer", Options{BearerToken: "token-123"}, func(r *http.Request) bool {
+			return r.Header.Get("Authorization") == "Bearer token-123"
+		}},
+	}
+	for _, tt := range tests {
+		fs := &testFileServer{content: content, etag: `"v1"`}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !tt.want(r) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			fs.ServeHTTP(w, r)
+		}))
+		defer srv.Close()
+
+		out := filepath.Join(t.TempDir(), "file.bin")
+		tt.opts.Output, tt.opts.ProgressMode = out, ProgressOff
+		if _, err := mustNewDownloader(t, tt.opts).Download(context.Background(), srv.URL); err != nil {
+			t.Fatalf("%s: download gagal: %v", tt.name, err)
+		}
+		assertFileContent(t, out, content)
+	}
+}
+
+func TestDownloaderCookieJar(t *testing.T) {
+	content := randomContent(64*1024, 61)
+	fs := &testFileServer{content: content, etag: `"v1"`}
+	// Server memberi cookie sesi pada HEAD dan mewajibkannya pada setiap GET.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			http.SetCookie(w, &http.Cookie{Name: "sesi", Value: "abc"})
+		} else if c, err := r.Cookie("sesi"); err != nil || c.Value != "abc" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		fs.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	jar, _ := cookiejar.New(nil)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	d := mustNewDownloader(t, Options{Output: out, CookieJar: jar, ProgressMode: ProgressOff})
+	if _, err := d.Download(context.Background(), srv.URL); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+}
+
+func TestDownloaderProxy(t *testing.T) {
+	content := randomContent(64*1024, 62)
+	_, origin := newTestFileServer(t, content, `"v1"`)
+
+	var proxied atomic.Int64
+	proxy := httptest.NewServer(&httputil.ReverseProxy{Rewrite: func(r *httputil.ProxyRequest) {
+		proxied.Add(1)
+		r.Out.URL = r.In.URL // Proxy HTTP menerima URL absolut tujuan
+	}})
+	defer proxy.Close()
+
+	out := filepath.Join(t.TempDir(), "file.bin")
+	d := mustNewDownloader(t, Options{Output: out, Proxy: proxy.URL, ProgressMode: ProgressOff})
+	if _, err := d.Download(context.Background(), origin.URL); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+	if proxied.Load() == 0 {
+		t.Error("request tidak melewati proxy")
+	}
+
+	if _, err := NewDownloader(Options{Proxy: "::bukan url"}); err == nil {
+		t.Error("URL proxy tidak valid seharusnya ditolak")
+	}
+}
+
+func TestDownloaderCustomCA(t *testing.T) {
+	content := randomContent(64*1024, 63)
+	fs := &testFileServer{content: content, etag: `"v1"`}
+	srv := httptest.NewTLSServer(fs)
+	defer srv.Close()
+	dir := t.TempDir()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tanpa CA, sertifikat server uji tidak dipercaya.
+	out := filepath.Join(dir, "file.bin")
+	plain := mustNewDownloader(t, Options{Output: out, MaxAttempts: 1, ProgressMode: ProgressOff})
+	if _, err := plain.Download(context.Background(), srv.URL); err == nil {
+		t.Fatal("download ke server dengan sertifikat tidak dikenal seharusnya gagal")
+	}
+
+	for _, opts := range []Options{{CAFile: caFile}, {InsecureSkipVerify: true}} {
+		opts.Output, opts.ProgressMode = out, ProgressOff
+		if _, err := mustNewDownloader(t, opts).Download(context.Background(), srv.URL); err != nil {
+			t.Fatalf("download dengan %+v gagal: %v", opts, err)
+		}
+		assertFileContent(t, out, content)
+		os.Remove(out)
+	}
+
+	if _, err := NewDownloader(Options{CAFile: filepath.Join(dir, "tidak-ada.pem")}); err == nil {
+		t.Error("CA bundle yang tidak ada seharusnya ditolak")
+	}
+}
+
+// stallingServer mengirim sebagian data lalu diam (tanpa menutup koneksi) pada GET pertama,
+// dan melayani normal setelahnya.
+func stallingServer(t *testing.T, content []byte) *httptest.Server {
+	t.Helper()
+	fs := &testFileServer{content: content, etag: `"v1"`}
+	var once sync.Once
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stall := false
+		if r.Method == "GET" {
+			once.Do(func() { stall = true })
+		}
+		if !stall {
+			fs.ServeHTTP(w, r)
+			return
+		}
+		fs.ServeHTTP(&stallingWriter{ResponseWriter: w, ctx: r.Context(), remaining: 16 * 1024}, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// stallingWriter menulis sejumlah byte lalu berhenti sampai klien memutus koneksi.
+type stallingWriter struct {
+	http.ResponseWriter
+	ctx       context.Context
+	remaining int
+}
+
+func (sw *stallingWriter) Write(p []byte) (int, error) {
+	if len(p) > sw.remaining {
+		sw.ResponseWriter.Write(p[:sw.remaining])
+		sw.ResponseWriter.(http.Flusher).Flush()
+		<-sw.ctx.Done()
+		return 0, sw.ctx.Err()
+	}
+	sw.remaining -= len(p)
+	return sw.ResponseWriter.Write(p)
+}
+
+func TestStalledPartIsRetried(t *testing.T) {
+	content := randomContent(256*1024, 64)
+	srv := stallingServer(t, content)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	d := mustNewDownloader(t, Options{Output: out, Parts: 2, StallTimeout: 200 * time.Millisecond, ProgressMode: ProgressOff})
+
+	start := time.Now()
+	if _, err := d.Download(context.Background(), srv.URL); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("bagian yang macet baru diulang setelah %v", elapsed)
+	}
+	assertFileContent(t, out, content)
+
+	// Dengan satu percobaan, kegagalannya dilaporkan sebagai koneksi macet.
+	srv = stallingServer(t, content)
+	out = filepath.Join(t.TempDir(), "file.bin")
+	d = mustNewDownloader(t, Options{Output: out, Parts: 1, MaxAttempts: 1, StallTimeout: 100 * time.Millisecond, ProgressMode: ProgressOff})
+	if _, err := d.Download(context.Background(), srv.URL); !errors.Is(err, errStalled) {
+		t.Errorf("error = %v, ingin errStalled", err)
+	}
+}
+
+func TestResponseHeaderTimeout(t *testing.T) {
+	content := randomContent(64*1024, 65)
+	fs := &testFileServer{content: content, etag: `"v1"`}
+	var slowOnce sync.Once
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			slowOnce.Do(func() {
+				select {
+				case <-time.After(2 * time.Second):
+				case <-r.Context().Done():
+				}
+			})
+		}
+		fs.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "file.bin")
+	d := mustNewDownloader(t, Options{Output: out, Parts: 1, ResponseHeaderTimeout: 100 * time.Millisecond, ProgressMode: ProgressOff})
+	start := time.Now()
+	if _, err := d.Download(context.Background(), srv.URL); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("request yang lambat tidak diputus (%v)", elapsed)
+	}
+	assertFileContent(t, out, content)
+}
@@ -0,0 +1,169 @@
+// mini-projects/downloader-app/manifest.go
+package parallel_downloader_app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// downloadManifest adalah catatan progres download yang disimpan di samping file output
+// (<output>.manifest.json). Jika download terputus, manifest ini dipakai untuk
+// melanjutkan setiap bagian dari byte terakhir yang sudah diunduh.
+type downloadManifest struct {
+	URL          string      `json:"url"`
+	Output       string      `json:"output"`
+	Mode         string      `json:"mode"`                // writeModeDirect atau writeModePartFiles
+	TempFile     string      `json:"temp_file,omitempty"` // File sementara pada mode direct (<output>.download)
+	Size         int64       `json:"size"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Parts        []partState `json:"parts"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// partState menyimpan rentang byte satu bagian (segmen) dan jumlah byte yang sudah diunduh.
+// End bisa dipendekkan selama download jika sisa segmen diambil alih worker lain; potongan
+// yang diambil alih menjadi segmen baru di akhir daftar Parts (lihat segments.go).
+type partState struct {
+	Index   int    `json:"index"`
+	Start   int64  `json:"start"`
+	End     int64  `json:"end"` // Inklusif
+	Written int64  `json:"written"`
+	File    string `json:"file,omitempty"` // Hanya dipakai pada mode file bagian (.partN)
+}
+
+// length mengembalikan ukuran total bagian ini dalam byte.
+func (p partState) length() int64 {
+	return p.End - p.Start + 1
+}
+
+// remaining mengembalikan jumlah byte yang belum diunduh.
+func (p partState) remaining() int64 {
+	return p.length() - p.Written
+}
+
+// manifestPath mengembalikan lokasi file manifest untuk sebuah file output.
+func manifestPath(outputFileName string) string {
+	return outputFileName + ".manifest.json"
+}
+
+// loadManifest membaca manifest dari disk. Mengembalikan (nil, nil) jika file tidak ada.
+func loadManifest(path string) (*downloadManifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca manifest %s: %v", path, err)
+	}
+	var m downloadManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("manifest %s rusak: %v", path, err)
+	}
+	if m.Mode == "" {
+		m.Mode = writeModePartFiles // Manifest lama selalu memakai file bagian
+	}
+	return &m, nil
+}
+
+// save menulis manifest secara atomik (tulis ke file sementara lalu rename),
+// sehingga manifest tidak pernah setengah tertulis jika program terhenti.
+func (m *downloadManifest) save(path string) error {
+	m.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("gagal mengkodekan manifest: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return &diskError{err: fmt.Errorf("gagal menulis manifest: %v", err)}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return &diskError{err: fmt.Errorf("gagal menyimpan manifest: %v", err)}
+	}
+	return nil
+}
+
+// matches memeriksa apakah manifest lama masih berlaku untuk file di server saat ini.
+func (m *downloadManifest) matches(url string, info remoteInfo) bool {
+	if m.URL != url || m.Size != info.Size {
+		return false
+	}
+	if m.ETag != "" || info.ETag != "" {
+		return m.ETag == info.ETag
+	}
+	return m.LastModified == info.LastModified
+}
+
+// manifestTracker membungkus manifest yang diperbarui bersamaan oleh banyak Goroutine.
+type manifestTracker struct {
+	mu      sync.Mutex
+	flushMu sync.Mutex // Menjaga urutan flush, agar salinan lama tidak menimpa yang lebih baru
+	path    string
+	m       *downloadManifest
+	dirty   bool
+	// syncData (opsional) dipanggil sebelum manifest disimpan, agar manifest tidak
+	// pernah mencatat byte yang belum benar-benar sampai ke disk.
+	syncData func() error
+
+	// Status penjadwalan segmen (lihat segments.go); tidak disimpan ke manifest.
+	active   map[int]bool  // Segmen yang sedang dikerjakan worker
+	reserved map[int]int64 // Byte yang sedang ditulis (sudah dipesan, belum di-commit) per segmen
+
+	// logf (opsional) dipakai untuk mencetak pesan selama download berjalan.
+	logf func(format string, args ...any)
+}
+
+// flush menyimpan manifest ke disk jika ada perubahan sejak penyimpanan terakhir.
+// Manifest disalin di bawah t.mu, lalu fsync dan penulisan dilakukan di luar kunci,
+// agar worker tidak ikut menunggu fsync file output yang bisa berukuran beberapa GB.
+// Salinan diambil sebelum fsync, sehingga byte yang dicatatnya sudah ikut disinkronkan.
+func (t *manifestTracker) flush() error {
+	t.flushMu.Lock()
+	defer t.flushMu.Unlock()
+
+	t.mu.Lock()
+	if !t.dirty {
+		t.mu.Unlock()
+		return nil
+	}
+	snapshot := *t.m
+	snapshot.Parts = append([]partState(nil), t.m.Parts...)
+	t.dirty = false
+	t.mu.Unlock()
+
+	err := t.saveSnapshot(&snapshot)
+	if err != nil {
+		t.mu.Lock()
+		t.dirty = true // Dicoba lagi pada flush berikutnya
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// saveSnapshot menyinkronkan data output lalu menyimpan salinan manifest.
+func (t *manifestTracker) saveSnapshot(m *downloadManifest) error {
+	if t.syncData != nil {
+		if err := t.syncData(); err != nil {
+			return &diskError{err: fmt.Errorf("gagal menyinkronkan data ke disk: %v", err)}
+		}
+	}
+	return m.save(t.path)
+}
+
+// autoFlush menyimpan manifest secara berkala sampai channel stop ditutup.
+func (t *manifestTracker) autoFlush(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.flush()
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,330 @@
+// mini-projects/downloader-app/mirror.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Aturan perpindahan antar mirror. Variabel (bukan konstanta) agar bisa diperkecil di test.
+var (
+	// mirrorSlowAfter adalah lama minimal sebuah koneksi berjalan sebelum kecepatannya dinilai.
+	mirrorSlowAfter = 3 * time.Second
+	// mirrorSlowFactor: koneksi dianggap lambat jika mirror lain rata-rata sekian kali lebih cepat.
+	mirrorSlowFactor = 4.0
+	// mirrorCheckInterval adalah jeda antar penilaian kecepatan satu koneksi.
+	mirrorCheckInterval = 250 * time.Millisecond
+)
+
+// mirrorMaxFailures adalah jumlah kegagalan berturut-turut sebelum mirror tidak dipakai lagi.
+const mirrorMaxFailures = 3
+
+// errMirrorSlow dikembalikan jika koneksi ke sebuah mirror jauh lebih lambat daripada mirror
+// lain. Bagian tersebut dilanjutkan dari mirror lain tanpa menghitungnya sebagai percobaan gagal.
+var errMirrorSlow = errors.New("mirror terlalu lambat")
+
+// MirrorStats adalah statistik satu sumber (URL) sebuah download.
+type MirrorStats struct {
+	URL      string
+	Bytes    int64   // Byte yang diterima dari mirror ini
+	Requests int     // Jumlah request bagian
+	Failures int     // Request yang gagal (error jaringan atau status HTTP)
+	Switches int     // Bagian yang dipindahkan ke mirror lain karena mirror ini lambat
+	Speed    float64 // Rata-rata kecepatan per koneksi (bytes/detik)
+	Disabled string  // Alasan mirror berhenti dipakai; kosong jika tetap aktif
+}
+
+// mirror adalah satu URL untuk file yang sama beserta hasil probe dan statistiknya.
+// Semua field selain url, target, headers, dan info dilindungi mirrorSet.mu.
+type mirror struct {
+	url      string
+	target   string      // URL akhir setelah redirect, tujuan semua request bagian
+	headers  http.Header // Header tambahan untuk 'target' (tanpa kredensial jika host-nya berbeda)
+	info     remoteInfo
+	active   int // Koneksi yang sedang berjalan
+	bytes    int64
+	busy     time.Duration // Total lama koneksi berjalan
+	requests int
+	failures int
+	streak   int     // Kegagalan berturut-turut
+	switches int     // Koneksi yang diputus karena lambat
+	connRate float64 // Perkiraan kecepatan per koneksi (bytes/detik, rata-rata bergerak)
+	disabled string
+}
+
+// mirrorSet membagi bagian-bagian sebuah download ke beberapa mirror. Setiap request bagian
+// memilih mirror dengan perkiraan kecepatan per koneksi terbaik (kecepatan dibagi jumlah
+// koneksi aktifnya), sehingga mirror yang cepat mendapat lebih banyak koneksi.
+// Dengan satu mirror, perilakunya sama seperti download dari satu URL.
+type mirrorSet struct {
+	mu      sync.Mutex
+	mirrors []*mirror
+}
+
+// mirrorConn adalah satu request bagian ke sebuah mirror.
+type mirrorConn struct {
+	set   *mirrorSet
+	m     *mirror
+	start time.Time
+	n     int64 // Byte yang diterima koneksi ini
+}
+
+// probeMirrors melakukan HEAD ke cfg.URL dan semua cfg.Mirrors secara bersamaan. Mirror pertama
+// yang berhasil menjadi acuan; mirror lain dipakai hanya jika ukuran dan ETag-nya sama serta
+// mendukung Range request. Error dikembalikan hanya jika tidak ada satu pun URL yang bisa diakses.
+func probeMirrors(ctx context.Context, cfg downloadConfig) (*mirrorSet, remoteInfo, error) {
+	urls := append([]string{cfg.URL}, cfg.Mirrors...)
+	infos := make([]remoteInfo, len(urls))
+	errs := make([]error, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mcfg := cfg
+			mcfg.URL = u
+			infos[i], errs[i] = probeRemote(ctx, mcfg)
+		}()
+	}
+	wg.Wait()
+
+	set := &mirrorSet{}
+	var ref remoteInfo
+	for i, u := range urls {
+		info, err := infos[i], errs[i]
+		if err != nil {
+			if len(urls) > 1 {
+				cfg.logf("Mirror %s dilewati: %v\n", u, err)
+			}
+			continue
+		}
+		if len(set.mirrors) == 0 {
+			ref = info
+		} else if reason := mirrorMismatch(ref, info); reason != "" {
+			cfg.logf("Mirror %s dilewati: %s.\n", u, reason)
+			continue
+		}
+		m := &mirror{url: u, target: info.FinalURL, headers: pinnedHeaders(cfg.Headers, u, info.FinalURL), info: info}
+		if m.target == "" {
+			m.target = u
+		} else if m.target != u {
+			cfg.logf("%s dialihkan ke %s.\n", u, m.target)
+		}
+		set.mirrors = append(set.mirrors, m)
+	}
+	if len(set.mirrors) == 0 {
+		if len(urls) == 1 {
+			return nil, remoteInfo{}, errs[0]
+		}
+		return nil, remoteInfo{}, fmt.Errorf("semua mirror gagal diakses: %w", errors.Join(errs...))
+	}
+	if len(urls) > 1 {
+		cfg.logf("Mengunduh dari %d dari %d mirror.\n", len(set.mirrors), len(urls))
+	}
+	return set, ref, nil
+}
+
+// mirrorMismatch menjelaskan kenapa mirror 'info' tidak bisa dipakai bersama mirror acuan,
+// atau string kosong jika bisa. ETag hanya dibandingkan jika keduanya mengirimnya, karena
+// server yang berbeda belum tentu membuat ETag.
+func mirrorMismatch(ref, info remoteInfo) string {
+	switch {
+	case info.Size != ref.Size:
+		return fmt.Sprintf("ukuran file %d bytes, berbeda dengan %d bytes", info.Size, ref.Size)
+	case info.ETag != "" && ref.ETag != "" && info.ETag != ref.ETag:
+		return fmt.Sprintf("ETag %s berbeda dengan %s", info.ETag, ref.ETag)
+	case info.rangeUnsupportedReason() != "":
+		return info.rangeUnsupportedReason()
+	}
+	return ""
+}
+
+// primary mengembalikan mirror acuan (yang pertama berhasil di-probe).
+func (s *mirrorSet) primary() *mirror {
+	return s.mirrors[0]
+}
+
+// len mengembalikan jumlah mirror yang ikut dalam download ini.
+func (s *mirrorSet) len() int {
+	return len(s.mirrors)
+}
+
+// enabledLocked menghitung mirror yang masih aktif selain 'except'.
+func (s *mirrorSet) enabledLocked(except *mirror) int {
+	n := 0
+	for _, m := range s.mirrors {
+		if m.disabled == "" && m != except {
+			n++
+		}
+	}
+	return n
+}
+
+// pick memilih mirror untuk request berikutnya dan mencatatnya sebagai koneksi aktif.
+// Mirror 'avoid' (misalnya yang baru saja gagal atau lambat) hanya dipilih jika tidak ada
+// mirror aktif lain. Mirror yang kecepatannya belum diketahui dianggap secepat mirror terbaik,
+// agar semua mirror sempat dicoba.
+func (s *mirrorSet) pick(avoid *mirror) *mirrorConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	known := 0.0
+	for _, m := range s.mirrors {
+		if m.disabled == "" {
+			known = max(known, m.connRate)
+		}
+	}
+	skipAvoid := avoid != nil && s.enabledLocked(avoid) > 0
+	var best *mirror
+	bestScore := -1.0
+	for _, m := range s.mirrors {
+		if m.disabled != "" || (skipAvoid && m == avoid) {
+			continue
+		}
+		rate := m.connRate
+		if rate == 0 {
+			rate = max(known, 1)
+		}
+		if score := rate / float64(m.active+1); score > bestScore {
+			best, bestScore = m, score
+		}
+	}
+	best.active++
+	best.requests++
+	return &mirrorConn{set: s, m: best, start: time.Now()}
+}
+
+// done mencatat akhir sebuah request. Mirror yang gagal mirrorMaxFailures kali berturut-turut
+// tidak dipakai lagi, selama masih ada mirror lain. Request yang berhenti karena ctx
+// dibatalkan tidak dihitung sebagai kegagalan mirror.
+func (s *mirrorSet) done(ctx context.Context, c *mirrorConn, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := c.m
+	elapsed := time.Since(c.start)
+	m.active--
+	m.busy += elapsed
+	switch {
+	case err == nil:
+		m.streak = 0
+		if c.n > 0 && elapsed > 0 {
+			m.updateRate(float64(c.n) / elapsed.Seconds())
+		}
+	case errors.Is(err, errMirrorSlow), ctx.Err() != nil:
+	default:
+		m.failures++
+		if m.streak++; m.streak >= mirrorMaxFailures && s.enabledLocked(m) > 0 {
+			m.disabled = fmt.Sprintf("gagal %d kali berturut-turut: %v", m.streak, err)
+		}
+	}
+}
+
+// disable berhenti memakai mirror m karena 'err' (misalnya 404 atau file yang berbeda).
+// Mengembalikan false jika m adalah mirror aktif terakhir; error-nya lalu diperlakukan seperti
+// download dari satu URL.
+func (s *mirrorSet) disable(m *mirror, err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.enabledLocked(m) == 0 {
+		return false
+	}
+	if m.disabled == "" {
+		m.disabled = err.Error()
+	}
+	return true
+}
+
+// hasAlternative memeriksa apakah ada mirror aktif selain m.
+func (s *mirrorSet) hasAlternative(m *mirror) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enabledLocked(m) > 0
+}
+
+// updateRate memperbarui perkiraan kecepatan per koneksi dengan rata-rata bergerak.
+func (m *mirror) updateRate(rate float64) {
+	if m.connRate == 0 {
+		m.connRate = rate
+		return
+	}
+	m.connRate = 0.7*m.connRate + 0.3*rate
+}
+
+// tooSlow mencatat kecepatan koneksi c dan memeriksa apakah mirror aktif lain jauh lebih cepat.
+func (s *mirrorSet) tooSlow(c *mirrorConn, elapsed time.Duration) bool {
+	if elapsed < mirrorSlowAfter {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rate := float64(c.n) / elapsed.Seconds()
+	c.m.updateRate(rate)
+	for _, m := range s.mirrors {
+		if m != c.m && m.disabled == "" && m.connRate > rate*mirrorSlowFactor {
+			c.m.switches++
+			return true
+		}
+	}
+	return false
+}
+
+// stats mengembalikan statistik semua mirror, urut seperti daftar URL.
+func (s *mirrorSet) stats() []MirrorStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]MirrorStats, 0, len(s.mirrors))
+	for _, m := range s.mirrors {
+		st := MirrorStats{
+			URL: m.url, Bytes: m.bytes, Requests: m.requests, Failures: m.failures,
+			Switches: m.switches, Disabled: m.disabled,
+		}
+		if m.busy > 0 {
+			st.Speed = float64(m.bytes) / m.busy.Seconds()
+		}
+		stats = append(stats, st)
+	}
+	return stats
+}
+
+// reader membungkus body respons: menghitung byte per mirror dan memutus koneksi dengan
+// errMirrorSlow jika mirror lain jauh lebih cepat.
+func (c *mirrorConn) reader(r io.Reader) io.Reader {
+	return &mirrorReader{r: r, c: c}
+}
+
+type mirrorReader struct {
+	r         io.Reader
+	c         *mirrorConn
+	lastCheck time.Time
+}
+
+func (mr *mirrorReader) Read(p []byte) (int, error) {
+	n, err := mr.r.Read(p)
+	c := mr.c
+	if n > 0 {
+		c.set.mu.Lock()
+		c.n += int64(n)
+		c.m.bytes += int64(n)
+		c.set.mu.Unlock()
+	}
+	if now := time.Now(); err == nil && now.Sub(mr.lastCheck) >= mirrorCheckInterval {
+		mr.lastCheck = now
+		if c.set.tooSlow(c, now.Sub(c.start)) {
+			return n, fmt.Errorf("%w (%s)", errMirrorSlow, mirrorHost(c.m.url))
+		}
+	}
+	return n, err
+}
+
+// mirrorHost mengembalikan host sebuah URL mirror untuk pesan log.
+func mirrorHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
@@ -0,0 +1,145 @@
+// mini-projects/downloader-app/mirror_test.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setMirrorSlowAfter(t *testing.T, d time.Duration) {
+	t.Helper()
+	old := mirrorSlowAfter
+	mirrorSlowAfter = d
+	t.Cleanup(func() { mirrorSlowAfter = old })
+}
+
+// mirrorStat mencari statistik mirror dengan URL 'u'.
+func mirrorStat(t *testing.T, res Result, u string) MirrorStats {
+	t.Helper()
+	for _, st := range res.Mirrors {
+		if st.URL == u {
+			return st
+		}
+	}
+	t.Fatalf("mirror %s tidak ada di statistik %+v", u, res.Mirrors)
+	return MirrorStats{}
+}
+
+func TestMirrorsShareParts(t *testing.T) {
+	content := randomContent(1024*1024, 70)
+	fsA, srvA := newTestFileServer(t, content, `"v1"`)
+	fsB, srvB := newTestFileServer(t, content, `"v1"`)
+	fsSize, srvSize := newTestFileServer(t, content[:len(content)-1], `"v1"`) // Ukuran berbeda
+	fsTag, srvTag := newTestFileServer(t, content, `"lain"`)                  // ETag berbeda
+
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{
+		URL: srvA.URL, Mirrors: []string{srvB.URL, srvSize.URL, srvTag.URL, "http://127.0.0.1:1/mati"},
+		Output: out, NumParts: 4, Checksum: sha256Hex(content),
+	}
+	res, err := runDownload(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+
+	if len(fsA.requests) == 0 || len(fsB.requests) == 0 {
+		t.Errorf("bagian tidak dibagi ke kedua mirror: %d GET ke A, %d GET ke B", len(fsA.requests), len(fsB.requests))
+	}
+	if len(fsSize.requests) != 0 || len(fsTag.requests) != 0 {
+		t.Error("mirror dengan ukuran atau ETag berbeda tetap dipakai")
+	}
+	if len(res.Mirrors) != 2 {
+		t.Fatalf("statistik berisi %d mirror, ingin 2: %+v", len(res.Mirrors), res.Mirrors)
+	}
+	var total int64
+	for _, st := range res.Mirrors {
+		total += st.Bytes
+		if st.Requests == 0 || st.Speed <= 0 || st.Disabled != "" {
+			t.Errorf("statistik mirror tidak lengkap: %+v", st)
+		}
+	}
+	if total != int64(len(content)) {
+		t.Errorf("total byte dari semua mirror = %d, ingin %d", total, len(content))
+	}
+}
+
+func TestFailingMirrorIsDisabled(t *testing.T) {
+	content := randomContent(512*1024, 71)
+	for _, status := range []int{http.StatusNotFound, http.StatusInternalServerError} {
+		_, good := newTestFileServer(t, content, `"v1"`)
+		fs := &testFileServer{content: content, etag: `"v1"`}
+		bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+			fs.ServeHTTP(w, r) // HEAD berhasil, tetapi setiap GET gagal
+		}))
+		defer bad.Close()
+
+		out := filepath.Join(t.TempDir(), "file.bin")
+		cfg := downloadConfig{
+			URL: bad.URL, Mirrors: []string{good.URL}, Output: out, NumParts: 4,
+			Retry: retryPolicy{BaseDelay: time.Millisecond},
+		}
+		res, err := runDownload(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("status %d: download gagal: %v", status, err)
+		}
+		assertFileContent(t, out, content)
+		st := mirrorStat(t, res, bad.URL)
+		if st.Bytes != 0 || st.Failures == 0 {
+			t.Errorf("status %d: statistik mirror yang gagal salah: %+v", status, st)
+		}
+		// 404 langsung menghentikan mirror; 5xx baru setelah beberapa kegagalan berturut-turut.
+		if status == http.StatusNotFound && st.Disabled == "" {
+			t.Errorf("mirror yang membalas 404 tidak dihentikan: %+v", st)
+		}
+		if st := mirrorStat(t, res, good.URL); st.Bytes != int64(len(content)) {
+			t.Errorf("status %d: mirror yang sehat hanya mengirim %d bytes", status, st.Bytes)
+		}
+	}
+}
+
+func TestSlowMirrorPartsMoveToFastMirror(t *testing.T) {
+	setMirrorSlowAfter(t, 200*time.Millisecond)
+	content := randomContent(2*1024*1024, 72)
+	slowFS, slow := newTestFileServer(t, content, `"v1"`)
+	slowFS.throttle = func(*http.Request) int64 { return 32 * 1024 }
+	_, fast := newTestFileServer(t, content, `"v1"`)
+
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: slow.URL, Mirrors: []string{fast.URL}, Output: out, NumParts: 4, Checksum: sha256Hex(content)}
+	start := time.Now()
+	res, err := runDownload(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertFileContent(t, out, content)
+	// Tanpa perpindahan, segmen di mirror lambat (minimal 256 KB) butuh sekitar 8 detik.
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("download butuh %v; bagian di mirror lambat tidak dipindahkan", elapsed)
+	}
+	slowSt, fastSt := mirrorStat(t, res, slow.URL), mirrorStat(t, res, fast.URL)
+	if slowSt.Switches == 0 {
+		t.Errorf("tidak ada bagian yang dipindahkan dari mirror lambat: %+v", slowSt)
+	}
+	if fastSt.Bytes <= slowSt.Bytes {
+		t.Errorf("mirror cepat mengirim %d bytes, mirror lambat %d bytes", fastSt.Bytes, slowSt.Bytes)
+	}
+}
+
+func TestDownloadFromValidatesMirrorURLs(t *testing.T) {
+	d := mustNewDownloader(t, Options{ProgressMode: ProgressOff})
+	if _, err := d.DownloadFrom(context.Background()); err == nil {
+		t.Error("DownloadFrom tanpa URL seharusnya gagal")
+	}
+	if _, err := d.DownloadFrom(context.Background(), "http://example.com/a.bin", "ftp://example.com/a.bin"); err == nil {
+		t.Error("mirror selain http/https seharusnya ditolak")
+	}
+}
@@ -0,0 +1,116 @@
+// mini-projects/downloader-app/output.go
+package parallel_downloader_app
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Mode penulisan hasil download.
+const (
+	// writeModeDirect: file output dialokasikan di awal, lalu setiap Goroutine menulis
+	// langsung ke offset bagiannya dengan WriteAt. Tidak ada tahap penggabungan.
+	writeModeDirect = "direct"
+	// writeModePartFiles: setiap bagian ditulis ke <output>.partN lalu digabungkan
+	// di akhir (cara lama; butuh I/O dan ruang disk dua kali lipat).
+	writeModePartFiles = "parts"
+)
+
+// tempOutputPath mengembalikan nama file sementara untuk mode direct. File baru
+// di-rename menjadi nama output setelah semua bagian selesai.
+func tempOutputPath(outputFileName string) string {
+	return outputFileName + ".download"
+}
+
+// partOutput menentukan ke mana data setiap bagian ditulis.
+type partOutput interface {
+	// writerFor membuka writer untuk melanjutkan bagian 'part' dari byte ke-part.Written.
+	// Nilai string adalah nama file tujuan untuk ditampilkan ke pengguna.
+	writerFor(part partState) (io.WriteCloser, string, error)
+	// sync memastikan data yang sudah ditulis benar-benar tersimpan di disk.
+	sync() error
+	// ReadAt membaca kembali data yang sudah ditulis berdasarkan offset di file akhir
+	// (dipakai untuk menghitung checksum).
+	ReadAt(p []byte, off int64) (int, error)
+	// Close menutup file yang dipegang output (jika ada).
+	Close() error
+}
+
+// openPartOutput membuka tujuan penulisan sesuai mode yang tercatat di manifest.
+// Pada mode file bagian, 'tracker' dipakai untuk mencari file segmen saat membaca ulang data.
+func openPartOutput(m *downloadManifest, tracker *manifestTracker) (partOutput, error) {
+	if m.Mode == writeModeDirect {
+		return openDirectOutput(m.TempFile, m.Size)
+	}
+	return partFilesOutput{segmentAt: tracker.segmentAt}, nil
+}
+
+// directOutput menulis semua bagian ke satu file yang sudah dialokasikan.
+// *os.File aman dipakai WriteAt secara bersamaan dari banyak Goroutine.
+type directOutput struct {
+	file *os.File
+}
+
+// openDirectOutput membuka (atau membuat) file sementara dan mengalokasikan ukurannya.
+// File lama yang lebih besar dipotong dulu, karena fallocate tidak pernah memperkecil file
+// dan byte sisa di ujungnya akan ikut menjadi bagian file output.
+func openDirectOutput(path string, size int64) (*directOutput, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, &diskError{err: fmt.Errorf("gagal membuka file %s: %v", path, err)}
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, &diskError{err: fmt.Errorf("gagal memotong %s menjadi %d bytes: %v", path, size, err)}
+	}
+	if err := preallocate(f, size); err != nil {
+		f.Close()
+		return nil, &diskError{err: fmt.Errorf("gagal mengalokasikan %d bytes untuk %s: %v", size, path, err)}
+	}
+	return &directOutput{file: f}, nil
+}
+
+func (o *directOutput) writerFor(part partState) (io.WriteCloser, string, error) {
+	return nopWriteCloser{io.NewOffsetWriter(o.file, part.Start+part.Written)}, o.file.Name(), nil
+}
+
+func (o *directOutput) sync() error                             { return o.file.Sync() }
+func (o *directOutput) ReadAt(p []byte, off int64) (int, error) { return o.file.ReadAt(p, off) }
+func (o *directOutput) Close() error                            { return o.file.Close() }
+
+// partFilesOutput menulis setiap bagian ke file .partN masing-masing.
+type partFilesOutput struct {
+	segmentAt func(off int64) (partState, bool) // Mencari segmen (dan filenya) untuk sebuah offset
+}
+
+func (partFilesOutput) writerFor(part partState) (io.WriteCloser, string, error) {
+	// Data baru ditambahkan di akhir file, sehingga byte yang sudah diunduh sebelumnya tetap dipakai.
+	f, err := os.OpenFile(part.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, part.File, err
+	}
+	return f, part.File, nil
+}
+
+func (partFilesOutput) sync() error  { return nil }
+func (partFilesOutput) Close() error { return nil }
+
+// ReadAt membaca dari file bagian yang memuat offset 'off'. Pembacaan tidak pernah
+// melewati batas satu bagian; pemanggil cukup mengulang untuk bagian berikutnya.
+func (o partFilesOutput) ReadAt(p []byte, off int64) (int, error) {
+	part, ok := o.segmentAt(off)
+	if !ok {
+		return 0, io.EOF
+	}
+	f, err := os.Open(part.File)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.ReadAt(p[:min(int64(len(p)), part.End-off+1)], off-part.Start)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
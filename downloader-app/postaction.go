@@ -0,0 +1,210 @@
+// mini-projects/downloader-app/postaction.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Jenis aksi yang dijalankan setelah download selesai.
+const (
+	actionVerify  = "verify"  // Periksa checksum file
+	actionExtract = "extract" // Ekstrak arsip zip, tar, atau tar.gz
+	actionMove    = "move"    // Pindahkan file ke direktori lain
+	actionExec    = "exec"    // Jalankan perintah dengan path file sebagai argumen
+)
+
+// Status hasil sebuah aksi. Aksi yang belum dijalankan berstatus kosong.
+const (
+	actionOK     = "ok"
+	actionFailed = "failed"
+)
+
+// execPlaceholder di argumen aksi exec diganti dengan path file. Jika tidak ada, path file
+// ditambahkan sebagai argumen terakhir.
+const execPlaceholder = "{}"
+
+// maxActionOutput membatasi panjang keluaran perintah yang dicatat di hasil aksi exec.
+const maxActionOutput = 200
+
+// postAction adalah satu aksi setelah download sebuah job, beserta hasil terakhirnya.
+type postAction struct {
+	Kind string `json:"kind"`
+	// Args untuk setiap jenis: verify <checksum>, extract [direktori], move <direktori>,
+	// exec <program> [argumen...].
+	Args   []string `json:"args,omitempty"`
+	Status string   `json:"status,omitempty"` // "", actionOK, atau actionFailed
+	Result string   `json:"result,omitempty"` // Ringkasan hasil atau pesan error
+}
+
+// parsePostAction membaca aksi dari argumen perintah, misalnya ["extract", "data/"].
+func parsePostAction(fields []string) (postAction, error) {
+	if len(fields) == 0 {
+		return postAction{}, errors.New("jenis aksi kosong")
+	}
+	a := postAction{Kind: strings.ToLower(fields[0]), Args: fields[1:]}
+	switch {
+	case a.Kind == actionVerify && len(a.Args) == 1:
+		// URL dan file checksum baru dibaca saat aksi dijalankan; nilai lain diperiksa sekarang.
+		if _, err := os.Stat(a.Args[0]); err != nil && !strings.Contains(a.Args[0], "://") {
+			if _, err := resolveExpectedDigest(context.Background(), downloadConfig{Checksum: a.Args[0]}); err != nil {
+				return postAction{}, err
+			}
+		}
+	case a.Kind == actionExtract && len(a.Args) <= 1:
+	case a.Kind == actionMove && len(a.Args) == 1:
+	case a.Kind == actionExec && len(a.Args) >= 1:
+	default:
+		return postAction{}, fmt.Errorf("aksi tidak valid: '%s' (pilih verify <checksum>, extract [dir], move <dir>, atau exec <program> [arg...])", strings.Join(fields, " "))
+	}
+	return a, nil
+}
+
+func (a postAction) String() string {
+	return strings.TrimSpace(a.Kind + " " + strings.Join(a.Args, " "))
+}
+
+// runPostAction menjalankan satu aksi terhadap 'file' (hasil download dari 'fileURL').
+// Mengembalikan path file setelah aksi (berubah pada move) dan ringkasan hasilnya.
+func runPostAction(ctx context.Context, a postAction, file, fileURL string) (string, string, error) {
+	if _, err := os.Stat(file); err != nil {
+		return file, "", fmt.Errorf("file hasil download tidak ditemukan: %v", err)
+	}
+	switch a.Kind {
+	case actionVerify:
+		result, err := verifyFile(ctx, file, fileURL, a.Args[0])
+		return file, result, err
+	case actionExtract:
+		dest := defaultExtractDir(file)
+		if len(a.Args) > 0 {
+			dest = a.Args[0]
+		}
+		files, skipped, err := extractArchive(file, dest)
+		if err != nil {
+			return file, "", err
+		}
+		result := fmt.Sprintf("%d file diekstrak ke %s", files, dest)
+		if skipped > 0 {
+			result += fmt.Sprintf(" (%d symlink/file khusus dilewati)", skipped)
+		}
+		return file, result, nil
+	case actionMove:
+		target, err := moveFile(file, a.Args[0], fileURL)
+		if err != nil {
+			return file, "", err
+		}
+		return target, "dipindahkan ke " + target, nil
+	case actionExec:
+		result, err := execAction(ctx, a.Args, file)
+		return file, result, err
+	}
+	return file, "", fmt.Errorf("jenis aksi tidak dikenal: '%s'", a.Kind)
+}
+
+// verifyFile menghitung hash 'file' dan membandingkannya dengan 'checksum' (format yang sama
+// seperti Options.Checksum).
+func verifyFile(ctx context.Context, file, fileURL, checksum string) (string, error) {
+	expected, err := resolveExpectedDigest(ctx, downloadConfig{URL: fileURL, Output: file, Checksum: checksum})
+	if err != nil {
+		return "", err
+	}
+	if expected == nil {
+		return "", errors.New("checksum kosong")
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", &diskError{err: fmt.Errorf("gagal membuka %s: %v", file, err)}
+	}
+	defer f.Close()
+	h := newHashFuncs[expected.Algo]()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", &diskError{err: fmt.Errorf("gagal membaca %s: %v", file, err)}
+	}
+	if err := verifyDigests(map[string][]byte{expected.Algo: h.Sum(nil)}, []expectedDigest{*expected}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s cocok", expected.Algo), nil
+}
+
+// moveFile memindahkan 'file' ke direktori 'dir'. Jika nama yang sama sudah ada di sana,
+// dipakai nama lain seperti pada ExistRename. Antar-filesystem, file disalin lalu dihapus.
+func moveFile(file, dir, fileURL string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", &diskError{err: fmt.Errorf("gagal membuat direktori %s: %v", dir, err)}
+	}
+	target, _, err := resolveCollision(filepath.Join(dir, filepath.Base(file)), fileURL, ExistRename)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(file, target); err == nil {
+		return target, nil
+	}
+	if err := copyFile(file, target); err != nil {
+		os.Remove(target)
+		return "", &diskError{err: fmt.Errorf("gagal memindahkan %s ke %s: %v", file, target, err)}
+	}
+	if err := os.Remove(file); err != nil {
+		return "", &diskError{err: fmt.Errorf("gagal menghapus %s setelah disalin: %v", file, err)}
+	}
+	return target, nil
+}
+
+// copyFile menyalin isi dan izin file 'src' ke 'dst'.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// execAction menjalankan program dengan path file sebagai argumen (menggantikan "{}", atau
+// sebagai argumen terakhir). Program dijalankan langsung tanpa shell. Keluaran terakhir
+// perintah dicatat di hasil aksi.
+func execAction(ctx context.Context, args []string, file string) (string, error) {
+	argv := make([]string, 0, len(args)+1)
+	replaced := false
+	for _, arg := range args {
+		if strings.Contains(arg, execPlaceholder) {
+			arg, replaced = strings.ReplaceAll(arg, execPlaceholder, file), true
+		}
+		argv = append(argv, arg)
+	}
+	if !replaced {
+		argv = append(argv, file)
+	}
+	out, err := exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput()
+	summary := strings.TrimSpace(string(out))
+	if len(summary) > maxActionOutput {
+		summary = "..." + summary[len(summary)-maxActionOutput:]
+	}
+	if err != nil {
+		if summary != "" {
+			return "", fmt.Errorf("perintah %s gagal: %v: %s", argv[0], err, summary)
+		}
+		return "", fmt.Errorf("perintah %s gagal: %v", argv[0], err)
+	}
+	if summary == "" {
+		summary = "perintah selesai"
+	}
+	return summary, nil
+}
@@ -0,0 +1,246 @@
+// mini-projects/downloader-app/postaction_test.go
+package parallel_downloader_app
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// archiveEntry adalah satu entri arsip buatan test. Link diisi untuk symlink.
+type archiveEntry struct {
+	Name, Body, Link string
+}
+
+func buildTar(t *testing.T, entries []archiveEntry, gzipped bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if gzipped {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.Name, Mode: 0644, Size: int64(len(e.Body)), Typeflag: tar.TypeReg}
+		switch {
+		case e.Link != "":
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.Link, 0
+		case strings.HasSuffix(e.Name, "/"):
+			hdr.Typeflag, hdr.Mode = tar.TypeDir, 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.Body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.Body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractArchiveFormats(t *testing.T) {
+	entries := []archiveEntry{
+		{Name: "docs/"},
+		{Name: "docs/readme.txt", Body: "halo"},
+		{Name: "bin/tool", Body: "isi alat"},
+	}
+	dir := t.TempDir()
+	archives := map[string][]byte{
+		"data.zip":       buildZip(t, entries),
+		"data.tar":       buildTar(t, entries, false),
+		"data.tar.gz":    buildTar(t, entries, true),
+		"tanpa-ekstensi": buildTar(t, append(entries, archiveEntry{Name: "link", Link: "/etc/passwd"}), true),
+	}
+	for name, data := range archives {
+		archive := filepath.Join(dir, name)
+		if err := os.WriteFile(archive, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		dest := defaultExtractDir(archive)
+		files, skipped, err := extractArchive(archive, dest)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if files != 2 {
+			t.Errorf("%s: %d file diekstrak, ingin 2", name, files)
+		}
+		assertFileContent(t, filepath.Join(dest, "docs", "readme.txt"), []byte("halo"))
+		assertFileContent(t, filepath.Join(dest, "bin", "tool"), []byte("isi alat"))
+		if name == "tanpa-ekstensi" {
+			if skipped != 1 {
+				t.Errorf("symlink seharusnya dilewati, skipped = %d", skipped)
+			}
+			if _, err := os.Lstat(filepath.Join(dest, "link")); !os.IsNotExist(err) {
+				t.Errorf("symlink dari arsip tidak boleh dibuat: %v", err)
+			}
+		}
+	}
+}
+
+func TestExtractArchiveRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(dir, "luar")
+	if err := os.Mkdir(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	archives := map[string][]byte{
+		"dotdot.tar":   buildTar(t, []archiveEntry{{Name: "ok.txt", Body: "a"}, {Name: "../luar/jahat.txt", Body: "x"}}, false),
+		"absolute.zip": buildZip(t, []archiveEntry{{Name: filepath.Join(outside, "jahat.txt"), Body: "x"}}),
+		// Symlink yang sudah ada di tujuan tidak boleh dipakai untuk keluar dari direktori.
+		"via-link.tar.gz": buildTar(t, []archiveEntry{{Name: "tautan/jahat.txt", Body: "x"}}, true),
+	}
+	for name, data := range archives {
+		archive := filepath.Join(dir, name)
+		if err := os.WriteFile(archive, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		dest := filepath.Join(dir, "hasil-"+name)
+		if err := os.Mkdir(dest, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(outside, filepath.Join(dest, "tautan")); err != nil {
+			t.Skipf("symlink tidak didukung: %v", err)
+		}
+		if _, _, err := extractArchive(archive, dest); err == nil {
+			t.Errorf("%s: ekstrak seharusnya ditolak", name)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("file tertulis di luar direktori tujuan: %v", entries)
+	}
+}
+
+func TestQueueRunsPostActions(t *testing.T) {
+	archive := buildTar(t, []archiveEntry{{Name: "isi/data.txt", Body: "data dari arsip"}}, true)
+	fs, srv := newTestFileServer(t, archive, `"v1"`)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "paket.tar.gz")
+	extracted := filepath.Join(dir, "ekstrak")
+	moved := filepath.Join(dir, "arsip")
+
+	q, err := openDownloadQueue(filepath.Join(dir, "queue.json"), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.add(srv.URL+"/paket.tar.gz", out, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := [][]string{
+		{"verify", "sha256:" + sha256Hex(archive)},
+		{"extract", extracted},
+		{"move", moved},
+	}
+	if _, err := exec.LookPath("sh"); err == nil {
+		actions = append(actions, []string{"exec", "sh", "-c", `wc -c < "$0"`, "{}"})
+	}
+	for _, fields := range actions {
+		a, err := parsePostAction(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := q.addAction(job.ID, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.start(context.Background())
+	defer q.stop()
+
+	done := waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobDone || j.State == jobFailed })
+	if done.State != jobDone {
+		t.Fatalf("job gagal: %s (aksi %+v)", done.Error, done.Actions)
+	}
+	assertFileContent(t, filepath.Join(extracted, "isi", "data.txt"), []byte("data dari arsip"))
+	if want := filepath.Join(moved, "paket.tar.gz"); done.File != want {
+		t.Errorf("job.File = %s, ingin %s", done.File, want)
+	}
+	assertFileContent(t, done.File, archive)
+	for _, a := range done.Actions {
+		if a.Status != actionOK {
+			t.Errorf("aksi %s berstatus %s: %s", a, a.Status, a.Result)
+		}
+	}
+	if last := done.Actions[len(done.Actions)-1]; last.Kind == actionExec && last.Result != strconv.Itoa(len(archive)) {
+		t.Errorf("keluaran exec = %q, ingin ukuran file %d", last.Result, len(archive))
+	}
+
+	// Aksi yang gagal menggagalkan job; resume hanya mengulang aksi itu, tanpa download ulang.
+	fs.mu.Lock()
+	gets := len(fs.requests)
+	fs.mu.Unlock()
+	if err := q.addAction(job.ID, postAction{Kind: actionVerify, Args: []string{"sha256:" + strings.Repeat("0", 64)}}); err != nil {
+		t.Fatal(err)
+	}
+	failed := waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobFailed })
+	if last := failed.Actions[len(failed.Actions)-1]; last.Status != actionFailed || !strings.Contains(failed.Error, "verify") {
+		t.Errorf("aksi terakhir %+v, error job %q", last, failed.Error)
+	}
+	if err := q.clearActions(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.addAction(job.ID, postAction{Kind: actionVerify, Args: []string{sha256Hex(archive)}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.resume(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobDone })
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if len(fs.requests) != gets {
+		t.Errorf("file diunduh ulang hanya untuk menjalankan aksi (%d GET baru)", len(fs.requests)-gets)
+	}
+}
+
+func TestParsePostActionRejectsInvalid(t *testing.T) {
+	for _, fields := range [][]string{
+		{},
+		{"unzip"},
+		{"verify"},
+		{"verify", "sha256:bukanhex"},
+		{"move"},
+		{"extract", "a", "b"},
+		{"exec"},
+	} {
+		if _, err := parsePostAction(fields); err == nil {
+			t.Errorf("%q seharusnya ditolak", fields)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// mini-projects/downloader-app/preallocate_linux.go
+
+//go:build linux
+
+package parallel_downloader_app
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// preallocate memesan ruang disk untuk file dengan fallocate(2), sehingga disk penuh
+// langsung ketahuan di awal dan file tidak terfragmentasi oleh tulisan acak dari banyak bagian.
+// Jika filesystem tidak mendukung fallocate, ukuran file cukup diatur dengan Truncate.
+func preallocate(f *os.File, size int64) error {
+	if size <= 0 {
+		return f.Truncate(size)
+	}
+	err := syscall.Fallocate(int(f.Fd()), 0, 0, size)
+	if errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.ENOSYS) {
+		return f.Truncate(size)
+	}
+	return err
+}
@@ -0,0 +1,13 @@
+// mini-projects/downloader-app/preallocate_other.go
+
+//go:build !linux
+
+package parallel_downloader_app
+
+import "os"
+
+// preallocate mengatur ukuran file dengan Truncate. Di luar Linux tidak ada fallocate,
+// sehingga ruang disk baru benar-benar terpakai saat data ditulis (sparse file).
+func preallocate(f *os.File, size int64) error {
+	return f.Truncate(size)
+}
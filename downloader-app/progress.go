@@ -0,0 +1,340 @@
+// mini-projects/downloader-app/progress.go
+package parallel_downloader_app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PartProgress adalah progres satu bagian (segmen) download.
+type PartProgress struct {
+	Index      int   // Nomor segmen
+	Start      int64 // Byte awal segmen di file
+	End        int64 // Byte akhir segmen (inklusif); -1 jika ukuran file tidak diketahui
+	Downloaded int64 // Byte segmen yang sudah ada di disk
+	Active     bool  // Sedang diunduh oleh salah satu worker
+	Speed      float64
+}
+
+// Progress adalah gambaran progres sebuah download pada satu waktu. Nilai yang sama
+// dipakai untuk tampilan terminal, baris log, dan callback ProgressFunc.
+type Progress struct {
+	URL        string
+	Output     string
+	Total      int64         // Ukuran file; -1 jika tidak diketahui
+	Downloaded int64         // Termasuk byte dari download sebelumnya yang dilanjutkan
+	Speed      float64       // Bytes per detik (rata-rata bergerak)
+	ETA        time.Duration // Perkiraan sisa waktu; -1 jika tidak bisa dihitung
+	Elapsed    time.Duration
+	Parts      []PartProgress
+	Done       bool // true pada laporan terakhir setelah download berhenti
+}
+
+// Percent mengembalikan persentase selesai (0-100), atau -1 jika ukuran file tidak diketahui.
+func (p Progress) Percent() float64 {
+	if p.Total < 0 {
+		return -1
+	}
+	if p.Total == 0 {
+		return 100
+	}
+	return float64(p.Downloaded) * 100 / float64(p.Total)
+}
+
+// ProgressFunc dipanggil secara berkala (dan sekali di akhir dengan Done=true) selama download.
+// Fungsi ini dipanggil dari Goroutine pelapor, jadi sebaiknya cepat dan tidak memblokir.
+type ProgressFunc func(Progress)
+
+// Mode tampilan progres.
+const (
+	ProgressAuto = "auto" // Tampilan multi-baris jika stdout adalah terminal, baris log jika bukan (default)
+	ProgressTTY  = "tty"  // Selalu tampilan multi-baris dengan kode ANSI
+	ProgressLog  = "log"  // Selalu baris log berkala
+	ProgressOff  = "off"  // Tanpa tampilan (callback tetap dipanggil)
+)
+
+const (
+	progressSampleInterval = 200 * time.Millisecond // Seberapa sering progres diambil dan tampilan diperbarui
+	progressLogInterval    = 2 * time.Second        // Seberapa sering baris log ditulis pada mode log
+	speedSmoothing         = 0.3                    // Bobot sampel terbaru pada rata-rata bergerak kecepatan
+)
+
+// progressSource mengembalikan jumlah byte yang sudah diunduh dan progres setiap bagian.
+type progressSource func() (downloaded int64, parts []PartProgress)
+
+// countingWriter menghitung byte yang melewatinya. Dipakai pada download satu koneksi;
+// pada download per bagian, partWriter yang menghitung byte per segmen ke manifest.
+type countingWriter struct {
+	w io.Writer
+	n atomic.Int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n.Add(int64(n))
+	return n, err
+}
+
+// progressReporter mengambil progres secara berkala, menghitung kecepatan dan ETA, lalu
+// meneruskannya ke tampilan (terminal atau log) dan ke callback.
+type progressReporter struct {
+	mu       sync.Mutex
+	base     Progress
+	source   progressSource
+	callback ProgressFunc
+	mode     string
+	out      io.Writer
+
+	started   time.Time
+	lastTime  time.Time
+	lastBytes int64
+	// initialBytes adalah byte yang sudah ada saat mulai (download yang dilanjutkan);
+	// tidak dihitung ke kecepatan.
+	initialBytes int64
+	partLast     map[int]int64   // Byte per segmen pada sampel sebelumnya
+	partSpeed    map[int]float64 // Rata-rata bergerak kecepatan per segmen
+	speed        float64
+	lastLog      time.Time
+	lines        int // Jumlah baris tampilan terminal yang sedang tercetak
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newProgressReporter membuat pelapor untuk satu download. 'total' boleh -1.
+func newProgressReporter(cfg downloadConfig, total int64, source progressSource) *progressReporter {
+	out := cfg.logWriter()
+	mode := cfg.ProgressMode
+	if mode == "" || mode == ProgressAuto {
+		mode = ProgressLog
+		if f, ok := out.(*os.File); ok && isTerminal(f) {
+			mode = ProgressTTY
+		}
+	}
+	return &progressReporter{
+		base:      Progress{URL: cfg.URL, Output: cfg.Output, Total: total},
+		source:    source,
+		callback:  cfg.OnProgress,
+		mode:      mode,
+		out:       out,
+		partLast:  make(map[int]int64),
+		partSpeed: make(map[int]float64),
+	}
+}
+
+// isTerminal memeriksa apakah f adalah terminal (character device), tanpa dependensi tambahan.
+func isTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+// start mulai mengambil progres secara berkala di Goroutine terpisah.
+func (pr *progressReporter) start() {
+	pr.started = time.Now()
+	pr.lastTime = pr.started
+	pr.lastLog = pr.started
+	pr.initialBytes, _ = pr.source()
+	pr.lastBytes = pr.initialBytes
+	pr.stop = make(chan struct{})
+	pr.done = make(chan struct{})
+	go func() {
+		defer close(pr.done)
+		ticker := time.NewTicker(progressSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				pr.report(false)
+			case <-pr.stop:
+				return
+			}
+		}
+	}()
+}
+
+// finish menghentikan pelapor dan mengirim laporan terakhir (Done=true).
+func (pr *progressReporter) finish() {
+	if pr.stop == nil {
+		return
+	}
+	close(pr.stop)
+	<-pr.done
+	pr.stop = nil
+	pr.report(true)
+}
+
+// sample mengambil progres terbaru dan memperbarui rata-rata kecepatan.
+func (pr *progressReporter) sample(final bool) Progress {
+	now := time.Now()
+	downloaded, parts := pr.source()
+	p := pr.base
+	p.Downloaded, p.Parts, p.Done = downloaded, parts, final
+	p.Elapsed = now.Sub(pr.started)
+
+	if dt := now.Sub(pr.lastTime).Seconds(); dt > 0 && !final {
+		pr.speed = smooth(pr.speed, float64(downloaded-pr.lastBytes)/dt, pr.lastTime == pr.started)
+		for i, part := range parts {
+			last, seen := pr.partLast[part.Index]
+			if !seen {
+				last = part.Downloaded
+			}
+			pr.partSpeed[part.Index] = smooth(pr.partSpeed[part.Index], float64(part.Downloaded-last)/dt, !seen)
+			pr.partLast[part.Index] = part.Downloaded
+			parts[i].Speed = pr.partSpeed[part.Index]
+		}
+		pr.lastTime, pr.lastBytes = now, downloaded
+	}
+	if final {
+		// Kecepatan akhir adalah rata-rata selama download berjalan.
+		if secs := p.Elapsed.Seconds(); secs > 0 {
+			pr.speed = float64(downloaded-pr.initialBytes) / secs
+		}
+	}
+	p.Speed = pr.speed
+	p.ETA = -1
+	if p.Total >= 0 && p.Speed > 0 {
+		p.ETA = time.Duration(float64(p.Total-p.Downloaded) / p.Speed * float64(time.Second))
+	}
+	return p
+}
+
+// smooth menghitung rata-rata bergerak eksponensial; sampel pertama langsung dipakai.
+func smooth(prev, current float64, first bool) float64 {
+	if first {
+		return current
+	}
+	return speedSmoothing*current + (1-speedSmoothing)*prev
+}
+
+// report mengambil sampel lalu meneruskannya ke tampilan dan callback.
+func (pr *progressReporter) report(final bool) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	p := pr.sample(final)
+	switch pr.mode {
+	case ProgressTTY:
+		pr.redrawLocked(p)
+	case ProgressLog:
+		if final || time.Since(pr.lastLog) >= progressLogInterval {
+			fmt.Fprintln(pr.out, formatProgressLine(p))
+			pr.lastLog = time.Now()
+		}
+	}
+	if pr.callback != nil {
+		pr.callback(p)
+	}
+}
+
+// logf mencetak pesan tanpa merusak tampilan terminal: tampilan dihapus dulu, pesan dicetak,
+// lalu tampilan digambar ulang di bawahnya pada laporan berikutnya.
+func (pr *progressReporter) logf(format string, args ...any) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if pr.mode == ProgressTTY {
+		pr.clearLocked()
+	}
+	fmt.Fprintf(pr.out, format, args...)
+}
+
+// clearLocked menghapus baris-baris tampilan terminal yang sedang tercetak.
+func (pr *progressReporter) clearLocked() {
+	for ; pr.lines > 0; pr.lines-- {
+		fmt.Fprint(pr.out, "\x1b[1A\x1b[2K") // Naik satu baris lalu hapus baris itu
+	}
+}
+
+// redrawLocked menggambar ulang tampilan multi-baris: satu baris total dan satu baris per
+// segmen yang sedang aktif.
+func (pr *progressReporter) redrawLocked(p Progress) {
+	pr.clearLocked()
+	var b strings.Builder
+	b.WriteString(formatProgressLine(p) + "\n")
+	lines := 1
+	if !p.Done {
+		for _, part := range p.Parts {
+			if !part.Active {
+				continue
+			}
+			b.WriteString(formatPartLine(part) + "\n")
+			lines++
+		}
+	}
+	fmt.Fprint(pr.out, b.String())
+	pr.lines = lines
+	if p.Done {
+		pr.lines = 0 // Tampilan terakhir dibiarkan di layar
+	}
+}
+
+// formatProgressLine membuat ringkasan satu baris, misalnya:
+// "[#########-----------]  45.2%  12.3 MB / 27.0 MB  3.4 MB/s  ETA 5s"
+func formatProgressLine(p Progress) string {
+	var b strings.Builder
+	if pct := p.Percent(); pct >= 0 {
+		fmt.Fprintf(&b, "%s %5.1f%%  %s / %s", progressBar(pct, 20), pct, formatBytes(p.Downloaded), formatBytes(p.Total))
+	} else {
+		fmt.Fprintf(&b, "%s diunduh (ukuran tidak diketahui)", formatBytes(p.Downloaded))
+	}
+	if p.Done {
+		fmt.Fprintf(&b, "  selesai dalam %s (rata-rata %s/s)", formatDuration(p.Elapsed), formatBytes(int64(p.Speed)))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "  %s/s", formatBytes(int64(p.Speed)))
+	if p.ETA >= 0 {
+		fmt.Fprintf(&b, "  ETA %s", formatDuration(p.ETA))
+	}
+	return b.String()
+}
+
+// formatPartLine membuat baris progres satu segmen.
+func formatPartLine(part PartProgress) string {
+	if part.End < 0 {
+		return fmt.Sprintf("  Bagian %-3d %s  %s/s", part.Index, formatBytes(part.Downloaded), formatBytes(int64(part.Speed)))
+	}
+	length := part.End - part.Start + 1
+	pct := 100.0
+	if length > 0 {
+		pct = float64(part.Downloaded) * 100 / float64(length)
+	}
+	return fmt.Sprintf("  Bagian %-3d %s %5.1f%%  %s/s", part.Index, progressBar(pct, 14), pct, formatBytes(int64(part.Speed)))
+}
+
+// progressBar menggambar bar seperti "[#####-----]" untuk persentase pct.
+func progressBar(pct float64, width int) string {
+	filled := int(pct / 100 * float64(width))
+	filled = min(max(filled, 0), width)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+// formatBytes menampilkan ukuran dalam satuan biner (B, KB, MB, GB, TB).
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, units := float64(n)/unit, "KMGT"
+	i := 0
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %cB", value, units[i])
+}
+
+// formatDuration menampilkan durasi secara ringkas: "45s", "3m05s", "1h02m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
@@ -0,0 +1,962 @@
+// mini-projects/downloader-app/queue.go
+package parallel_downloader_app
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queueFilePath adalah file tempat antrean download disimpan.
+const queueFilePath = "download-queue.json"
+
+// Nilai default antrean.
+const (
+	defaultQueueJobs     = 2 // Jumlah download yang berjalan bersamaan
+	defaultQueueConns    = 8 // Jumlah koneksi HTTP bersamaan di semua download
+	defaultQueueNumParts = 4 // Jumlah bagian paralel setiap download
+)
+
+// Status job di antrean.
+const (
+	jobQueued    = "queued"    // Menunggu giliran
+	jobScheduled = "scheduled" // Menunggu jendela waktu jadwalnya; progres tersimpan di manifest
+	jobRunning   = "running"   // Sedang diunduh
+	jobPaused    = "paused"    // Dijeda pengguna; progres tersimpan di manifest
+	jobDone      = "done"      // Selesai
+	jobFailed    = "failed"    // Gagal; bisa dicoba lagi dengan resume
+)
+
+// jobCanceled bukan status yang disimpan: job yang dibatalkan langsung dihapus dari antrean.
+const jobCanceled = "canceled"
+
+// queueJob adalah satu download di antrean.
+type queueJob struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	Output     string    `json:"output"`
+	NumParts   int       `json:"num_parts"`
+	PartFiles  bool      `json:"part_files,omitempty"`
+	Checksum   string    `json:"checksum,omitempty"`
+	Priority   int       `json:"priority"` // Makin besar makin didahulukan
+	State      string    `json:"state"`
+	Error      string    `json:"error,omitempty"`
+	Downloaded int64     `json:"downloaded"`
+	Total      int64     `json:"total"`                // -1 jika belum/tidak diketahui
+	RateLimit  int64     `json:"rate_limit,omitempty"` // Batas kecepatan job ini (bytes/detik); 0 = tanpa batas
+	Schedule   *Schedule `json:"schedule,omitempty"`   // Kapan job boleh berjalan; nil = kapan saja
+	// Actions dijalankan berurutan setelah download selesai. Jika satu aksi gagal, job gagal;
+	// resume hanya menjalankan ulang aksi yang belum berhasil, tanpa mengunduh ulang.
+	Actions []postAction `json:"actions,omitempty"`
+	// File adalah path file hasil download setelah download selesai (berubah setelah aksi move).
+	File       string    `json:"file,omitempty"`
+	AddedAt    time.Time `json:"added_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+}
+
+// queueFile adalah isi file antrean di disk.
+type queueFile struct {
+	NextID    int         `json:"next_id"`
+	RateLimit int64       `json:"rate_limit,omitempty"` // Batas kecepatan global (bytes/detik)
+	Jobs      []*queueJob `json:"jobs"`
+}
+
+// runningJob mencatat job yang sedang berjalan dan alasan jika job itu dihentikan.
+type runningJob struct {
+	cancel  context.CancelFunc
+	limiter *rateLimiter // Batas kecepatan job ini, agar bisa diubah saat berjalan
+	stopAs  string       // Status setelah berhenti (jobPaused, jobQueued, jobScheduled, jobCanceled); "" jika tidak dihentikan
+}
+
+// downloadQueue menjalankan banyak download dari satu daftar yang disimpan ke disk.
+// Paling banyak maxJobs download berjalan bersamaan, dan semuanya berbagi batas koneksi 'conns'.
+// Setiap perubahan (tambah, jeda, selesai, ...) langsung disimpan, sehingga antrean tetap
+// utuh jika program ditutup; download yang terhenti dilanjutkan dari manifest-nya.
+type downloadQueue struct {
+	mu      sync.Mutex
+	path    string
+	nextID  int
+	jobs    []*queueJob
+	maxJobs int
+	conns   *connLimiter
+	rate    *rateLimiter // Batas kecepatan global, dibagi semua download di antrean
+	retry   retryPolicy  // Aturan percobaan ulang setiap download (nilai nol = default)
+	running map[int]*runningJob
+	history *downloadHistory // Tempat mencatat hasil download; nil = tidak dicatat
+	ctx     context.Context  // Context induk semua download; nil jika antrean belum/tidak berjalan
+	timer   *time.Timer      // Membangunkan antrean di pergantian jendela waktu berikutnya
+	wg      sync.WaitGroup
+}
+
+// openDownloadQueue memuat antrean dari 'path' (atau membuat antrean kosong jika file belum ada).
+// Job yang tercatat "running" berarti program terhenti di tengah download, jadi dikembalikan ke antrean.
+func openDownloadQueue(path string, maxJobs, maxConns int) (*downloadQueue, error) {
+	if maxJobs <= 0 {
+		maxJobs = defaultQueueJobs
+	}
+	if maxConns <= 0 {
+		maxConns = defaultQueueConns
+	}
+	q := &downloadQueue{path: path, nextID: 1, maxJobs: maxJobs, conns: newConnLimiter(maxConns), rate: newRateLimiter(0), running: make(map[int]*runningJob)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca antrean %s: %v", path, err)
+	}
+	var f queueFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("antrean %s rusak: %v", path, err)
+	}
+	q.jobs = f.Jobs
+	q.rate.setRate(f.RateLimit)
+	q.nextID = max(f.NextID, 1)
+	for _, job := range q.jobs {
+		if job.State == jobRunning {
+			job.State = jobQueued
+		}
+		q.nextID = max(q.nextID, job.ID+1)
+	}
+	return q, nil
+}
+
+// saveLocked menulis antrean secara atomik, sama seperti manifest. q.mu harus dipegang.
+func (q *downloadQueue) saveLocked() error {
+	data, err := json.MarshalIndent(queueFile{NextID: q.nextID, RateLimit: q.rate.limit(), Jobs: q.jobs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("gagal mengkodekan antrean: %v", err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("gagal menulis antrean: %v", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("gagal menyimpan antrean: %v", err)
+	}
+	return nil
+}
+
+// add menambahkan satu URL ke antrean. Jika 'output' kosong, nama file diambil dari URL.
+func (q *downloadQueue) add(fileURL, output string, priority int) (queueJob, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return queueJob{}, fmt.Errorf("URL tidak valid: '%s'", fileURL)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if output == "" {
+		output = outputNameFromURL(u, q.nextID)
+	}
+	for _, job := range q.jobs {
+		if job.Output == output && job.State != jobDone {
+			return queueJob{}, fmt.Errorf("file output '%s' sudah dipakai job %d", output, job.ID)
+		}
+	}
+	job := &queueJob{
+		ID: q.nextID, URL: fileURL, Output: output, NumParts: defaultQueueNumParts, Priority: priority,
+		State: jobQueued, Total: -1, AddedAt: time.Now(),
+	}
+	q.nextID++
+	q.jobs = append(q.jobs, job)
+	if err := q.saveLocked(); err != nil {
+		return queueJob{}, err
+	}
+	q.scheduleLocked()
+	return *job, nil
+}
+
+// addFromFile menambahkan semua URL dari file teks: satu URL per baris, boleh diikuti nama
+// file output ("<url> [output]"). Baris kosong dan baris yang diawali '#' diabaikan.
+// Baris yang tidak valid dilewati dan dilaporkan lewat 'errs'.
+func (q *downloadQueue) addFromFile(listPath string) (added int, errs []error) {
+	file, err := os.Open(listPath)
+	if err != nil {
+		return 0, []error{fmt.Errorf("gagal membuka daftar URL %s: %v", listPath, err)}
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		output := ""
+		if len(fields) > 1 {
+			output = fields[1]
+		}
+		if _, err := q.add(fields[0], output, 0); err != nil {
+			errs = append(errs, fmt.Errorf("baris %d: %v", lineNum, err))
+			continue
+		}
+		added++
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("gagal membaca daftar URL %s: %v", listPath, err))
+	}
+	return added, errs
+}
+
+// outputNameFromURL mengambil nama file dari path URL, misalnya ".../linux.iso" -> "linux.iso".
+// Jika URL tidak punya nama file, dipakai "download-<id>.bin" (atau "download.bin" jika id 0).
+func outputNameFromURL(u *url.URL, id int) string {
+	name := sanitizeFilename(path.Base(u.Path))
+	if name == "" {
+		if id <= 0 {
+			return "download.bin"
+		}
+		return fmt.Sprintf("download-%d.bin", id)
+	}
+	return name
+}
+
+// findLocked mencari job berdasarkan ID. q.mu harus dipegang.
+func (q *downloadQueue) findLocked(id int) (*queueJob, error) {
+	for _, job := range q.jobs {
+		if job.ID == id {
+			return job, nil
+		}
+	}
+	return nil, fmt.Errorf("job %d tidak ditemukan", id)
+}
+
+// pause menjeda job yang menunggu atau sedang berjalan. Download yang berjalan dihentikan;
+// progresnya tetap ada di manifest sehingga bisa dilanjutkan dengan resume.
+func (q *downloadQueue) pause(id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.findLocked(id)
+	if err != nil {
+		return err
+	}
+	switch job.State {
+	case jobRunning:
+		q.stopLocked(id, jobPaused)
+		return nil // Status disimpan oleh runJob setelah download benar-benar berhenti
+	case jobQueued, jobScheduled:
+		job.State = jobPaused
+		return q.saveLocked()
+	default:
+		return fmt.Errorf("job %d berstatus %s dan tidak bisa dijeda", id, job.State)
+	}
+}
+
+// resume mengembalikan job yang dijeda atau gagal ke antrean.
+func (q *downloadQueue) resume(id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.findLocked(id)
+	if err != nil {
+		return err
+	}
+	if job.State != jobPaused && job.State != jobFailed {
+		return fmt.Errorf("job %d berstatus %s dan tidak bisa dilanjutkan", id, job.State)
+	}
+	job.State, job.Error = jobQueued, ""
+	if err := q.saveLocked(); err != nil {
+		return err
+	}
+	q.scheduleLocked()
+	return nil
+}
+
+// cancel membatalkan job dan menghapusnya dari antrean beserta file bagian dan manifest-nya.
+// Untuk job yang sudah selesai, hanya entrinya yang dihapus; file hasil download tetap ada.
+func (q *downloadQueue) cancel(id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.findLocked(id)
+	if err != nil {
+		return err
+	}
+	if job.State == jobRunning {
+		q.stopLocked(id, jobCanceled)
+		return nil // Sisa download dihapus oleh runJob setelah download berhenti
+	}
+	if job.State != jobDone {
+		discardManifest(manifestPath(job.Output))
+	}
+	q.removeLocked(id)
+	return q.saveLocked()
+}
+
+// setPriority mengubah prioritas job. Prioritas hanya memengaruhi urutan job yang menunggu;
+// job yang sedang berjalan tidak dihentikan.
+func (q *downloadQueue) setPriority(id, priority int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.findLocked(id)
+	if err != nil {
+		return err
+	}
+	job.Priority = priority
+	if err := q.saveLocked(); err != nil {
+		return err
+	}
+	q.scheduleLocked()
+	return nil
+}
+
+// setRateLimit mengubah batas kecepatan global (0 = tanpa batas). Download yang sedang
+// berjalan langsung mengikuti batas baru.
+func (q *downloadQueue) setRateLimit(bytesPerSec int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rate.setRate(bytesPerSec)
+	return q.saveLocked()
+}
+
+// setJobRateLimit mengubah batas kecepatan satu job (0 = tanpa batas), juga saat job berjalan.
+func (q *downloadQueue) setJobRateLimit(id int, bytesPerSec int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.findLocked(id)
+	if err != nil {
+		return err
+	}
+	job.RateLimit = bytesPerSec
+	if r := q.running[id]; r != nil {
+		r.limiter.setRate(job.rateAt(time.Now()))
+	}
+	return q.saveLocked()
+}
+
+// setSchedule mengubah jadwal job (nil = kapan saja). Job yang sedang berjalan di luar jadwal
+// barunya dihentikan dan menunggu jendela berikutnya.
+func (q *downloadQueue) setSchedule(id int, s *Schedule) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.findLocked(id)
+	if err != nil {
+		return err
+	}
+	if job.State == jobDone {
+		return fmt.Errorf("job %d sudah selesai", id)
+	}
+	job.Schedule = s
+	if err := q.saveLocked(); err != nil {
+		return err
+	}
+	q.scheduleLocked()
+	return nil
+}
+
+// addAction menambahkan aksi setelah download ke job yang tidak sedang berjalan. Untuk job
+// yang sudah selesai, aksi baru langsung dijalankan tanpa mengunduh ulang.
+func (q *downloadQueue) addAction(id int, a postAction) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.findLocked(id)
+	if err != nil {
+		return err
+	}
+	if job.State == jobRunning {
+		return fmt.Errorf("job %d sedang berjalan; jeda dulu sebelum mengubah aksinya", id)
+	}
+	job.Actions = append(job.Actions, a)
+	if job.State == jobDone {
+		if job.File == "" {
+			job.File = job.Output
+		}
+		job.State = jobQueued
+	}
+	if err := q.saveLocked(); err != nil {
+		return err
+	}
+	q.scheduleLocked()
+	return nil
+}
+
+// clearActions menghapus semua aksi setelah download dari job yang tidak sedang berjalan.
+func (q *downloadQueue) clearActions(id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.findLocked(id)
+	if err != nil {
+		return err
+	}
+	if job.State == jobRunning {
+		return fmt.Errorf("job %d sedang berjalan; jeda dulu sebelum mengubah aksinya", id)
+	}
+	job.Actions = nil
+	return q.saveLocked()
+}
+
+// rateAt mengembalikan batas kecepatan job pada 'now': yang paling ketat antara batas job
+// dan batas jendela waktu yang sedang berlaku (0 = tanpa batas).
+func (job *queueJob) rateAt(now time.Time) int64 {
+	rate := job.RateLimit
+	if job.Schedule != nil {
+		if _, windowRate := job.Schedule.state(now); windowRate > 0 && (rate == 0 || windowRate < rate) {
+			rate = windowRate
+		}
+	}
+	return rate
+}
+
+// refreshSchedulesLocked menyesuaikan job dengan jadwalnya pada 'now': job menunggu di luar
+// jadwal menjadi jobScheduled dan sebaliknya, job yang berjalan di luar jadwal dihentikan
+// (dilanjutkan dari manifest saat jendela berikutnya dibuka), dan batas kecepatan job yang
+// berjalan mengikuti jendela saat ini. Timer antrean dipasang ke pergantian jendela berikutnya.
+func (q *downloadQueue) refreshSchedulesLocked(now time.Time) {
+	changed := false
+	var wake time.Time
+	for _, job := range q.jobs {
+		active := true
+		if job.Schedule != nil {
+			active, _ = job.Schedule.state(now)
+		}
+		switch job.State {
+		case jobQueued:
+			if !active {
+				job.State, changed = jobScheduled, true
+			}
+		case jobScheduled:
+			if active {
+				job.State, changed = jobQueued, true
+			}
+		case jobRunning:
+			if r := q.running[job.ID]; r != nil && r.stopAs == "" {
+				if !active {
+					q.stopLocked(job.ID, jobScheduled)
+				} else {
+					r.limiter.setRate(job.rateAt(now))
+				}
+			}
+		default:
+			continue
+		}
+		if job.Schedule != nil {
+			if next := job.Schedule.nextChange(now); !next.IsZero() && (wake.IsZero() || next.Before(wake)) {
+				wake = next
+			}
+		}
+	}
+	if changed {
+		if err := q.saveLocked(); err != nil {
+			fmt.Println("Peringatan:", err)
+		}
+	}
+
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	if !wake.IsZero() && q.ctx != nil {
+		q.timer = time.AfterFunc(wake.Sub(now), func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.scheduleLocked()
+		})
+	}
+}
+
+// stopLocked menghentikan download job yang sedang berjalan; 'stopAs' adalah status setelahnya.
+func (q *downloadQueue) stopLocked(id int, stopAs string) {
+	if r := q.running[id]; r != nil {
+		r.stopAs = stopAs
+		r.cancel()
+	}
+}
+
+// removeLocked menghapus job dari daftar.
+func (q *downloadQueue) removeLocked(id int) {
+	q.jobs = slices.DeleteFunc(q.jobs, func(job *queueJob) bool { return job.ID == id })
+}
+
+// start mulai menjalankan job yang menunggu. Download berhenti jika ctx dibatalkan atau stop dipanggil.
+func (q *downloadQueue) start(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ctx = ctx
+	q.scheduleLocked()
+}
+
+// stop menghentikan semua download yang berjalan dan menunggu sampai semuanya berhenti.
+// Job tersebut dikembalikan ke status queued agar dilanjutkan saat antrean dijalankan lagi.
+func (q *downloadQueue) stop() error {
+	q.mu.Lock()
+	q.ctx = nil
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	for id := range q.running {
+		q.stopLocked(id, jobQueued)
+	}
+	q.mu.Unlock()
+
+	q.wg.Wait()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.saveLocked()
+}
+
+// nextQueuedLocked memilih job menunggu berikutnya: prioritas tertinggi, lalu yang paling dulu ditambahkan.
+func (q *downloadQueue) nextQueuedLocked() *queueJob {
+	var next *queueJob
+	for _, job := range q.jobs {
+		if job.State != jobQueued {
+			continue
+		}
+		if next == nil || cmp.Or(cmp.Compare(next.Priority, job.Priority), cmp.Compare(job.ID, next.ID)) < 0 {
+			next = job
+		}
+	}
+	return next
+}
+
+// scheduleLocked menyesuaikan job dengan jadwalnya, lalu menjalankan job menunggu selama
+// slot download masih tersedia.
+func (q *downloadQueue) scheduleLocked() {
+	if q.ctx == nil {
+		return
+	}
+	now := time.Now()
+	q.refreshSchedulesLocked(now)
+	for q.ctx != nil && q.ctx.Err() == nil && len(q.running) < q.maxJobs {
+		job := q.nextQueuedLocked()
+		if job == nil {
+			return
+		}
+		ctx, cancel := context.WithCancel(q.ctx)
+		limiter := newRateLimiter(job.rateAt(now))
+		q.running[job.ID] = &runningJob{cancel: cancel, limiter: limiter}
+		job.State, job.Error = jobRunning, ""
+		if err := q.saveLocked(); err != nil {
+			fmt.Println("Peringatan:", err)
+		}
+
+		id := job.ID
+		cfg := downloadConfig{
+			URL: job.URL, Output: job.Output, NumParts: job.NumParts, PartFiles: job.PartFiles,
+			Checksum: job.Checksum, Retry: q.retry, Conns: q.conns, RateLimit: limiter, SharedRateLimit: q.rate,
+			KeepPartial: true, // Job yang gagal dilanjutkan saat dicoba lagi
+			// Banyak download berjalan bersamaan, jadi progres dilihat lewat perintah 'list'.
+			ProgressMode: ProgressOff,
+			OnProgress: func(p Progress) {
+				q.mu.Lock()
+				job.Downloaded, job.Total = p.Downloaded, p.Total
+				q.mu.Unlock()
+			},
+		}
+		q.wg.Add(1)
+		go q.runJob(ctx, id, cfg, job.File)
+	}
+}
+
+// runJob menjalankan satu download beserta aksi setelahnya, lalu mencatat hasilnya dan
+// menjalankan job berikutnya. Jika 'file' sudah diisi, download sudah selesai sebelumnya dan
+// hanya aksi yang belum berhasil yang dijalankan.
+func (q *downloadQueue) runJob(ctx context.Context, id int, cfg downloadConfig, file string) {
+	defer q.wg.Done()
+	var err error
+	if file == "" {
+		var res Result
+		res, err = runDownload(ctx, cfg)
+		if q.history != nil && (err == nil || ctx.Err() == nil) {
+			if _, recErr := q.history.record([]string{cfg.URL}, res, err); recErr != nil {
+				fmt.Println("Peringatan:", recErr)
+			}
+		}
+		if err == nil {
+			q.mu.Lock()
+			if job, findErr := q.findLocked(id); findErr == nil {
+				job.File = cfg.Output
+				if saveErr := q.saveLocked(); saveErr != nil {
+					fmt.Println("Peringatan:", saveErr)
+				}
+			}
+			q.mu.Unlock()
+		}
+	}
+	if err == nil {
+		err = q.runActions(ctx, id)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	r := q.running[id]
+	delete(q.running, id)
+	r.cancel()
+	job, findErr := q.findLocked(id)
+	if findErr != nil {
+		return
+	}
+	switch {
+	case err == nil:
+		job.State, job.FinishedAt = jobDone, time.Now()
+		fmt.Printf("\n[Antrean] Job %d selesai: %s\n", id, cmp.Or(job.File, job.Output))
+	case r.stopAs == jobCanceled:
+		discardManifest(manifestPath(job.Output))
+		q.removeLocked(id)
+		fmt.Printf("\n[Antrean] Job %d dibatalkan.\n", id)
+	case r.stopAs == jobScheduled:
+		job.State = jobScheduled
+		fmt.Printf("\n[Antrean] Job %d dihentikan karena jendela waktunya ditutup; dilanjutkan di jendela berikutnya.\n", id)
+	case r.stopAs != "":
+		job.State = r.stopAs
+	default:
+		job.State, job.Error = jobFailed, err.Error()
+		fmt.Printf("\n[Antrean] Job %d gagal: %v\n", id, err)
+	}
+	if err := q.saveLocked(); err != nil {
+		fmt.Println("Peringatan:", err)
+	}
+	q.scheduleLocked()
+}
+
+// runActions menjalankan aksi job yang belum berhasil secara berurutan dan mencatat hasil
+// setiap aksi di job. Aksi move mengubah job.File, sehingga aksi berikutnya memakai path baru.
+// Aksi yang terhenti karena job dihentikan tetap dianggap belum dijalankan.
+func (q *downloadQueue) runActions(ctx context.Context, id int) error {
+	for i := 0; ; i++ {
+		q.mu.Lock()
+		job, err := q.findLocked(id)
+		if err != nil || i >= len(job.Actions) {
+			q.mu.Unlock()
+			return nil
+		}
+		a, file, fileURL := job.Actions[i], job.File, job.URL
+		q.mu.Unlock()
+		if a.Status == actionOK {
+			continue
+		}
+
+		newFile, result, err := runPostAction(ctx, a, file, fileURL)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		q.mu.Lock()
+		if job, findErr := q.findLocked(id); findErr == nil && i < len(job.Actions) {
+			job.File = newFile
+			if err != nil {
+				job.Actions[i].Status, job.Actions[i].Result = actionFailed, err.Error()
+			} else {
+				job.Actions[i].Status, job.Actions[i].Result = actionOK, result
+			}
+			if saveErr := q.saveLocked(); saveErr != nil {
+				fmt.Println("Peringatan:", saveErr)
+			}
+		}
+		q.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("aksi %s gagal: %w", a.Kind, err)
+		}
+		fmt.Printf("\n[Antrean] Job %d: %s: %s\n", id, a.Kind, result)
+	}
+}
+
+// snapshot mengembalikan salinan semua job, diurutkan seperti urutan eksekusinya.
+func (q *downloadQueue) snapshot() []queueJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]queueJob, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+	slices.SortStableFunc(jobs, func(a, b queueJob) int {
+		return cmp.Or(cmp.Compare(b.Priority, a.Priority), cmp.Compare(a.ID, b.ID))
+	})
+	return jobs
+}
+
+// connLimiter membatasi jumlah koneksi HTTP yang terbuka bersamaan di beberapa download.
+// Nilai nil berarti tanpa batas.
+type connLimiter struct {
+	slots chan struct{}
+}
+
+func newConnLimiter(n int) *connLimiter {
+	return &connLimiter{slots: make(chan struct{}, max(n, 1))}
+}
+
+// acquire menunggu sampai ada slot koneksi kosong, atau ctx dibatalkan.
+func (l *connLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release mengembalikan slot yang diambil acquire.
+func (l *connLimiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
+
+// RunDownloadQueueCLI menjalankan antrean download secara interaktif. Download berjalan di
+// latar belakang selama pengguna mengetik perintah; saat keluar, download yang belum selesai
+// dihentikan dan dilanjutkan lain kali menu ini dibuka.
+func RunDownloadQueueCLI(reader *bufio.Reader) {
+	fmt.Println("\n--- Antrean Download ---")
+
+	fmt.Printf("Jumlah download bersamaan (default %d): ", defaultQueueJobs)
+	maxJobs := readPositiveInt(reader, defaultQueueJobs)
+	fmt.Printf("Batas total koneksi untuk semua download (default %d): ", defaultQueueConns)
+	maxConns := readPositiveInt(reader, defaultQueueConns)
+
+	q, err := openDownloadQueue(queueFilePath, maxJobs, maxConns)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if q.history, err = openDownloadHistory(historyFilePath); err != nil {
+		fmt.Printf("Peringatan: %v. Hasil download tidak dicatat di riwayat.\n", err)
+	}
+	q.start(context.Background())
+	defer func() {
+		fmt.Println("Menghentikan download yang sedang berjalan...")
+		if err := q.stop(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Printf("Antrean disimpan di %s.\n", queueFilePath)
+	}()
+
+	printQueueHelp()
+	for {
+		fmt.Print("\nantrean> ")
+		line, err := reader.ReadString('\n')
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			if err != nil {
+				return // stdin ditutup
+			}
+			continue
+		}
+
+		cmd, args := strings.ToLower(fields[0]), fields[1:]
+		switch cmd {
+		case "add":
+			if len(args) == 0 {
+				fmt.Println("Penggunaan: add <url> [output]")
+				continue
+			}
+			output := ""
+			if len(args) > 1 {
+				output = args[1]
+			}
+			job, err := q.add(args[0], output, 0)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("Job %d ditambahkan: %s -> %s\n", job.ID, job.URL, job.Output)
+		case "addfile":
+			if len(args) != 1 {
+				fmt.Println("Penggunaan: addfile <path>")
+				continue
+			}
+			added, errs := q.addFromFile(args[0])
+			for _, err := range errs {
+				fmt.Printf("Error: %v\n", err)
+			}
+			fmt.Printf("%d job ditambahkan.\n", added)
+		case "list", "ls":
+			fmt.Printf("Batas kecepatan global: %s\n", formatRate(q.rate.limit()))
+			printQueue(q.snapshot())
+		case "pause", "resume", "cancel":
+			id, err := parseJobID(args)
+			if err != nil {
+				fmt.Printf("Penggunaan: %s <id>\n", cmd)
+				continue
+			}
+			switch cmd {
+			case "pause":
+				err = q.pause(id)
+			case "resume":
+				err = q.resume(id)
+			default:
+				err = q.cancel(id)
+			}
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("Job %d: %s berhasil.\n", id, cmd)
+		case "prio":
+			id, err := parseJobID(args)
+			var priority int
+			if err == nil && len(args) == 2 {
+				_, err = fmt.Sscan(args[1], &priority)
+			}
+			if err != nil || len(args) != 2 {
+				fmt.Println("Penggunaan: prio <id> <prioritas> (makin besar makin didahulukan)")
+				continue
+			}
+			if err := q.setPriority(id, priority); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("Prioritas job %d sekarang %d.\n", id, priority)
+		case "limit":
+			// "limit <kecepatan>" untuk batas global, "limit <id> <kecepatan>" untuk satu job.
+			if len(args) == 0 || len(args) > 2 {
+				fmt.Println("Penggunaan: limit <kecepatan> | limit <id> <kecepatan> (contoh: 2MB, 500K, 0 = tanpa batas)")
+				continue
+			}
+			bytesPerSec, err := parseByteRate(args[len(args)-1])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			if len(args) == 1 {
+				err = q.setRateLimit(bytesPerSec)
+			} else if id, idErr := parseJobID(args); idErr != nil {
+				err = fmt.Errorf("ID job tidak valid: '%s'", args[0])
+			} else {
+				err = q.setJobRateLimit(id, bytesPerSec)
+			}
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("Batas kecepatan sekarang %s.\n", formatRate(bytesPerSec))
+		case "schedule":
+			// "schedule <id> [start=<waktu>] [<jendela>...]" atau "schedule <id> clear".
+			id, err := parseJobID(args)
+			if err != nil || len(args) < 2 {
+				fmt.Println("Penggunaan: schedule <id> [start=<waktu>] [<jendela>[@kecepatan]...] | schedule <id> clear")
+				fmt.Println("Contoh: schedule 3 22:00-06:00@2MB   schedule 3 start=23:30 00:00-07:00")
+				continue
+			}
+			var s *Schedule
+			if len(args) != 2 || args[1] != "clear" {
+				parsed, err := parseScheduleArgs(args[1:], time.Now())
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					continue
+				}
+				s = &parsed
+			}
+			if err := q.setSchedule(id, s); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			if s == nil {
+				fmt.Printf("Jadwal job %d dihapus; job boleh berjalan kapan saja.\n", id)
+			} else {
+				fmt.Printf("Jadwal job %d: %s.\n", id, s)
+			}
+		case "action":
+			// "action <id> <jenis> [argumen...]" atau "action <id> clear".
+			id, err := parseJobID(args)
+			if err != nil || len(args) < 2 {
+				fmt.Println("Penggunaan: action <id> verify <checksum> | extract [dir] | move <dir> | exec <program> [arg...] | clear")
+				continue
+			}
+			if len(args) == 2 && args[1] == "clear" {
+				err = q.clearActions(id)
+			} else {
+				var a postAction
+				if a, err = parsePostAction(args[1:]); err == nil {
+					err = q.addAction(id, a)
+				}
+			}
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("Aksi job %d diperbarui.\n", id)
+		case "help":
+			printQueueHelp()
+		case "exit", "quit", "back":
+			return
+		default:
+			fmt.Println("Perintah tidak dikenal. Ketik 'help' untuk daftar perintah.")
+		}
+	}
+}
+
+func printQueueHelp() {
+	fmt.Println("Perintah:")
+	fmt.Println("  add <url> [output]     Tambahkan URL ke antrean")
+	fmt.Println("  addfile <path>         Tambahkan semua URL dari file (satu '<url> [output]' per baris)")
+	fmt.Println("  list                   Tampilkan semua job dan progresnya")
+	fmt.Println("  pause <id>             Jeda job (progres disimpan)")
+	fmt.Println("  resume <id>            Lanjutkan job yang dijeda atau gagal")
+	fmt.Println("  cancel <id>            Batalkan job dan hapus sisa download-nya")
+	fmt.Println("  prio <id> <n>          Ubah prioritas job (makin besar makin didahulukan)")
+	fmt.Println("  limit [id] <kecepatan> Batasi kecepatan semua download atau satu job (contoh: 2MB, 0 = tanpa batas)")
+	fmt.Println("  schedule <id> <jadwal> Jalankan job hanya dalam jendela waktu (contoh: 22:00-06:00@2MB start=23:30, atau clear)")
+	fmt.Println("  action <id> <aksi>     Tambah aksi setelah download: verify <checksum>, extract [dir], move <dir>,")
+	fmt.Println("                         exec <program> [arg...] ('{}' = path file), atau clear")
+	fmt.Println("  exit                   Kembali ke menu utama (download dihentikan dan dilanjutkan nanti)")
+}
+
+// printQueue menampilkan job dalam bentuk tabel.
+func printQueue(jobs []queueJob) {
+	if len(jobs) == 0 {
+		fmt.Println("Antrean kosong.")
+		return
+	}
+	fmt.Printf("%-4s %-5s %-9s %-16s %s\n", "ID", "PRIO", "STATUS", "PROGRES", "OUTPUT")
+	for _, job := range jobs {
+		progress := formatBytes(job.Downloaded)
+		if job.Total > 0 {
+			progress = fmt.Sprintf("%.1f%% / %s", float64(job.Downloaded)*100/float64(job.Total), formatBytes(job.Total))
+		}
+		fmt.Printf("%-4d %-5d %-9s %-16s %s\n", job.ID, job.Priority, job.State, progress, job.Output)
+		if job.RateLimit > 0 {
+			fmt.Printf("     batas kecepatan: %s\n", formatRate(job.RateLimit))
+		}
+		if job.Schedule != nil {
+			fmt.Printf("     jadwal: %s\n", job.Schedule)
+			if next := job.Schedule.nextOpening(time.Now()); job.State == jobScheduled && !next.IsZero() {
+				fmt.Printf("     mulai lagi: %s\n", next.Format("2006-01-02 15:04"))
+			}
+		}
+		for _, a := range job.Actions {
+			status := cmp.Or(a.Status, "menunggu")
+			if a.Result != "" {
+				status += ": " + a.Result
+			}
+			fmt.Printf("     aksi %s [%s]\n", a, status)
+		}
+		if job.File != "" && job.File != job.Output {
+			fmt.Printf("     file: %s\n", job.File)
+		}
+		if job.Error != "" {
+			fmt.Printf("     error: %s\n", job.Error)
+		}
+	}
+}
+
+// parseJobID membaca ID job dari argumen pertama perintah.
+func parseJobID(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, errors.New("ID job kosong")
+	}
+	var id int
+	_, err := fmt.Sscan(args[0], &id)
+	return id, err
+}
+
+// readPositiveInt membaca satu bilangan bulat positif, atau mengembalikan 'def' jika input kosong/tidak valid.
+func readPositiveInt(reader *bufio.Reader, def int) int {
+	input, _ := reader.ReadString('\n')
+	var n int
+	if _, err := fmt.Sscan(strings.TrimSpace(input), &n); err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,233 @@
+// mini-projects/downloader-app/queue_test.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingServer membungkus testFileServer dan mencatat jumlah GET terbanyak yang berjalan bersamaan.
+type countingServer struct {
+	*testFileServer
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (cs *countingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		cs.mu.Lock()
+		cs.inFlight++
+		cs.maxInFlight = max(cs.maxInFlight, cs.inFlight)
+		cs.mu.Unlock()
+		defer func() {
+			cs.mu.Lock()
+			cs.inFlight--
+			cs.mu.Unlock()
+		}()
+	}
+	cs.testFileServer.ServeHTTP(w, r)
+}
+
+func newThrottledQueueServer(t *testing.T, content []byte, rate int64) (*countingServer, *httptest.Server) {
+	t.Helper()
+	cs := &countingServer{testFileServer: &testFileServer{content: content, etag: `"v1"`}}
+	cs.throttle = func(*http.Request) int64 { return rate }
+	srv := httptest.NewServer(cs)
+	t.Cleanup(srv.Close)
+	return cs, srv
+}
+
+// waitForJob menunggu sampai job 'id' memenuhi 'cond', atau menggagalkan test setelah 10 detik.
+func waitForJob(t *testing.T, q *downloadQueue, id int, cond func(queueJob) bool) queueJob {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var last queueJob
+		for _, job := range q.snapshot() {
+			if job.ID == id {
+				last = job
+			}
+		}
+		if cond(last) {
+			return last
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %d tidak mencapai kondisi yang ditunggu; status terakhir %+v", id, last)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQueueRunsJobsWithinConnectionLimit(t *testing.T) {
+	content := randomContent(256*1024, 30)
+	cs, srv := newThrottledQueueServer(t, content, 1024*1024)
+	dir := t.TempDir()
+	q, err := openDownloadQueue(filepath.Join(dir, "queue.json"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+		job, err := q.add(srv.URL+"/"+name, filepath.Join(dir, name), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, job.ID)
+	}
+	q.start(context.Background())
+	for _, id := range ids {
+		job := waitForJob(t, q, id, func(j queueJob) bool { return j.State == jobDone || j.State == jobFailed })
+		if job.State != jobDone {
+			t.Fatalf("job %d gagal: %s", id, job.Error)
+		}
+		assertFileContent(t, job.Output, content)
+	}
+	if err := q.stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.maxInFlight > 2 {
+		t.Errorf("%d GET berjalan bersamaan, batasnya 2", cs.maxInFlight)
+	}
+	if cs.maxInFlight < 2 {
+		t.Errorf("download tidak berjalan paralel (maksimal %d GET bersamaan)", cs.maxInFlight)
+	}
+
+	// Status akhir tersimpan di disk.
+	reopened, err := openDownloadQueue(filepath.Join(dir, "queue.json"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, job := range reopened.snapshot() {
+		if job.State != jobDone || job.Downloaded != int64(len(content)) {
+			t.Errorf("job %d tersimpan sebagai %s dengan %d bytes", job.ID, job.State, job.Downloaded)
+		}
+	}
+}
+
+func TestQueuePauseAndResume(t *testing.T) {
+	content := randomContent(512*1024, 31)
+	_, srv := newThrottledQueueServer(t, content, 256*1024)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "file.bin")
+	q, err := openDownloadQueue(filepath.Join(dir, "queue.json"), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.add(srv.URL, out, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.start(context.Background())
+	defer q.stop()
+
+	waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobRunning && j.Downloaded > 0 })
+	if err := q.pause(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobPaused })
+	if _, err := os.Stat(manifestPath(out)); err != nil {
+		t.Fatalf("manifest harus tetap ada setelah jeda: %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatal("file output tidak boleh ada sebelum download selesai")
+	}
+
+	if err := q.resume(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	done := waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobDone || j.State == jobFailed })
+	if done.State != jobDone {
+		t.Fatalf("job gagal setelah dilanjutkan: %s", done.Error)
+	}
+	assertFileContent(t, out, content)
+}
+
+func TestQueueCancelRemovesJobAndPartialData(t *testing.T) {
+	content := randomContent(512*1024, 32)
+	_, srv := newThrottledQueueServer(t, content, 128*1024)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "file.bin")
+	q, err := openDownloadQueue(filepath.Join(dir, "queue.json"), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.add(srv.URL, out, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.start(context.Background())
+	defer q.stop()
+
+	waitForJob(t, q, job.ID, func(j queueJob) bool { return j.Downloaded > 0 })
+	if err := q.cancel(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitForJob(t, q, job.ID, func(j queueJob) bool { return j.ID == 0 }) // Job sudah hilang dari daftar
+	for _, leftover := range []string{out, tempOutputPath(out), manifestPath(out)} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s tidak dihapus setelah job dibatalkan", leftover)
+		}
+	}
+}
+
+func TestQueueRecoversAndOrdersByPriority(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "queue.json")
+	q, err := openDownloadQueue(path, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"a.bin", "b.bin", "c.bin"} {
+		if _, err := q.add("http://example.com/"+name, "", i%2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := q.add("http://example.com/a.bin", "", 0); err == nil {
+		t.Error("output yang sama dengan job yang belum selesai seharusnya ditolak")
+	}
+	if _, err := q.add("ftp://example.com/x", "", 0); err == nil {
+		t.Error("URL selain http/https seharusnya ditolak")
+	}
+
+	// Simulasikan program yang terhenti saat job 1 berjalan.
+	q.jobs[0].State = jobRunning
+	if err := q.saveLocked(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := openDownloadQueue(path, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := reopened.snapshot()
+	if jobs[0].Output != "b.bin" || jobs[0].Priority != 1 {
+		t.Errorf("job pertama = %+v, ingin b.bin dengan prioritas 1", jobs[0])
+	}
+	if next := reopened.nextQueuedLocked(); next == nil || next.ID != 2 {
+		t.Errorf("job berikutnya = %+v, ingin job 2", next)
+	}
+	if err := reopened.setPriority(3, 5); err != nil {
+		t.Fatal(err)
+	}
+	if next := reopened.nextQueuedLocked(); next.ID != 3 {
+		t.Errorf("setelah prio, job berikutnya = %d, ingin 3", next.ID)
+	}
+	for _, job := range jobs {
+		if job.State != jobQueued {
+			t.Errorf("job %d berstatus %s setelah dibuka ulang, ingin queued", job.ID, job.State)
+		}
+	}
+	if job, _ := reopened.add("http://example.com/d.bin", "", 0); job.ID != 4 {
+		t.Errorf("ID job baru = %d, ingin 4", job.ID)
+	}
+}
@@ -0,0 +1,171 @@
+// mini-projects/downloader-app/ratelimit.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitChunk adalah jumlah byte terbanyak yang dibaca sebelum menunggu token. Potongan
+// kecil membuat kecepatan merata, bukan semburan besar yang diikuti jeda panjang.
+const rateLimitChunk = 16 * 1024
+
+// rateLimiter membatasi kecepatan dengan algoritma token bucket: token (byte) bertambah
+// sebesar 'rate' per detik sampai kapasitas 'burst', dan setiap byte yang dibaca menghabiskan
+// satu token. Satu rateLimiter bisa dipakai bersama oleh banyak bagian dan banyak download,
+// dan batasnya bisa diubah kapan saja dengan setRate, termasuk saat download berjalan.
+// Nilai nil atau rate 0 berarti tanpa batas.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Bytes per detik; 0 = tanpa batas
+	burst   float64
+	tokens  float64
+	last    time.Time
+	changed chan struct{} // Ditutup oleh setRate untuk membangunkan yang sedang menunggu
+}
+
+// newRateLimiter membuat pembatas dengan batas awal bytesPerSec (0 = tanpa batas).
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	l := &rateLimiter{changed: make(chan struct{})}
+	l.setRate(bytesPerSec)
+	return l
+}
+
+// setRate mengubah batas kecepatan. Pembaca yang sedang menunggu langsung memakai batas baru.
+func (l *rateLimiter) setRate(bytesPerSec int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = float64(max(bytesPerSec, 0))
+	// Kapasitas sekitar 100 ms lalu lintas, tetapi minimal satu potongan baca.
+	l.burst = max(l.rate/10, rateLimitChunk)
+	l.tokens = min(l.tokens, l.burst)
+	if l.last.IsZero() {
+		l.tokens = l.burst
+	}
+	l.last = time.Now()
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// limit mengembalikan batas saat ini dalam bytes per detik (0 = tanpa batas).
+func (l *rateLimiter) limit() int64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int64(l.rate)
+}
+
+// wait menunggu sampai ada n token (n tidak lebih dari rateLimitChunk), lalu memakainya.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		if l.rate == 0 {
+			l.mu.Unlock()
+			return nil
+		}
+		now := time.Now()
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= float64(n) {
+			l.tokens -= float64(n)
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((float64(n) - l.tokens) / l.rate * float64(time.Second))
+		changed := l.changed
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-changed: // Batas diubah: hitung ulang dengan batas baru
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// rateLimitedReader membaca dari r dengan kecepatan yang dibatasi semua 'limiters'
+// (misalnya batas global dan batas download ini sekaligus).
+type rateLimitedReader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiters []*rateLimiter
+}
+
+// limitReader membungkus r dengan pembatas kecepatan yang tidak nil. Jika tidak ada, r dikembalikan apa adanya.
+func limitReader(ctx context.Context, r io.Reader, limiters ...*rateLimiter) io.Reader {
+	var active []*rateLimiter
+	for _, l := range limiters {
+		if l != nil {
+			active = append(active, l)
+		}
+	}
+	if len(active) == 0 {
+		return r
+	}
+	return &rateLimitedReader{ctx: ctx, r: r, limiters: active}
+}
+
+func (lr *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > rateLimitChunk {
+		p = p[:rateLimitChunk]
+	}
+	n, err := lr.r.Read(p)
+	// Token diambil setelah data tiba, agar tidak ada token yang terbuang untuk byte yang tidak pernah datang.
+	for _, l := range lr.limiters {
+		if n == 0 {
+			break
+		}
+		if werr := l.wait(lr.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// parseByteRate membaca batas kecepatan seperti "500K", "2MB", "1.5M/s", atau "0" (tanpa batas)
+// menjadi bytes per detik. Satuan memakai kelipatan 1024, sama seperti formatBytes.
+func parseByteRate(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "/S"), "B")
+	multiplier := 1.0
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			s = strings.TrimSpace(s[:len(s)-1])
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("batas kecepatan tidak valid: '%s' (contoh: 500K, 2MB, 0 untuk tanpa batas)", value)
+	}
+	return int64(n * multiplier), nil
+}
+
+// formatRate menampilkan batas kecepatan, misalnya "2.0 MB/s" atau "tanpa batas".
+func formatRate(bytesPerSec int64) string {
+	if bytesPerSec <= 0 {
+		return "tanpa batas"
+	}
+	return formatBytes(bytesPerSec) + "/s"
+}
@@ -0,0 +1,100 @@
+// mini-projects/downloader-app/ratelimit_test.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// assertDuration memastikan download memakan waktu sekitar 'want' (toleransi 25%).
+func assertDuration(t *testing.T, got, want time.Duration) {
+	t.Helper()
+	if got < want*3/4 || got > want*5/4 {
+		t.Errorf("download memakan %v, ingin sekitar %v", got.Round(time.Millisecond), want)
+	}
+}
+
+func TestRateLimitPerDownload(t *testing.T) {
+	content := randomContent(768*1024, 40)
+	_, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 4, ProgressMode: ProgressOff, RateLimit: newRateLimiter(512 * 1024)}
+
+	start := time.Now()
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	assertDuration(t, time.Since(start), 1500*time.Millisecond)
+	assertFileContent(t, out, content)
+}
+
+func TestRateLimitSharedAcrossDownloads(t *testing.T) {
+	content := randomContent(384*1024, 41)
+	_, srv := newTestFileServer(t, content, `"v1"`)
+	dir := t.TempDir()
+	shared := newRateLimiter(512 * 1024)
+
+	// Dua download bersamaan dengan batas global 512 KB/s: total 768 KB butuh sekitar 1,5 detik,
+	// bukan 0,75 detik seperti jika setiap download mendapat batasnya sendiri.
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, name := range []string{"a.bin", "b.bin"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg := downloadConfig{URL: srv.URL, Output: filepath.Join(dir, name), NumParts: 2, ProgressMode: ProgressOff, SharedRateLimit: shared}
+			if _, err := runDownload(context.Background(), cfg); err != nil {
+				t.Errorf("download %s gagal: %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	assertDuration(t, time.Since(start), 1500*time.Millisecond)
+}
+
+func TestRateLimitChangedWhileRunning(t *testing.T) {
+	content := randomContent(512*1024, 42)
+	_, srv := newTestFileServer(t, content, `"v1"`)
+	out := filepath.Join(t.TempDir(), "file.bin")
+	limiter := newRateLimiter(64 * 1024) // Tanpa perubahan butuh 8 detik
+	cfg := downloadConfig{URL: srv.URL, Output: out, NumParts: 2, ProgressMode: ProgressOff, RateLimit: limiter}
+
+	time.AfterFunc(300*time.Millisecond, func() { limiter.setRate(0) })
+	start := time.Now()
+	if _, err := runDownload(context.Background(), cfg); err != nil {
+		t.Fatalf("download gagal: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("download memakan %v; batas baru tidak dipakai saat download berjalan", elapsed)
+	}
+	assertFileContent(t, out, content)
+}
+
+func TestRateLimiterWaitHonorsContext(t *testing.T) {
+	l := newRateLimiter(1024)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	l.wait(ctx, rateLimitChunk) // Menghabiskan token awal
+	if err := l.wait(ctx, rateLimitChunk); err == nil {
+		t.Error("wait seharusnya berhenti saat context dibatalkan")
+	}
+}
+
+func TestParseByteRate(t *testing.T) {
+	tests := map[string]int64{
+		"0": 0, "1500": 1500, "500K": 500 * 1024, "2MB": 2 << 20, "1.5M/s": 3 << 19, "1g": 1 << 30, " 64 kb ": 64 * 1024,
+	}
+	for value, want := range tests {
+		if got, err := parseByteRate(value); err != nil || got != want {
+			t.Errorf("parseByteRate(%q) = %d, %v; ingin %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "cepat", "-1M", "2XB"} {
+		if _, err := parseByteRate(value); err == nil {
+			t.Errorf("parseByteRate(%q) seharusnya error", value)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// mini-projects/downloader-app/retry.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nilai default aturan percobaan ulang setiap bagian.
+const (
+	defaultMaxAttempts    = 5
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+	maxRetryAfter         = 5 * time.Minute // Retry-After yang lebih lama dari ini dianggap tidak wajar
+)
+
+// retryPolicy mengatur berapa kali sebuah bagian dicoba ulang dan berapa lama jedanya.
+// Nilai nol pada field mana pun berarti memakai default.
+type retryPolicy struct {
+	MaxAttempts int           // Jumlah percobaan maksimum per bagian, termasuk percobaan pertama
+	BaseDelay   time.Duration // Jeda dasar backoff eksponensial
+	MaxDelay    time.Duration // Batas atas jeda backoff
+}
+
+func (rp retryPolicy) withDefaults() retryPolicy {
+	if rp.MaxAttempts <= 0 {
+		rp.MaxAttempts = defaultMaxAttempts
+	}
+	if rp.BaseDelay <= 0 {
+		rp.BaseDelay = defaultRetryBaseDelay
+	}
+	if rp.MaxDelay <= 0 {
+		rp.MaxDelay = defaultRetryMaxDelay
+	}
+	return rp
+}
+
+// next memutuskan apakah percobaan ke-'attempt' yang gagal dengan 'err' perlu diulang,
+// dan berapa lama harus menunggu. Jedanya memakai exponential backoff dengan "full jitter"
+// (acak antara 0 dan BaseDelay*2^(attempt-1)) agar semua bagian tidak menyerbu server
+// bersamaan, kecuali server meminta jeda tertentu lewat header Retry-After.
+func (rp retryPolicy) next(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	rp = rp.withDefaults()
+	var re *retryableError
+	if ctx.Err() != nil || attempt >= rp.MaxAttempts || !errors.As(err, &re) {
+		return 0, false
+	}
+	if re.retryAfter > 0 {
+		return re.retryAfter, true
+	}
+	ceiling := rp.MaxDelay
+	if shift := attempt - 1; shift < 30 && rp.BaseDelay<<shift < rp.MaxDelay {
+		ceiling = rp.BaseDelay << shift
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1)), true
+}
+
+// sleepContext menunggu selama d, atau berhenti lebih awal jika ctx dibatalkan.
+// Mengembalikan false jika ctx dibatalkan.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryableError menandai error sementara (jaringan, 5xx, 429) yang layak dicoba lagi.
+type retryableError struct {
+	err        error
+	retryAfter time.Duration // Jeda yang diminta server lewat Retry-After (0 jika tidak ada)
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// diskError menandai kegagalan menulis ke disk lokal. Error ini tidak dicoba ulang.
+type diskError struct {
+	err error
+}
+
+func (e *diskError) Error() string { return e.err.Error() }
+func (e *diskError) Unwrap() error { return e.err }
+
+// statusError membungkus error karena status HTTP. Status 5xx, 429 (Too Many Requests),
+// dan 408 (Request Timeout) dianggap sementara; status lain (misalnya 404) langsung gagal.
+func statusError(resp *http.Response, err error) error {
+	code := resp.StatusCode
+	if code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout {
+		return &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	}
+	return err
+}
+
+// parseRetryAfter membaca header Retry-After, yang bisa berupa jumlah detik ("120")
+// atau tanggal HTTP ("Wed, 21 Oct 2015 07:28:00 GMT").
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		d = t.Sub(now)
+	}
+	return min(max(d, 0), maxRetryAfter)
+}
@@ -0,0 +1,317 @@
+// mini-projects/downloader-app/schedule.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// TimeOfDay adalah jam dalam sehari (waktu lokal), dihitung dari tengah malam. Contoh:
+// TimeOfDay(22 * time.Hour) adalah pukul 22:00. Di JSON ditulis sebagai "22:00".
+type TimeOfDay time.Duration
+
+// timeOfDayOf mengambil jam dinding dari 't' di zona waktunya.
+func timeOfDayOf(t time.Time) TimeOfDay {
+	h, m, s := t.Clock()
+	return TimeOfDay(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(t.Nanosecond()))
+}
+
+// parseTimeOfDay membaca jam "HH:MM" atau "HH:MM:SS". "24:00" sama dengan "00:00".
+func parseTimeOfDay(value string) (TimeOfDay, error) {
+	fields := strings.Split(value, ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, fmt.Errorf("jam tidak valid: '%s' (contoh: 22:00)", value)
+	}
+	var nums [3]int
+	limits := [3]int{24, 59, 59}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 || n > limits[i] {
+			return 0, fmt.Errorf("jam tidak valid: '%s' (contoh: 22:00)", value)
+		}
+		nums[i] = n
+	}
+	if nums[0] == 24 {
+		if nums[1] != 0 || nums[2] != 0 {
+			return 0, fmt.Errorf("jam tidak valid: '%s' (contoh: 22:00)", value)
+		}
+		nums[0] = 0
+	}
+	return TimeOfDay(time.Duration(nums[0])*time.Hour + time.Duration(nums[1])*time.Minute + time.Duration(nums[2])*time.Second), nil
+}
+
+func (t TimeOfDay) clock() (h, m, s int) {
+	d := time.Duration(t)
+	return int(d / time.Hour), int(d % time.Hour / time.Minute), int(d % time.Minute / time.Second)
+}
+
+func (t TimeOfDay) String() string {
+	h, m, s := t.clock()
+	if s != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", h, m)
+}
+
+func (t TimeOfDay) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+
+func (t *TimeOfDay) UnmarshalText(text []byte) error {
+	v, err := parseTimeOfDay(string(text))
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+// next mengembalikan kemunculan berikutnya jam ini setelah 'after' (tidak termasuk 'after').
+func (t TimeOfDay) next(after time.Time) time.Time {
+	h, m, s := t.clock()
+	y, mo, d := after.Date()
+	next := time.Date(y, mo, d, h, m, s, 0, after.Location())
+	if !next.After(after) {
+		next = time.Date(y, mo, d+1, h, m, s, 0, after.Location())
+	}
+	return next
+}
+
+// TimeWindow adalah rentang jam harian saat download boleh berjalan. End boleh lebih kecil
+// dari Start untuk jendela yang melewati tengah malam (misalnya 22:00-06:00); Start sama dengan
+// End berarti sepanjang hari.
+type TimeWindow struct {
+	Start TimeOfDay `json:"start"`
+	End   TimeOfDay `json:"end"`
+	// RateLimit membatasi kecepatan selama jendela ini (bytes/detik, 0 = tanpa batas).
+	RateLimit int64 `json:"rate_limit,omitempty"`
+}
+
+// contains memeriksa apakah 't' berada di dalam jendela (Start inklusif, End eksklusif).
+func (w TimeWindow) contains(t time.Time) bool {
+	tod := timeOfDayOf(t)
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return tod >= w.Start && tod < w.End
+	default:
+		return tod >= w.Start || tod < w.End
+	}
+}
+
+func (w TimeWindow) String() string {
+	s := w.Start.String() + "-" + w.End.String()
+	if w.RateLimit > 0 {
+		s += " @ " + formatRate(w.RateLimit)
+	}
+	return s
+}
+
+// parseTimeWindow membaca jendela seperti "22:00-06:00" atau "22:00-06:00@2MB".
+func parseTimeWindow(spec string) (TimeWindow, error) {
+	rangePart, ratePart, hasRate := strings.Cut(strings.TrimSpace(spec), "@")
+	startStr, endStr, ok := strings.Cut(rangePart, "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("jendela waktu tidak valid: '%s' (contoh: 22:00-06:00 atau 22:00-06:00@2MB)", spec)
+	}
+	var w TimeWindow
+	var err error
+	if w.Start, err = parseTimeOfDay(strings.TrimSpace(startStr)); err != nil {
+		return TimeWindow{}, err
+	}
+	if w.End, err = parseTimeOfDay(strings.TrimSpace(endStr)); err != nil {
+		return TimeWindow{}, err
+	}
+	if hasRate {
+		if w.RateLimit, err = parseByteRate(ratePart); err != nil {
+			return TimeWindow{}, err
+		}
+	}
+	return w, nil
+}
+
+// parseStartTime membaca waktu mulai: "2006-01-02T15:04:05Z07:00", "2006-01-02 15:04", atau
+// jam saja ("22:00", berarti kemunculan berikutnya setelah 'now'). Zona waktu default adalah lokal.
+func parseStartTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if tod, err := parseTimeOfDay(value); err == nil {
+		return tod.next(now), nil
+	}
+	return time.Time{}, fmt.Errorf("waktu mulai tidak valid: '%s' (contoh: 22:00 atau 2006-01-02 22:00)", value)
+}
+
+// Schedule membatasi kapan sebuah download boleh berjalan. Nilai nol berarti kapan saja.
+type Schedule struct {
+	// StartAt menunda download sampai waktu ini.
+	StartAt time.Time `json:"start_at,omitzero"`
+	// Windows adalah jendela waktu harian; kosong berarti sepanjang hari. Di luar semua
+	// jendela, download dihentikan dan dilanjutkan dari manifest saat jendela berikutnya dibuka.
+	Windows []TimeWindow `json:"windows,omitempty"`
+}
+
+// IsZero melaporkan apakah jadwal kosong (download boleh berjalan kapan saja).
+func (s Schedule) IsZero() bool {
+	return s.StartAt.IsZero() && len(s.Windows) == 0
+}
+
+// state mengembalikan apakah download boleh berjalan pada 't' dan batas kecepatan dari
+// jendela yang berlaku (0 = tanpa batas). Jika beberapa jendela tumpang tindih, yang
+// pertama dipakai.
+func (s Schedule) state(t time.Time) (active bool, rate int64) {
+	if t.Before(s.StartAt) {
+		return false, 0
+	}
+	if len(s.Windows) == 0 {
+		return true, 0
+	}
+	for _, w := range s.Windows {
+		if w.contains(t) {
+			return true, w.RateLimit
+		}
+	}
+	return false, 0
+}
+
+// nextChange mengembalikan waktu berikutnya setelah 't' saat hasil state bisa berubah (awal
+// atau akhir jendela, atau StartAt). Waktu nol berarti jadwal tidak akan berubah lagi.
+func (s Schedule) nextChange(t time.Time) time.Time {
+	var next time.Time
+	consider := func(c time.Time) {
+		if c.After(t) && (next.IsZero() || c.Before(next)) {
+			next = c
+		}
+	}
+	consider(s.StartAt)
+	for _, w := range s.Windows {
+		if w.Start != w.End {
+			consider(w.Start.next(t))
+			consider(w.End.next(t))
+		}
+	}
+	return next
+}
+
+// nextOpening mengembalikan waktu berikutnya saat download boleh berjalan lagi setelah 't'.
+// Waktu nol berarti tidak akan pernah.
+func (s Schedule) nextOpening(t time.Time) time.Time {
+	for c := s.nextChange(t); !c.IsZero(); c = s.nextChange(c) {
+		if active, _ := s.state(c); active {
+			return c
+		}
+	}
+	return time.Time{}
+}
+
+func (s Schedule) String() string {
+	var parts []string
+	if !s.StartAt.IsZero() {
+		parts = append(parts, "mulai "+s.StartAt.Format("2006-01-02 15:04"))
+	}
+	for _, w := range s.Windows {
+		parts = append(parts, w.String())
+	}
+	if len(parts) == 0 {
+		return "kapan saja"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseScheduleArgs membaca jadwal dari argumen perintah: "start=<waktu>" untuk waktu mulai,
+// dan satu atau lebih jendela waktu seperti "22:00-06:00@2MB".
+func parseScheduleArgs(args []string, now time.Time) (Schedule, error) {
+	var s Schedule
+	for _, arg := range args {
+		if value, ok := strings.CutPrefix(arg, "start="); ok {
+			t, err := parseStartTime(value, now)
+			if err != nil {
+				return Schedule{}, err
+			}
+			s.StartAt = t
+			continue
+		}
+		w, err := parseTimeWindow(arg)
+		if err != nil {
+			return Schedule{}, err
+		}
+		s.Windows = append(s.Windows, w)
+	}
+	if s.IsZero() {
+		return Schedule{}, errors.New("jadwal kosong")
+	}
+	return s, nil
+}
+
+// runScheduled menjalankan runDownload hanya di dalam jadwal 's'. Sebelum jadwal aktif,
+// download menunggu; saat jendela ditutup, download dihentikan (progres tetap di manifest)
+// lalu dilanjutkan saat jendela berikutnya dibuka. Batas kecepatan jendela dipasang sebagai
+// cfg.RateLimit dan diperbarui di setiap pergantian jendela.
+func runScheduled(ctx context.Context, cfg downloadConfig, s Schedule) (Result, error) {
+	if s.IsZero() {
+		return runDownload(ctx, cfg)
+	}
+	windowRate := newRateLimiter(0)
+	cfg.RateLimit = windowRate
+	for {
+		now := time.Now()
+		active, rate := s.state(now)
+		if !active {
+			next := s.nextOpening(now)
+			if next.IsZero() {
+				return Result{URL: cfg.URL, Output: cfg.Output}, errors.New("jadwal download tidak akan aktif lagi")
+			}
+			cfg.logf("Di luar jadwal download. Menunggu sampai %s...\n", next.Format("2006-01-02 15:04:05"))
+			if !sleepContext(ctx, time.Until(next)) {
+				return Result{URL: cfg.URL, Output: cfg.Output}, ctx.Err()
+			}
+			continue
+		}
+
+		windowRate.setRate(rate)
+		windowCtx, cancel := context.WithCancel(ctx)
+		var closed atomic.Bool
+		done := make(chan struct{})
+		go func() {
+			// Ikuti setiap pergantian jendela: ubah batas kecepatan, atau hentikan download.
+			for {
+				next := s.nextChange(time.Now())
+				if next.IsZero() {
+					return
+				}
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-timer.C:
+				case <-done:
+					timer.Stop()
+					return
+				}
+				active, rate := s.state(time.Now())
+				if !active {
+					closed.Store(true)
+					cancel()
+					return
+				}
+				windowRate.setRate(rate)
+			}
+		}()
+		res, err := runDownload(windowCtx, cfg)
+		close(done)
+		cancel()
+		if err != nil && closed.Load() && ctx.Err() == nil {
+			cfg.logf("\nJendela waktu download ditutup. Progres disimpan dan dilanjutkan di jendela berikutnya.\n")
+			continue
+		}
+		return res, err
+	}
+}
@@ -0,0 +1,189 @@
+// mini-projects/downloader-app/schedule_test.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduleWindows(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 10, h, m, 0, 0, time.UTC) }
+	night := TimeWindow{Start: TimeOfDay(22 * time.Hour), End: TimeOfDay(6 * time.Hour), RateLimit: 2 << 20}
+	lunch := TimeWindow{Start: TimeOfDay(12 * time.Hour), End: TimeOfDay(13 * time.Hour)}
+	s := Schedule{Windows: []TimeWindow{night, lunch}}
+
+	tests := []struct {
+		now        time.Time
+		active     bool
+		rate       int64
+		nextChange time.Time
+	}{
+		{at(23, 0), true, 2 << 20, at(30, 0)}, // Jendela melewati tengah malam
+		{at(5, 59), true, 2 << 20, at(6, 0)},
+		{at(6, 0), false, 0, at(12, 0)}, // Akhir jendela tidak termasuk
+		{at(12, 30), true, 0, at(13, 0)},
+		{at(18, 0), false, 0, at(22, 0)},
+	}
+	for _, tt := range tests {
+		active, rate := s.state(tt.now)
+		if active != tt.active || rate != tt.rate {
+			t.Errorf("state(%s) = %v, %d; ingin %v, %d", tt.now.Format("15:04"), active, rate, tt.active, tt.rate)
+		}
+		if next := s.nextChange(tt.now); !next.Equal(tt.nextChange) {
+			t.Errorf("nextChange(%s) = %s; ingin %s", tt.now.Format("15:04"), next, tt.nextChange)
+		}
+	}
+
+	// StartAt menunda jadwal, termasuk jendela yang sedang terbuka.
+	delayed := Schedule{StartAt: at(23, 30), Windows: []TimeWindow{night}}
+	if active, _ := delayed.state(at(23, 0)); active {
+		t.Error("jadwal seharusnya belum aktif sebelum StartAt")
+	}
+	if next := delayed.nextOpening(at(23, 0)); !next.Equal(at(23, 30)) {
+		t.Errorf("nextOpening = %s; ingin StartAt", next)
+	}
+	if next := s.nextOpening(at(18, 0)); !next.Equal(at(22, 0)) {
+		t.Errorf("nextOpening(18:00) = %s; ingin 22:00", next)
+	}
+
+	// Jadwal tersimpan di file antrean sebagai jam yang mudah dibaca.
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Schedule
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("gagal membaca %s: %v", data, err)
+	}
+	if len(decoded.Windows) != 2 || decoded.Windows[0] != night || decoded.Windows[1] != lunch {
+		t.Errorf("jadwal setelah JSON = %+v (%s)", decoded, data)
+	}
+}
+
+func TestParseScheduleArgs(t *testing.T) {
+	now := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+	s, err := parseScheduleArgs([]string{"start=22:30", "22:00-06:00@2MB", "12:00-13:30:15"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 11, 22, 30, 0, 0, time.UTC); !s.StartAt.Equal(want) {
+		t.Errorf("StartAt = %s; ingin kemunculan berikutnya %s", s.StartAt, want)
+	}
+	want := []TimeWindow{
+		{Start: TimeOfDay(22 * time.Hour), End: TimeOfDay(6 * time.Hour), RateLimit: 2 * 1024 * 1024},
+		{Start: TimeOfDay(12 * time.Hour), End: TimeOfDay(13*time.Hour + 30*time.Minute + 15*time.Second)},
+	}
+	if len(s.Windows) != 2 || s.Windows[0] != want[0] || s.Windows[1] != want[1] {
+		t.Errorf("Windows = %+v; ingin %+v", s.Windows, want)
+	}
+	if got := s.Windows[1].String(); got != "12:00-13:30:15" {
+		t.Errorf("String() = %q", got)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"22:00"},
+		{"25:00-06:00"},
+		{"22:00-06:61"},
+		{"22:00-06:00@lambat"},
+		{"start=besok"},
+	} {
+		if _, err := parseScheduleArgs(args, now); err == nil {
+			t.Errorf("%q seharusnya ditolak", args)
+		}
+	}
+}
+
+// windowBetween membuat jendela harian dari jam dinding 'from' sampai 'to'.
+func windowBetween(from, to time.Time) TimeWindow {
+	return TimeWindow{Start: timeOfDayOf(from.Truncate(time.Second)), End: timeOfDayOf(to.Truncate(time.Second))}
+}
+
+func TestDownloaderStopsAndResumesWithWindow(t *testing.T) {
+	content := randomContent(1024*1024, 48)
+	_, srv := newThrottledQueueServer(t, content, 192*1024)
+	out := filepath.Join(t.TempDir(), "file.bin")
+
+	// Jendela pertama ditutup di tengah download, jendela kedua dibuka sedetik kemudian.
+	start := time.Now()
+	closeAt := start.Truncate(time.Second).Add(2 * time.Second)
+	reopenAt := closeAt.Add(time.Second)
+	d, err := NewDownloader(Options{
+		Output: out, Parts: 2, ProgressMode: ProgressOff, Log: io.Discard,
+		Schedule: Schedule{Windows: []TimeWindow{
+			windowBetween(start.Add(-time.Hour), closeAt),
+			windowBetween(reopenAt, reopenAt.Add(time.Hour)),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := d.Download(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, out, content)
+	if !res.Resumed {
+		t.Error("download seharusnya dilanjutkan dari manifest setelah jendela dibuka lagi")
+	}
+	if elapsed := time.Since(start); elapsed < reopenAt.Sub(start) {
+		t.Errorf("download selesai setelah %s, sebelum jendela kedua dibuka", elapsed)
+	}
+}
+
+func TestQueueJobFollowsSchedule(t *testing.T) {
+	content := randomContent(1024*1024, 49)
+	_, srv := newThrottledQueueServer(t, content, 64*1024)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "file.bin")
+	q, err := openDownloadQueue(filepath.Join(dir, "queue.json"), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.add(srv.URL, out, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Jendela yang baru dibuka dua detik lagi: job menunggu.
+	now := time.Now()
+	opensAt := now.Truncate(time.Second).Add(2 * time.Second)
+	if err := q.setSchedule(job.ID, &Schedule{Windows: []TimeWindow{windowBetween(opensAt, opensAt.Add(time.Hour))}}); err != nil {
+		t.Fatal(err)
+	}
+	q.start(context.Background())
+	defer q.stop()
+	waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobScheduled })
+
+	// Job berjalan saat jendela dibuka, lalu dihentikan saat jendela ditutup.
+	waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobRunning })
+	if time.Now().Before(opensAt) {
+		t.Error("job berjalan sebelum jendelanya dibuka")
+	}
+	closeAt := time.Now().Truncate(time.Second).Add(2 * time.Second)
+	if err := q.setSchedule(job.ID, &Schedule{Windows: []TimeWindow{windowBetween(opensAt, closeAt)}}); err != nil {
+		t.Fatal(err)
+	}
+	stopped := waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobScheduled })
+	if stopped.Downloaded == 0 || stopped.Downloaded >= int64(len(content)) {
+		t.Errorf("job dihentikan dengan %d dari %d bytes", stopped.Downloaded, len(content))
+	}
+	if _, err := os.Stat(manifestPath(out)); err != nil {
+		t.Fatalf("manifest harus tetap ada setelah jendela ditutup: %v", err)
+	}
+
+	// Tanpa jadwal, job dilanjutkan dari manifest sampai selesai.
+	if err := q.setSchedule(job.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	done := waitForJob(t, q, job.ID, func(j queueJob) bool { return j.State == jobDone || j.State == jobFailed })
+	if done.State != jobDone {
+		t.Fatalf("job gagal: %s", done.Error)
+	}
+	assertFileContent(t, out, content)
+}
@@ -0,0 +1,160 @@
+// mini-projects/downloader-app/segments.go
+package parallel_downloader_app
+
+import (
+	"errors"
+	"fmt"
+)
+
+// minSegmentSize adalah ukuran terkecil segmen hasil pemecahan. Segmen yang sedang diunduh
+// hanya dipecah jika sisanya minimal dua kali nilai ini, agar tidak membuang request HTTP
+// untuk potongan yang terlalu kecil.
+var minSegmentSize int64 = 256 * 1024
+
+// errSegmentShrunk dikembalikan partWriter jika ujung segmennya sudah diambil alih oleh
+// worker lain (work stealing). Ini bukan kegagalan: bagian segmen yang tersisa sudah selesai.
+var errSegmentShrunk = errors.New("segmen sudah diambil alih worker lain")
+
+// Penjadwalan segmen (work stealing)
+//
+// File tidak lagi dibagi sekali di awal lalu ditunggu sampai bagian paling lambat selesai.
+// Ada N worker (satu koneksi masing-masing). Setiap worker mengambil segmen yang belum
+// dikerjakan; jika tidak ada lagi, worker "mencuri" separuh belakang dari segmen aktif yang
+// sisanya paling besar. Segmen asal dipendekkan (End dimajukan), dan penulisnya berhenti
+// begitu mencapai ujung baru. Dengan begitu semua koneksi selesai hampir bersamaan.
+// Semua status segmen dijaga oleh mutex manifestTracker dan ikut tersimpan di manifest.
+
+// claim memberikan segmen berikutnya untuk seorang worker: segmen yang belum selesai dan
+// belum dikerjakan, atau hasil pemecahan segmen aktif terbesar. Mengembalikan false jika
+// tidak ada lagi pekerjaan yang layak diambil.
+func (t *manifestTracker) claim() (partState, bool) {
+	t.mu.Lock()
+	part, ok, victim := t.claimLocked()
+	t.mu.Unlock()
+	// Dicetak setelah mutex dilepas: logf mengunci pelapor progres, yang juga membaca tracker.
+	if ok && victim >= 0 {
+		t.printf("[Bagian %d] Mengambil alih byte %d sampai %d dari bagian %d.\n", part.Index, part.Start, part.End, victim)
+	}
+	return part, ok
+}
+
+// claimLocked adalah isi claim; 'victim' adalah segmen yang dipecah, atau -1.
+func (t *manifestTracker) claimLocked() (part partState, ok bool, victim int) {
+	if t.active == nil {
+		t.active = make(map[int]bool)
+		t.reserved = make(map[int]int64)
+	}
+
+	for i, p := range t.m.Parts {
+		if !t.active[i] && p.remaining() > 0 {
+			t.active[i] = true
+			return p, true, -1
+		}
+	}
+
+	// Tidak ada segmen menganggur: cari segmen aktif dengan sisa terbesar.
+	victim, victimLeft := -1, int64(0)
+	for i, p := range t.m.Parts {
+		if left := p.remaining() - t.reserved[i]; t.active[i] && left > victimLeft {
+			victim, victimLeft = i, left
+		}
+	}
+	if victim < 0 || victimLeft < 2*minSegmentSize {
+		return partState{}, false, -1
+	}
+
+	old := &t.m.Parts[victim]
+	next := old.Start + old.Written + t.reserved[victim] // Byte pertama yang belum "dipesan" penulis
+	mid := next + victimLeft/2
+	stolen := partState{Index: len(t.m.Parts), Start: mid, End: old.End}
+	if t.m.Mode == writeModePartFiles {
+		stolen.File = fmt.Sprintf("%s.part%d", t.m.Output, stolen.Index)
+	}
+	old.End = mid - 1
+	t.m.Parts = append(t.m.Parts, stolen)
+	t.active[stolen.Index] = true
+	t.dirty = true
+	return stolen, true, victim
+}
+
+// release menandai segmen ke-index tidak lagi dikerjakan worker mana pun.
+func (t *manifestTracker) release(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.active, index)
+	delete(t.reserved, index)
+}
+
+// segment mengembalikan status terbaru segmen ke-index (End bisa sudah dipendekkan).
+func (t *manifestTracker) segment(index int) partState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.m.Parts[index]
+}
+
+// reserve memesan hingga n byte berikutnya untuk ditulis ke segmen ke-index, dibatasi ujung
+// segmen saat ini. Byte yang dipesan tidak akan dicuri worker lain sampai commit dipanggil.
+func (t *manifestTracker) reserve(index int, n int64) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	allowed := max(min(n, t.m.Parts[index].remaining()), 0)
+	if t.reserved == nil {
+		t.reserved = make(map[int]int64)
+	}
+	t.reserved[index] = allowed
+	return allowed
+}
+
+// commit mencatat n byte yang benar-benar tertulis dan melepas pesanan reserve.
+func (t *manifestTracker) commit(index int, n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.m.Parts[index].Written += n
+	delete(t.reserved, index)
+	t.dirty = true
+}
+
+// segmentAt mengembalikan segmen yang memuat offset 'off' di file akhir.
+func (t *manifestTracker) segmentAt(off int64) (partState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, p := range t.m.Parts {
+		if off >= p.Start && off <= p.End {
+			return p, true
+		}
+	}
+	return partState{}, false
+}
+
+// availableAt mengembalikan jumlah byte berurutan mulai dari 'off' yang sudah tertulis di disk.
+func (t *manifestTracker) availableAt(off int64) int64 {
+	p, ok := t.segmentAt(off)
+	if !ok {
+		return 0
+	}
+	return p.Start + p.Written - off
+}
+
+// printf mencetak pesan lewat t.logf (pelapor progres) jika ada, atau langsung ke stdout.
+func (t *manifestTracker) printf(format string, args ...any) {
+	if t.logf != nil {
+		t.logf(format, args...)
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// progress mengembalikan total byte yang sudah diunduh dan progres setiap segmen.
+func (t *manifestTracker) progress() (int64, []PartProgress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var total int64
+	parts := make([]PartProgress, 0, len(t.m.Parts))
+	for i, p := range t.m.Parts {
+		total += p.Written
+		parts = append(parts, PartProgress{
+			Index: p.Index, Start: p.Start, End: p.End, Downloaded: p.Written, Active: t.active[i],
+		})
+	}
+	return total, parts
+}
@@ -0,0 +1,437 @@
+// mini-projects/downloader-app/serve.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Jenis gangguan yang bisa disuntikkan server file lokal ke sebuah GET.
+const (
+	FailReset  = "reset"  // Koneksi diputus setelah FailAfter byte body
+	FailStall  = "stall"  // Setelah FailAfter byte, server diam sampai klien memutus koneksi
+	FailStatus = "status" // Dibalas dengan status error (default 503) tanpa data
+)
+
+// ServeOptions mengatur server file lokal. Semua field opsional; nilai nol berarti tanpa
+// jeda, tanpa batas kecepatan, dan tanpa gangguan.
+type ServeOptions struct {
+	// Latency adalah jeda sebelum setiap respons (termasuk HEAD), seperti jaringan yang jauh.
+	Latency time.Duration
+	// ConnRate membatasi kecepatan setiap respons; TotalRate membatasi semua respons bersama
+	// (bytes/detik, 0 = tanpa batas).
+	ConnRate  int64
+	TotalRate int64
+	// FailRate adalah peluang (0 sampai 1) sebuah GET file diberi gangguan FailMode.
+	FailRate float64
+	// FailMode adalah FailReset (default), FailStall, atau FailStatus.
+	FailMode string
+	// FailAfter adalah jumlah byte body yang dikirim sebelum FailReset atau FailStall terjadi.
+	FailAfter int64
+	// FailStatusCode adalah status untuk FailStatus (default 503).
+	FailStatusCode int
+	// Seed mengatur urutan gangguan agar skenario bisa diulang (0 = acak setiap kali).
+	Seed int64
+	// Log menerima satu baris per request; nil = tanpa log.
+	Log io.Writer
+}
+
+// FileServer menyajikan isi sebuah direktori lewat HTTP dengan dukungan penuh Range,
+// If-Range, dan ETag (lewat http.ServeContent), untuk menguji downloader tanpa internet atau
+// berbagi file di jaringan lokal. Path di luar direktori (termasuk lewat symlink) ditolak,
+// begitu juga file dan direktori tersembunyi (.git, .env, ...), yang juga tidak ditampilkan
+// di daftar isi direktori.
+type FileServer struct {
+	root  *os.Root
+	opts  ServeOptions
+	total *rateLimiter
+
+	mu  sync.Mutex // Melindungi rng dan penulisan ke opts.Log
+	rng *rand.Rand
+}
+
+// NewFileServer membuat FileServer untuk direktori 'dir'. Tutup dengan Close setelah selesai.
+func NewFileServer(dir string, opts ServeOptions) (*FileServer, error) {
+	switch opts.FailMode {
+	case "":
+		opts.FailMode = FailReset
+	case FailReset, FailStall, FailStatus:
+	default:
+		return nil, fmt.Errorf("jenis gangguan tidak dikenal: '%s' (pilih %s, %s, atau %s)", opts.FailMode, FailReset, FailStall, FailStatus)
+	}
+	if opts.FailRate < 0 || opts.FailRate > 1 {
+		return nil, fmt.Errorf("peluang gangguan harus antara 0 dan 1, bukan %v", opts.FailRate)
+	}
+	if opts.FailStatusCode == 0 {
+		opts.FailStatusCode = http.StatusServiceUnavailable
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka direktori %s: %v", dir, err)
+	}
+	s := &FileServer{root: root, opts: opts, rng: rand.New(rand.NewSource(opts.Seed))}
+	if opts.TotalRate > 0 {
+		s.total = newRateLimiter(opts.TotalRate)
+	}
+	return s, nil
+}
+
+// Close menutup direktori yang disajikan.
+func (s *FileServer) Close() error {
+	return s.root.Close()
+}
+
+// fileETag membuat ETag kuat dari ukuran dan waktu perubahan file. ETag harus kuat agar
+// If-Range dari downloader berlaku.
+func fileETag(st os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, st.Size(), st.ModTime().UnixNano())
+}
+
+func (s *FileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &recordingWriter{ResponseWriter: w}
+	fault := ""
+	started := time.Now()
+	// Dicatat lewat defer agar koneksi yang sengaja diputus (panic ErrAbortHandler) tetap tercatat.
+	defer func() {
+		if s.opts.Log == nil {
+			return
+		}
+		line := fmt.Sprintf("%s %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+		if rng := r.Header.Get("Range"); rng != "" {
+			line += " [" + rng + "]"
+		}
+		line += fmt.Sprintf(" -> %d, %s, %v", rec.statusCode(), formatBytes(rec.written), time.Since(started).Round(time.Millisecond))
+		if fault != "" {
+			line += " (gangguan: " + fault + ")"
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		fmt.Fprintf(s.opts.Log, "%s %s\n", time.Now().Format("15:04:05"), line)
+	}()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		rec.Header().Set("Allow", "GET, HEAD")
+		http.Error(rec, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if s.opts.Latency > 0 {
+		select {
+		case <-time.After(s.opts.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+	if isHiddenPath(name) {
+		http.NotFound(rec, r)
+		return
+	}
+	f, err := s.root.Open(name)
+	if err != nil {
+		http.NotFound(rec, r) // Termasuk path yang keluar dari direktori
+		return
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		http.NotFound(rec, r)
+		return
+	}
+	if st.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(rec, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		s.serveListing(rec, r, f)
+		return
+	}
+	if !st.Mode().IsRegular() {
+		http.NotFound(rec, r)
+		return
+	}
+
+	var out http.ResponseWriter = rec
+	if r.Method == http.MethodGet {
+		if fault = s.pickFault(); fault == FailStatus {
+			http.Error(rec, http.StatusText(s.opts.FailStatusCode), s.opts.FailStatusCode)
+			return
+		}
+		limiters := []*rateLimiter{s.total}
+		if s.opts.ConnRate > 0 {
+			limiters = append(limiters, newRateLimiter(s.opts.ConnRate))
+		}
+		out = &faultWriter{ResponseWriter: rec, ctx: r.Context(), mode: fault, remaining: s.opts.FailAfter, limiters: limiters}
+	}
+	out.Header().Set("ETag", fileETag(st))
+	http.ServeContent(out, r, st.Name(), st.ModTime(), f)
+}
+
+// isHiddenPath melaporkan apakah salah satu elemen path (relatif terhadap direktori yang
+// disajikan) diawali titik, misalnya ".git/config" atau "data/.env".
+func isHiddenPath(name string) bool {
+	for _, elem := range strings.Split(name, "/") {
+		if elem != "." && strings.HasPrefix(elem, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// pickFault memilih gangguan untuk sebuah GET sesuai FailRate; string kosong berarti normal.
+func (s *FileServer) pickFault() string {
+	if s.opts.FailRate == 0 {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rng.Float64() < s.opts.FailRate {
+		return s.opts.FailMode
+	}
+	return ""
+}
+
+// serveListing menampilkan daftar isi direktori sebagai HTML sederhana.
+func (s *FileServer) serveListing(w http.ResponseWriter, r *http.Request, dir *os.File) {
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		http.Error(w, "gagal membaca direktori", http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+	fmt.Fprintf(w, "<!doctype html>\n<title>%s</title>\n<h1>%s</h1>\n<ul>\n", html.EscapeString(r.URL.Path), html.EscapeString(r.URL.Path))
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue // Tidak bisa diunduh juga (lihat isHiddenPath)
+		}
+		name, size := e.Name(), ""
+		if e.IsDir() {
+			name += "/"
+		} else if info, err := e.Info(); err == nil {
+			size = " (" + formatBytes(info.Size()) + ")"
+		}
+		link := (&url.URL{Path: name}).String()
+		fmt.Fprintf(w, "<li><a href=\"%s\">%s</a>%s</li>\n", html.EscapeString(link), html.EscapeString(name), size)
+	}
+	fmt.Fprintln(w, "</ul>")
+}
+
+// recordingWriter mencatat status dan jumlah byte body yang terkirim untuk log.
+type recordingWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(p)
+	rw.written += int64(n)
+	return n, err
+}
+
+func (rw *recordingWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *recordingWriter) statusCode() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// faultWriter mengirim body dengan batas kecepatan dan, jika 'mode' diisi, memutus koneksi
+// (FailReset) atau berhenti mengirim (FailStall) setelah 'remaining' byte.
+type faultWriter struct {
+	http.ResponseWriter
+	ctx       context.Context
+	mode      string
+	remaining int64
+	limiters  []*rateLimiter
+}
+
+func (fw *faultWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), rateLimitChunk)]
+		if fw.mode != "" {
+			if fw.remaining <= 0 {
+				fw.fail()
+			}
+			chunk = chunk[:min(int64(len(chunk)), fw.remaining)]
+		}
+		for _, l := range fw.limiters {
+			if err := l.wait(fw.ctx, len(chunk)); err != nil {
+				return written, err
+			}
+		}
+		n, err := fw.ResponseWriter.Write(chunk)
+		written += n
+		fw.remaining -= int64(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// fail menjalankan gangguan setelah semua byte yang diizinkan terkirim. Fungsi ini tidak kembali.
+func (fw *faultWriter) fail() {
+	if f, ok := fw.ResponseWriter.(http.Flusher); ok {
+		f.Flush() // Pastikan klien menerima data sebelum gangguan
+	}
+	if fw.mode == FailStall {
+		<-fw.ctx.Done()
+	}
+	// http.Server memutus koneksi tanpa mencatat stack trace untuk panic ini.
+	panic(http.ErrAbortHandler)
+}
+
+// RunServeCommand menjalankan server file lokal sampai Ctrl+C atau SIGTERM:
+//
+//	mini-projects serve [-addr 127.0.0.1:8080] [-latency 200ms] [-rate 1M] [-fail-rate 0.2] [DIR]
+//
+// Direktori default adalah direktori kerja. Secara default server hanya bisa diakses dari
+// komputer ini; -addr :8080 membukanya untuk jaringan lokal. Nilai yang dikembalikan adalah
+// exit code: 0 setelah server berhenti, 1 jika server gagal dijalankan, dan 2 jika argumen salah.
+func RunServeCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var opts ServeOptions
+	addr := flags.String("addr", "127.0.0.1:8080", "alamat dan port yang didengarkan (:8080 untuk berbagi di jaringan lokal)")
+	flags.DurationVar(&opts.Latency, "latency", 0, "jeda sebelum setiap respons")
+	connRate := flags.String("rate", "", "batas kecepatan per koneksi, misalnya 500K")
+	totalRate := flags.String("total-rate", "", "batas kecepatan semua koneksi bersama")
+	flags.Float64Var(&opts.FailRate, "fail-rate", 0, "peluang (0-1) sebuah GET diberi gangguan")
+	flags.StringVar(&opts.FailMode, "fail-mode", FailReset, "jenis gangguan: reset, stall, atau status")
+	failAfter := flags.String("fail-after", "0", "byte yang dikirim sebelum koneksi diputus atau macet, misalnya 64K")
+	flags.IntVar(&opts.FailStatusCode, "fail-status", http.StatusServiceUnavailable, "status HTTP untuk -fail-mode status")
+	flags.Int64Var(&opts.Seed, "seed", 0, "seed urutan gangguan agar bisa diulang (0 = acak)")
+	quiet := flags.Bool("quiet", false, "jangan catat setiap request")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Penggunaan: mini-projects serve [flag] [DIR]")
+		flags.PrintDefaults()
+	}
+	dirs, err := parseInterspersed(flags, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	usageError := func(format string, args ...any) int {
+		fmt.Fprintf(stderr, "Error: "+format+"\n", args...)
+		flags.Usage()
+		return exitUsage
+	}
+	dir := "."
+	switch len(dirs) {
+	case 0:
+	case 1:
+		dir = dirs[0]
+	default:
+		return usageError("hanya satu direktori yang bisa disajikan")
+	}
+	for _, v := range []struct {
+		name, value string
+		dst         *int64
+	}{{"rate", *connRate, &opts.ConnRate}, {"total-rate", *totalRate, &opts.TotalRate}, {"fail-after", *failAfter, &opts.FailAfter}} {
+		if v.value == "" {
+			continue
+		}
+		n, err := parseByteRate(v.value)
+		if err != nil {
+			return usageError("nilai -%s tidak valid: '%s' (contoh: 64K atau 2MB)", v.name, v.value)
+		}
+		*v.dst = n
+	}
+	if !*quiet {
+		opts.Log = stdout
+	}
+	fsrv, err := NewFileServer(dir, opts)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	defer fsrv.Close()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: gagal mendengarkan %s: %v\n", *addr, err)
+		return exitFailure
+	}
+	fmt.Fprintf(stdout, "Menyajikan %s di %s\n", dir, serveURLs(ln.Addr()))
+	if opts.FailRate > 0 {
+		fmt.Fprintf(stdout, "Gangguan '%s' pada %.0f%% GET (seed %d).\n", opts.FailMode, opts.FailRate*100, fsrv.opts.Seed)
+	}
+	fmt.Fprintln(stdout, "Tekan Ctrl+C untuk berhenti.")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{Handler: fsrv, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	fmt.Fprintln(stdout, "Server berhenti.")
+	return exitOK
+}
+
+// serveURLs mengembalikan URL server untuk ditampilkan. Jika server mendengarkan di semua
+// alamat, alamat IPv4 jaringan lokal ikut ditampilkan agar mudah dibagikan.
+func serveURLs(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok || !tcp.IP.IsUnspecified() {
+		return "http://" + addr.String() + "/"
+	}
+	urls := []string{fmt.Sprintf("http://localhost:%d/", tcp.Port)}
+	if ifaceAddrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range ifaceAddrs {
+			if ipnet, ok := a.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+				urls = append(urls, fmt.Sprintf("http://%s:%d/", ipnet.IP, tcp.Port))
+			}
+		}
+	}
+	return strings.Join(urls, ", ")
+}
@@ -0,0 +1,200 @@
+// mini-projects/downloader-app/serve_test.go
+package parallel_downloader_app
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newServeTest menyajikan 'content' sebagai file.bin dari direktori sementara lewat FileServer.
+func newServeTest(t *testing.T, content []byte, opts ServeOptions) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file.bin"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	fsrv, err := NewFileServer(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(fsrv)
+	t.Cleanup(func() {
+		srv.Close()
+		fsrv.Close()
+	})
+	return srv
+}
+
+func serveGet(t *testing.T, url string, header http.Header) (*http.Response, []byte) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, body
+}
+
+func TestFileServerRangeAndETag(t *testing.T) {
+	content := randomContent(64*1024, 110)
+	srv := newServeTest(t, content, ServeOptions{})
+
+	resp, body := serveGet(t, srv.URL+"/file.bin", nil)
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != 200 || string(body) != string(content) || resp.Header.Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("GET penuh: status %d, %d bytes, Accept-Ranges %q", resp.StatusCode, len(body), resp.Header.Get("Accept-Ranges"))
+	}
+	if !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("ETag kuat tidak dikirim: %q", etag)
+	}
+
+	resp, body = serveGet(t, srv.URL+"/file.bin", http.Header{"Range": {"bytes=100-199"}, "If-Range": {etag}})
+	if resp.StatusCode != http.StatusPartialContent || string(body) != string(content[100:200]) {
+		t.Errorf("Range dengan If-Range yang cocok: status %d, %d bytes", resp.StatusCode, len(body))
+	}
+	resp, body = serveGet(t, srv.URL+"/file.bin", http.Header{"Range": {"bytes=100-199"}, "If-Range": {`"lama"`}})
+	if resp.StatusCode != http.StatusOK || len(body) != len(content) {
+		t.Errorf("If-Range yang tidak cocok harus mengirim file utuh: status %d, %d bytes", resp.StatusCode, len(body))
+	}
+	if resp, _ := serveGet(t, srv.URL+"/file.bin", http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("If-None-Match: status %d, ingin 304", resp.StatusCode)
+	}
+
+	for _, p := range []string{"/../../etc/passwd", "/%2e%2e/%2e%2e/etc/passwd", "/tidak-ada.bin"} {
+		if resp, _ := serveGet(t, srv.URL+p, nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: status %d, ingin 404", p, resp.StatusCode)
+		}
+	}
+	if resp, body := serveGet(t, srv.URL+"/", nil); resp.StatusCode != 200 || !strings.Contains(string(body), `href="file.bin"`) {
+		t.Errorf("daftar direktori: status %d\n%s", resp.StatusCode, body)
+	}
+}
+
+func TestFileServerOutsideSymlinkRejected(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "rahasia.txt")
+	if err := os.WriteFile(outside, []byte("rahasia"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "tautan.txt")); err != nil {
+		t.Skipf("symlink tidak didukung: %v", err)
+	}
+	fsrv, err := NewFileServer(dir, ServeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsrv.Close()
+	rec := httptest.NewRecorder()
+	fsrv.ServeHTTP(rec, httptest.NewRequest("GET", "/tautan.txt", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("symlink ke luar direktori: status %d, ingin 404", rec.Code)
+	}
+}
+
+func TestFileServerHidesDotfiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		".env":             "RAHASIA=1",
+		".git/config":      "[core]",
+		"data/.token":      "abc",
+		"data/laporan.txt": "isi",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsrv, err := NewFileServer(dir, ServeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsrv.Close()
+	get := func(p string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		fsrv.ServeHTTP(rec, httptest.NewRequest("GET", p, nil))
+		return rec
+	}
+
+	for _, p := range []string{"/.env", "/.git/config", "/.git/", "/data/.token", "/data/../.env"} {
+		if rec := get(p); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, ingin 404", p, rec.Code)
+		}
+	}
+	if rec := get("/data/laporan.txt"); rec.Code != http.StatusOK {
+		t.Errorf("file biasa: status %d, ingin 200", rec.Code)
+	}
+	for _, p := range []string{"/", "/data/"} {
+		rec := get(p)
+		if body := rec.Body.String(); rec.Code != http.StatusOK || strings.Contains(body, ">.") {
+			t.Errorf("daftar %s menampilkan file tersembunyi (status %d):\n%s", p, rec.Code, body)
+		}
+	}
+}
+
+func TestFileServerFaultsAreSurvivedByDownloader(t *testing.T) {
+	content := randomContent(1024*1024, 111)
+	for _, mode := range []string{FailReset, FailStatus, FailStall} {
+		var log bytes.Buffer
+		srv := newServeTest(t, content, ServeOptions{FailRate: 0.5, FailMode: mode, FailAfter: 32 * 1024, Seed: 7, Log: &log})
+		out := filepath.Join(t.TempDir(), "file.bin")
+		cfg := downloadConfig{
+			URL: srv.URL + "/file.bin", Output: out, NumParts: 4, Checksum: sha256Hex(content),
+			Retry: retryPolicy{MaxAttempts: 10, BaseDelay: time.Millisecond}, StallTimeout: 200 * time.Millisecond,
+			ProgressMode: ProgressOff, Log: io.Discard,
+		}
+		if _, err := runDownload(context.Background(), cfg); err != nil {
+			t.Fatalf("%s: download gagal: %v", mode, err)
+		}
+		assertFileContent(t, out, content)
+		srv.Close() // Menunggu semua handler selesai sebelum log dibaca
+		if !strings.Contains(log.String(), "(gangguan: "+mode+")") {
+			t.Errorf("%s: tidak ada request yang diberi gangguan:\n%s", mode, log.String())
+		}
+	}
+}
+
+func TestFileServerRateLimitAndLatency(t *testing.T) {
+	content := randomContent(96*1024, 112)
+	srv := newServeTest(t, content, ServeOptions{ConnRate: 128 * 1024, Latency: 100 * time.Millisecond})
+	start := time.Now()
+	resp, body := serveGet(t, srv.URL+"/file.bin", nil)
+	elapsed := time.Since(start)
+	if resp.StatusCode != 200 || len(body) != len(content) {
+		t.Fatalf("status %d, %d bytes", resp.StatusCode, len(body))
+	}
+	// 100 ms jeda ditambah 80 KB di luar burst awal (16 KB) pada 128 KB/s (sekitar 600 ms).
+	if elapsed < 500*time.Millisecond {
+		t.Errorf("respons selesai dalam %v; batas kecepatan atau jeda tidak berlaku", elapsed)
+	}
+}
+
+func TestServeCommandValidatesFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-fail-mode", "meledak"},
+		{"-fail-rate", "2"},
+		{"-rate", "cepat"},
+		{"a", "b"},
+		{filepath.Join(t.TempDir(), "tidak-ada")},
+	} {
+		var stdout, stderr strings.Builder
+		if code := RunServeCommand(args, &stdout, &stderr); code != exitUsage || stderr.Len() == 0 {
+			t.Errorf("%q: exit code = %d, ingin %d dengan pesan di stderr", args, code, exitUsage)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// mini-projects/downloader-app/singlestream.go
+package parallel_downloader_app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errRangesUnsupported dikembalikan jika server membalas Range request dengan seluruh file
+// (status 200) padahal file tidak berubah. Download lalu diulang dengan satu koneksi.
+var errRangesUnsupported = errors.New("server mengabaikan header Range")
+
+// errBadContentRange dikembalikan jika respons 206 berisi rentang yang berbeda dari yang diminta.
+// Menulis data seperti itu ke offset bagian akan merusak file, jadi download dihentikan.
+var errBadContentRange = errors.New("Content-Range dari server tidak sesuai permintaan")
+
+// checkContentRange memastikan header Content-Range respons 206 ("bytes 0-999/5000") sama
+// persis dengan rentang yang diminta dan ukuran file yang diketahui.
+func checkContentRange(value string, start, end, size int64) error {
+	gotStart, gotEnd, gotSize, ok := parseContentRange(value)
+	if !ok {
+		return fmt.Errorf("%w: header '%s' tidak valid", errBadContentRange, value)
+	}
+	if gotStart != start || gotEnd != end || (gotSize >= 0 && gotSize != size) {
+		return fmt.Errorf("%w: diminta bytes %d-%d/%d, diterima '%s'", errBadContentRange, start, end, size, value)
+	}
+	return nil
+}
+
+// parseContentRange membaca "bytes <start>-<end>/<size>". Ukuran "*" dikembalikan sebagai -1.
+func parseContentRange(value string) (start, end, size int64, ok bool) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(value), "bytes ")
+	if !found {
+		return 0, 0, 0, false
+	}
+	rng, total, found := strings.Cut(rest, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	first, last, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+	var err1, err2, err3 error
+	start, err1 = strconv.ParseInt(first, 10, 64)
+	end, err2 = strconv.ParseInt(last, 10, 64)
+	size = -1
+	if total != "*" {
+		size, err3 = strconv.ParseInt(total, 10, 64)
+	}
+	if err1 != nil || err2 != nil || err3 != nil || start < 0 || end < start {
+		return 0, 0, 0, false
+	}
+	return start, end, size, true
+}
+
+// discardManifest menghapus sisa download per bagian sebelumnya (jika ada), karena download
+// dengan satu koneksi tidak bisa memakai data tersebut.
+func discardManifest(mPath string) {
+	if old, _ := loadManifest(mPath); old != nil {
+		removeArtifacts(old, mPath)
+	}
+}
+
+// downloadSingleStream mengunduh seluruh file dengan satu request tanpa Range. Dipakai jika
+// server tidak mengirim Content-Length (misalnya respons chunked) atau tidak mendukung Range.
+// Data ditulis ke <output>.download lalu di-rename setelah lengkap. Karena tidak bisa
+// dilanjutkan dari tengah, setiap percobaan ulang dimulai dari byte pertama.
+// 'total' adalah ukuran file dari probe (-1 jika tidak diketahui), hanya untuk tampilan progres.
+func downloadSingleStream(ctx context.Context, cfg downloadConfig, total int64, algos []string) (map[string][]byte, error) {
+	tmp := tempOutputPath(cfg.Output)
+	counter := &countingWriter{}
+	progress := newProgressReporter(cfg, total, func() (int64, []PartProgress) {
+		n := counter.n.Load()
+		return n, []PartProgress{{Index: 0, End: total - 1, Downloaded: n, Active: true}}
+	})
+	progress.start()
+	defer progress.finish()
+
+	for attempt := 1; ; attempt++ {
+		if err := cfg.Conns.acquire(ctx); err != nil {
+			os.Remove(tmp)
+			return nil, err
+		}
+		sums, err := fetchSingleStream(ctx, cfg, tmp, algos, counter, progress.logf)
+		cfg.Conns.release()
+		if err == nil {
+			if err := os.Rename(tmp, cfg.Output); err != nil {
+				return nil, &diskError{err: fmt.Errorf("gagal mengganti nama %s menjadi %s: %v", tmp, cfg.Output, err)}
+			}
+			return sums, nil
+		}
+		delay, retry := cfg.Retry.next(ctx, attempt, err)
+		if !retry {
+			os.Remove(tmp)
+			return nil, err
+		}
+		progress.logf("Percobaan %d gagal: %v. Mengulang dari awal dalam %v...\n", attempt, err, delay.Round(time.Millisecond))
+		if !sleepContext(ctx, delay) {
+			os.Remove(tmp)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// fetchSingleStream melakukan satu kali GET dan menyalin body ke file 'tmp', sambil menghitung hash.
+// Byte yang tertulis dihitung oleh 'counter' untuk pelapor progres.
+func fetchSingleStream(ctx context.Context, cfg downloadConfig, tmp string, algos []string, counter *countingWriter, logf func(string, ...any)) (map[string][]byte, error) {
+	reqCtx, cancelReq := context.WithCancel(ctx) // Dibatalkan juga oleh detektor koneksi macet
+	defer cancelReq()
+	req, err := newFileRequest(reqCtx, "GET", cfg.URL, cfg.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat request HTTP: %v", err)
+	}
+	resp, err := cfg.httpClient().Do(req)
+	if err != nil {
+		return nil, &retryableError{err: fmt.Errorf("gagal melakukan request HTTP: %w", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, fmt.Errorf("server mengembalikan status %d", resp.StatusCode))
+	}
+	if resp.ContentLength >= 0 {
+		logf("Mengunduh %d bytes dengan satu koneksi...\n", resp.ContentLength)
+	} else {
+		logf("Mengunduh dengan satu koneksi (ukuran tidak diketahui)...\n")
+	}
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return nil, &diskError{err: fmt.Errorf("gagal membuat file %s: %v", tmp, err)}
+	}
+	defer file.Close()
+
+	hashes := make(map[string]hash.Hash, len(algos))
+	counter.w = &diskErrorWriter{file}
+	counter.n.Store(0) // Percobaan ulang dimulai dari byte pertama
+	writers := []io.Writer{counter}
+	for _, algo := range algos {
+		hashes[algo] = newHashFuncs[algo]()
+		writers = append(writers, hashes[algo])
+	}
+	// Jika Content-Length ada, net/http sendiri mengembalikan io.ErrUnexpectedEOF
+	// saat body lebih pendek dari yang dijanjikan.
+	n, err := io.Copy(io.MultiWriter(writers...), limitReader(ctx, watchStall(resp.Body, cfg.StallTimeout, cancelReq), cfg.RateLimit, cfg.SharedRateLimit))
+	if err != nil {
+		err = fmt.Errorf("gagal mengunduh ke %s setelah %d bytes: %w", tmp, n, err)
+		var de *diskError
+		if errors.As(err, &de) {
+			return nil, err
+		}
+		return nil, &retryableError{err: err}
+	}
+	if err := file.Close(); err != nil {
+		return nil, &diskError{err: fmt.Errorf("gagal menutup file %s: %v", tmp, err)}
+	}
+	logf("Download selesai: %d bytes.\n", n)
+
+	sums := make(map[string][]byte, len(hashes))
+	for algo, h := range hashes {
+		sums[algo] = h.Sum(nil)
+	}
+	return sums, nil
+}
+
+// diskErrorWriter menandai error penulisan file sebagai diskError agar tidak dicoba ulang.
+type diskErrorWriter struct {
+	w io.Writer
+}
+
+func (dw *diskErrorWriter) Write(p []byte) (int, error) {
+	n, err := dw.w.Write(p)
+	if err != nil {
+		err = &diskError{err: err}
+	}
+	return n, err
+}
@@ -18,6 +18,11 @@ import (
 )
 
 func main() {
+	// Subcommand non-interaktif untuk skrip dan cron, misalnya "mini-projects download URL -o out".
+	if len(os.Args) > 1 {
+		os.Exit(runSubcommand(os.Args[1], os.Args[2:]))
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
@@ -36,7 +41,9 @@ func main() {
 			fmt.Println("6. Start Product API Server") // Tampilkan opsi start jika server mati
 		}
 
-		fmt.Println("7. Exit")
+		fmt.Println("7. Download Queue")
+		fmt.Println("8. Download History")
+		fmt.Println("9. Exit")
 		fmt.Print("Enter your choice: ")
 
 		input, _ := reader.ReadString('\n')
@@ -60,6 +67,10 @@ func main() {
 				product_service.RunProductAPICLI() // Panggil fungsi start jika server mati
 			}
 		case "7":
+			parallel_downloader_app.RunDownloadQueueCLI(reader)
+		case "8":
+			parallel_downloader_app.RunDownloadHistoryCLI(reader)
+		case "9":
 			fmt.Println("Thank you for using Mini-Projects! Sayonara!")
 			// Pastikan server API dihentikan dengan graceful saat keluar aplikasi utama
 			if product_service.IsProductAPIRunning() {
@@ -71,3 +82,20 @@ func main() {
 		}
 	}
 }
+
+// runSubcommand menjalankan subcommand dan mengembalikan exit code-nya.
+func runSubcommand(name string, args []string) int {
+	switch name {
+	case "download":
+		return parallel_downloader_app.RunDownloadCommand(args, os.Stdout, os.Stderr)
+	case "cleanup":
+		return parallel_downloader_app.RunCleanupCommand(args, os.Stdout, os.Stderr)
+	case "serve":
+		return parallel_downloader_app.RunServeCommand(args, os.Stdout, os.Stderr)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", name)
+		fmt.Fprintln(os.Stderr, "Usage: mini-projects [download URL [flags] | cleanup [flags] [DIR...] | serve [flags] [DIR]]")
+		fmt.Fprintln(os.Stderr, "Run without arguments for the interactive menu.")
+		return 2
+	}
+}
@@ -0,0 +1,404 @@
+// mini-projects/product_service/middleware.go
+package product_service
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- CORS ---
+
+// CORSConfig mengatur header CORS yang dikirim server API Produk.
+// Origin "*" berarti semua origin diizinkan. Pola "https://*.contoh.com"
+// mencocokkan semua subdomain dari contoh.com.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration // Lama browser boleh menyimpan hasil preflight
+}
+
+// DefaultCORSConfig mengembalikan konfigurasi CORS bawaan.
+// Daftar origin bisa diatur lewat environment variable PRODUCT_API_CORS_ORIGINS
+// (dipisahkan koma). Jika kosong, semua origin diizinkan.
+func DefaultCORSConfig() CORSConfig {
+	origins := []string{"*"}
+	if env := strings.TrimSpace(os.Getenv("PRODUCT_API_CORS_ORIGINS")); env != "" {
+		origins = splitCommaList(env)
+	}
+	return CORSConfig{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "If-Modified-Since", "X-API-Key", "X-Tenant-ID"},
+		ExposedHeaders: []string{"Last-Modified"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+var (
+	corsMu     sync.RWMutex
+	corsConfig = DefaultCORSConfig()
+)
+
+// SetCORSConfig mengganti konfigurasi CORS yang dipakai server API Produk.
+// Bisa dipanggil sebelum maupun saat server berjalan.
+func SetCORSConfig(cfg CORSConfig) {
+	corsMu.Lock()
+	defer corsMu.Unlock()
+	corsConfig = cfg
+}
+
+func currentCORSConfig() CORSConfig {
+	corsMu.RLock()
+	defer corsMu.RUnlock()
+	return corsConfig
+}
+
+func splitCommaList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// originAllowed memeriksa apakah origin termasuk dalam daftar yang diizinkan.
+func (c CORSConfig) originAllowed(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		// Pola wildcard subdomain, misal "https://*.contoh.com".
+		if i := strings.Index(allowed, "*."); i >= 0 {
+			prefix, suffix := allowed[:i], allowed[i+1:]
+			if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) &&
+				len(origin) > len(prefix)+len(suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c CORSConfig) allowsAnyOrigin() bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if item == "*" || strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsMiddleware menambahkan header CORS dan menjawab permintaan preflight (OPTIONS).
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := currentCORSConfig()
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// Bukan permintaan cross-origin, teruskan apa adanya.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if !cfg.allowsAnyOrigin() || cfg.AllowCredentials {
+			// Respons berbeda tergantung origin, jadi cache perlu tahu.
+			w.Header().Add("Vary", "Origin")
+		}
+		if !cfg.originAllowed(origin) {
+			if preflight {
+				respondWithError(w, http.StatusForbidden, "Origin tidak diizinkan")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if cfg.allowsAnyOrigin() && !cfg.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if len(cfg.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// --- Permintaan preflight ---
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		reqMethod := r.Header.Get("Access-Control-Request-Method")
+		if !containsFold(cfg.AllowedMethods, reqMethod) {
+			respondWithError(w, http.StatusForbidden, "Metode tidak diizinkan oleh kebijakan CORS")
+			return
+		}
+		for _, h := range splitCommaList(r.Header.Get("Access-Control-Request-Headers")) {
+			if !containsFold(cfg.AllowedHeaders, h) {
+				respondWithError(w, http.StatusForbidden, "Header '"+h+"' tidak diizinkan oleh kebijakan CORS")
+				return
+			}
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+		if len(cfg.AllowedHeaders) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+		}
+		if cfg.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// --- Kompresi Respons ---
+
+// minCompressSize adalah ukuran body minimum (byte) sebelum respons dikompresi.
+// Respons kecil justru bisa membesar setelah dikompresi.
+const minCompressSize = 1024
+
+// ResponseEncoder membungkus writer dengan encoder kompresi tertentu.
+type ResponseEncoder func(w io.Writer) io.WriteCloser
+
+var (
+	encodersMu sync.RWMutex
+	// responseEncoders berisi encoder yang tersedia, dikunci dengan nama Content-Encoding.
+	// Bawaan hanya gzip dan deflate: library standar Go tidak menyediakan encoder Brotli,
+	// jadi "br" baru dinegosiasikan setelah didaftarkan lewat RegisterResponseEncoder.
+	responseEncoders = map[string]ResponseEncoder{
+		"gzip": func(w io.Writer) io.WriteCloser {
+			gz, _ := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+			return gz
+		},
+		"deflate": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+	}
+	// encodingPreference menentukan urutan pilihan jika client memberi bobot (q) yang sama.
+	encodingPreference = []string{"gzip", "deflate"}
+)
+
+// RegisterResponseEncoder mendaftarkan encoder untuk sebuah Content-Encoding,
+// misalnya "br" dengan encoder Brotli dari library pihak ketiga. Encoder baru
+// didahulukan dari gzip dan deflate jika client memberi bobot yang sama.
+func RegisterResponseEncoder(name string, enc ResponseEncoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	name = strings.ToLower(name)
+	if _, ok := responseEncoders[name]; !ok {
+		encodingPreference = append([]string{name}, encodingPreference...)
+	}
+	responseEncoders[name] = enc
+}
+
+// negotiateEncoding memilih Content-Encoding terbaik berdasarkan header Accept-Encoding.
+// Mengembalikan string kosong jika respons sebaiknya tidak dikompresi.
+func negotiateEncoding(acceptEncoding string) (string, ResponseEncoder) {
+	if acceptEncoding == "" {
+		return "", nil
+	}
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == "*" {
+			wildcard = q
+		} else {
+			weights[name] = q
+		}
+	}
+
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	bestName, bestQ := "", 0.0
+	for _, name := range encodingPreference {
+		if _, ok := responseEncoders[name]; !ok {
+			continue
+		}
+		q, ok := weights[name]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			bestName, bestQ = name, q
+		}
+	}
+	if bestName == "" {
+		return "", nil
+	}
+	return bestName, responseEncoders[bestName]
+}
+
+// compressResponseWriter menahan body sampai minCompressSize byte untuk memutuskan
+// apakah respons layak dikompresi.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encodingName string
+	newEncoder   ResponseEncoder
+
+	status      int
+	buf         bytes.Buffer
+	encoder     io.WriteCloser
+	wroteHeader bool // Header sudah diteruskan ke ResponseWriter asli
+	passthrough bool // Body ditulis tanpa kompresi
+}
+
+func (cw *compressResponseWriter) WriteHeader(code int) {
+	if cw.status == 0 {
+		cw.status = code
+	}
+}
+
+func (cw *compressResponseWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	if cw.passthrough {
+		return cw.ResponseWriter.Write(p)
+	}
+	// Respons tanpa body, atau yang sudah punya Content-Encoding, tidak dikompresi.
+	if cw.status == http.StatusNoContent || cw.status == http.StatusNotModified ||
+		cw.Header().Get("Content-Encoding") != "" {
+		cw.startPassthrough()
+		return cw.ResponseWriter.Write(p)
+	}
+	cw.buf.Write(p)
+	if cw.buf.Len() >= minCompressSize {
+		if err := cw.startEncoder(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (cw *compressResponseWriter) startPassthrough() {
+	cw.passthrough = true
+	cw.writeHeader()
+}
+
+func (cw *compressResponseWriter) startEncoder() error {
+	h := cw.Header()
+	h.Set("Content-Encoding", cw.encodingName)
+	h.Del("Content-Length")
+	cw.writeHeader()
+	cw.encoder = cw.newEncoder(cw.ResponseWriter)
+	_, err := cw.encoder.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
+
+func (cw *compressResponseWriter) writeHeader() {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// Close menulis sisa buffer dan menutup encoder jika ada.
+func (cw *compressResponseWriter) Close() error {
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	cw.writeHeader()
+	if cw.buf.Len() > 0 {
+		_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+		cw.buf.Reset()
+		return err
+	}
+	return nil
+}
+
+// compressionMiddleware mengompresi respons dengan encoding yang dinegosiasikan
+// dari header Accept-Encoding (gzip, deflate, atau encoder lain yang didaftarkan).
+func compressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		name, enc := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if enc == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressResponseWriter{ResponseWriter: w, encodingName: name, newEncoder: enc}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// --- Conditional GET (Last-Modified / If-Modified-Since) ---
+
+// checkNotModified memasang header Last-Modified dan mengembalikan true (serta menulis
+// status 304) jika client sudah punya versi terbaru berdasarkan If-Modified-Since.
+func checkNotModified(w http.ResponseWriter, r *http.Request, modTime time.Time) bool {
+	if modTime.IsZero() {
+		return false
+	}
+	// Header HTTP hanya punya presisi detik.
+	modTime = modTime.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil || modTime.After(t) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
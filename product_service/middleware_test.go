@@ -0,0 +1,321 @@
+// mini-projects/product_service/middleware_test.go
+package product_service
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func doRequest(h http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// setTestCORSConfig memasang konfigurasi CORS untuk satu tes dan memulihkan bawaan sesudahnya.
+func setTestCORSConfig(t *testing.T, cfg CORSConfig) {
+	t.Helper()
+	SetCORSConfig(cfg)
+	t.Cleanup(func() { SetCORSConfig(DefaultCORSConfig()) })
+}
+
+// okHandler mencatat apakah permintaan diteruskan dan membalas dengan body tetap.
+func okHandler(called *bool, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*called = true
+		io.WriteString(w, body)
+	})
+}
+
+func TestCORSPreflight(t *testing.T) {
+	setTestCORSConfig(t, CORSConfig{
+		AllowedOrigins: []string{"https://dashboard.contoh.com", "https://*.toko.id"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		ExposedHeaders: []string{"Last-Modified"},
+		MaxAge:         90 * time.Second,
+	})
+	var called bool
+	handler := corsMiddleware(okHandler(&called, "ok"))
+
+	tests := []struct {
+		name            string
+		method, origin  string
+		reqMethod       string // Access-Control-Request-Method; kosong = bukan preflight
+		reqHeaders      string
+		wantStatus      int
+		wantAllowOrigin string
+		wantNext        bool
+	}{
+		{"preflight diizinkan", "OPTIONS", "https://dashboard.contoh.com", "POST", "content-type, x-api-key", http.StatusNoContent, "https://dashboard.contoh.com", false},
+		{"subdomain wildcard", "OPTIONS", "https://cabang.toko.id", "GET", "", http.StatusNoContent, "https://cabang.toko.id", false},
+		{"metode ditolak", "OPTIONS", "https://dashboard.contoh.com", "DELETE", "", http.StatusForbidden, "https://dashboard.contoh.com", false},
+		{"header ditolak", "OPTIONS", "https://dashboard.contoh.com", "POST", "content-type, x-admin-key", http.StatusForbidden, "https://dashboard.contoh.com", false},
+		{"origin ditolak", "OPTIONS", "https://jahat.com", "GET", "", http.StatusForbidden, "", false},
+		// Pola "*.toko.id" tidak mencocokkan domain induk, skema lain, atau akhiran palsu.
+		{"domain induk", "OPTIONS", "https://toko.id", "GET", "", http.StatusForbidden, "", false},
+		{"skema lain", "OPTIONS", "http://cabang.toko.id", "GET", "", http.StatusForbidden, "", false},
+		{"akhiran palsu", "OPTIONS", "https://cabang.toko.id.jahat.com", "GET", "", http.StatusForbidden, "", false},
+		// OPTIONS tanpa Access-Control-Request-Method bukan preflight dan diteruskan.
+		{"OPTIONS biasa", "OPTIONS", "https://dashboard.contoh.com", "", "", http.StatusOK, "https://dashboard.contoh.com", true},
+		{"GET origin asing", "GET", "https://jahat.com", "", "", http.StatusOK, "", true},
+		{"GET tanpa origin", "GET", "", "", "", http.StatusOK, "", true},
+	}
+	for _, tt := range tests {
+		called = false
+		headers := map[string]string{}
+		for k, v := range map[string]string{"Origin": tt.origin, "Access-Control-Request-Method": tt.reqMethod, "Access-Control-Request-Headers": tt.reqHeaders} {
+			if v != "" {
+				headers[k] = v
+			}
+		}
+		rec := doRequest(handler, tt.method, "/api/products", "", headers)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, ingin %d (%s)", tt.name, rec.Code, tt.wantStatus, rec.Body.String())
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+			t.Errorf("%s: Allow-Origin %q, ingin %q", tt.name, got, tt.wantAllowOrigin)
+		}
+		if called != tt.wantNext {
+			t.Errorf("%s: handler berikutnya dipanggil = %v, ingin %v", tt.name, called, tt.wantNext)
+		}
+		vary := rec.Header().Values("Vary")
+		if tt.origin != "" && !slices.Contains(vary, "Origin") {
+			t.Errorf("%s: Vary %v tanpa Origin, padahal daftar origin terbatas", tt.name, vary)
+		}
+	}
+
+	rec := doRequest(handler, "OPTIONS", "/api/products", "", map[string]string{
+		"Origin":                        "https://dashboard.contoh.com",
+		"Access-Control-Request-Method": "POST",
+	})
+	want := map[string]string{
+		"Access-Control-Allow-Methods": "GET, POST",
+		"Access-Control-Allow-Headers": "Content-Type, X-API-Key",
+		"Access-Control-Max-Age":       "90",
+	}
+	for k, v := range want {
+		if got := rec.Header().Get(k); got != v {
+			t.Errorf("preflight %s = %q, ingin %q", k, got, v)
+		}
+	}
+	if vary := rec.Header().Values("Vary"); !slices.Contains(vary, "Access-Control-Request-Method") || !slices.Contains(vary, "Access-Control-Request-Headers") {
+		t.Errorf("Vary preflight tidak lengkap: %v", vary)
+	}
+	rec = doRequest(handler, "GET", "/api/products", "", map[string]string{"Origin": "https://dashboard.contoh.com"})
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "Last-Modified" {
+		t.Errorf("Expose-Headers = %q", got)
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	var called bool
+	handler := corsMiddleware(okHandler(&called, "ok"))
+	origin := map[string]string{"Origin": "https://mana.saja"}
+
+	// Semua origin tanpa credentials: "*" dan tidak perlu Vary: Origin.
+	setTestCORSConfig(t, CORSConfig{AllowedOrigins: []string{"*"}})
+	rec := doRequest(handler, "GET", "/", "", origin)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Allow-Origin = %q, ingin *", got)
+	}
+	if slices.Contains(rec.Header().Values("Vary"), "Origin") {
+		t.Error("Vary: Origin tidak perlu jika jawabannya selalu *")
+	}
+
+	// Dengan credentials, "*" tidak boleh dipakai: origin dipantulkan dan Vary: Origin wajib.
+	SetCORSConfig(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	rec = doRequest(handler, "GET", "/", "", origin)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://mana.saja" {
+		t.Errorf("Allow-Origin dengan credentials = %q", got)
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "true" || !slices.Contains(rec.Header().Values("Vary"), "Origin") {
+		t.Errorf("header credentials tidak lengkap: %v", rec.Header())
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct{ accept, want string }{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"}, // Bobot sama: ikuti urutan preferensi server
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"GZIP", "gzip"},
+		{"gzip;q=0", ""},
+		{"*", "gzip"},
+		{"gzip;q=0, *;q=0.3", "deflate"},
+		// "br" tidak tersedia kecuali didaftarkan.
+		{"br", ""},
+		{"br;q=1.0, gzip;q=0.8", "gzip"},
+	}
+	for _, tt := range tests {
+		if got, _ := negotiateEncoding(tt.accept); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, ingin %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterResponseEncoder(t *testing.T) {
+	encodersMu.Lock()
+	savedEncoders, savedPreference := maps.Clone(responseEncoders), slices.Clone(encodingPreference)
+	encodersMu.Unlock()
+	t.Cleanup(func() {
+		encodersMu.Lock()
+		responseEncoders, encodingPreference = savedEncoders, savedPreference
+		encodersMu.Unlock()
+	})
+
+	// Encoder palsu cukup untuk memeriksa negosiasi; isinya ditulis tanpa kompresi.
+	RegisterResponseEncoder("BR", func(w io.Writer) io.WriteCloser { return nopCloser{w} })
+	for accept, want := range map[string]string{
+		"br, gzip":          "br", // Encoder terdaftar didahulukan jika bobotnya sama
+		"gzip, br;q=0.5":    "gzip",
+		"*":                 "br",
+		"deflate, br;q=0.9": "deflate",
+	} {
+		if got, _ := negotiateEncoding(accept); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, ingin %q", accept, got, want)
+		}
+	}
+	// Mendaftarkan ulang tidak menduplikasi urutan preferensi.
+	RegisterResponseEncoder("br", func(w io.Writer) io.WriteCloser { return nopCloser{w} })
+	if n := strings.Count(strings.Join(encodingPreference, ","), "br"); n != 1 {
+		t.Errorf("br muncul %d kali di urutan preferensi: %v", n, encodingPreference)
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat("produk ", 400) // Di atas minCompressSize
+	respond := func(status int, body string, headers map[string]string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(status)
+			io.WriteString(w, body)
+		})
+	}
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil },
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		accept       string
+		status       int
+		body         string
+		headers      map[string]string
+		wantEncoding string
+	}{
+		{"gzip", "GET", "gzip", http.StatusOK, large, nil, "gzip"},
+		{"deflate", "GET", "deflate;q=1, gzip;q=0.5", http.StatusCreated, large, nil, "deflate"},
+		{"body kecil", "GET", "gzip", http.StatusOK, "kecil", nil, ""},
+		{"tanpa Accept-Encoding", "GET", "", http.StatusOK, large, nil, ""},
+		{"HEAD", "HEAD", "gzip", http.StatusOK, "", nil, ""},
+		{"sudah terenkode", "GET", "gzip", http.StatusOK, large, map[string]string{"Content-Encoding": "identity"}, "identity"},
+		{"304", "GET", "gzip", http.StatusNotModified, "", nil, ""},
+	}
+	for _, tt := range tests {
+		handler := compressionMiddleware(respond(tt.status, tt.body, tt.headers))
+		rec := doRequest(handler, tt.method, "/", "", map[string]string{"Accept-Encoding": tt.accept})
+		if !slices.Contains(rec.Header().Values("Vary"), "Accept-Encoding") {
+			t.Errorf("%s: Vary tanpa Accept-Encoding: %v", tt.name, rec.Header().Values("Vary"))
+		}
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, ingin %d", tt.name, rec.Code, tt.status)
+		}
+		got := rec.Header().Get("Content-Encoding")
+		if got != tt.wantEncoding {
+			t.Errorf("%s: Content-Encoding %q, ingin %q", tt.name, got, tt.wantEncoding)
+			continue
+		}
+		body := io.Reader(rec.Body)
+		if decode, ok := decoders[got]; ok {
+			if rec.Header().Get("Content-Length") != "" {
+				t.Errorf("%s: Content-Length lama tidak dihapus dari respons terkompresi", tt.name)
+			}
+			var err error
+			if body, err = decode(rec.Body); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("%s: body tidak bisa dibaca: %v", tt.name, err)
+		}
+		if !bytes.Equal(data, []byte(tt.body)) {
+			t.Errorf("%s: body setelah didekode berbeda (%d byte, ingin %d)", tt.name, len(data), len(tt.body))
+		}
+	}
+}
+
+func TestCheckNotModified(t *testing.T) {
+	modTime := time.Date(2025, 3, 1, 10, 30, 15, 700_000_000, time.UTC) // Ada pecahan detik
+	header := func(t time.Time) string { return t.Format(http.TimeFormat) }
+
+	tests := []struct {
+		name    string
+		method  string
+		ims     string
+		modTime time.Time
+		want    bool
+	}{
+		{"tanpa If-Modified-Since", "GET", "", modTime, false},
+		{"sama persis (presisi detik)", "GET", header(modTime), modTime, true},
+		{"lebih baru dari modTime", "HEAD", header(modTime.Add(time.Hour)), modTime, true},
+		{"lebih lama dari modTime", "GET", header(modTime.Add(-time.Second)), modTime, false},
+		{"tanggal tidak valid", "GET", "kemarin", modTime, false},
+		{"bukan GET/HEAD", "PUT", header(modTime), modTime, false},
+		{"modTime kosong", "GET", header(modTime), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		headers := map[string]string{}
+		if tt.ims != "" {
+			headers["If-Modified-Since"] = tt.ims
+		}
+		var got bool
+		rec := doRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = checkNotModified(w, r, tt.modTime)
+		}), tt.method, "/", "", headers)
+		if got != tt.want {
+			t.Errorf("%s: checkNotModified = %v, ingin %v", tt.name, got, tt.want)
+		}
+		if tt.want && rec.Code != http.StatusNotModified {
+			t.Errorf("%s: status %d, ingin 304", tt.name, rec.Code)
+		}
+		wantLastModified := ""
+		if !tt.modTime.IsZero() {
+			wantLastModified = "Sat, 01 Mar 2025 10:30:15 GMT"
+		}
+		if lm := rec.Header().Get("Last-Modified"); lm != wantLastModified {
+			t.Errorf("%s: Last-Modified %q, ingin %q", tt.name, lm, wantLastModified)
+		}
+	}
+}
@@ -5,10 +5,8 @@ import (
 	"context" // Tambahkan import context
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time" // Tambahkan import time
@@ -16,65 +14,22 @@ import (
 
 // --- Struktur Data (Sama seperti sebelumnya) ---
 type Product struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Price int    `json:"price"`
-	Stock int    `json:"stock,omitempty"`
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Price       int      `json:"price"`
+	Stock       int      `json:"stock,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	SKU         string   `json:"sku,omitempty"`
 }
 
-var products []Product
-var nextProductID = 1
-
+// jsonFilePath adalah file katalog tenant default. Tenant lain memakai
+// products_<id tenant>.json di direktori yang sama.
 const jsonFilePath = "products.json"
 
 // serverInstance menyimpan instance HTTP server agar bisa diakses dan dimatikan.
 var serverInstance *http.Server
 
-// --- Fungsi Bantuan untuk File I/O JSON (Sama seperti sebelumnya) ---
-func getNextProductID() int {
-	currentMaxID := 0
-	for _, p := range products {
-		if p.ID > currentMaxID {
-			currentMaxID = p.ID
-		}
-	}
-	return currentMaxID + 1
-}
-
-func loadProductsFromJsonFile() error {
-	if _, err := os.Stat(jsonFilePath); os.IsNotExist(err) {
-		log.Printf("LOG: File '%s' tidak ditemukan. Membuat database produk kosong.", jsonFilePath)
-		products = []Product{}
-		return nil
-	}
-	data, err := ioutil.ReadFile(jsonFilePath)
-	if err != nil {
-		return fmt.Errorf("gagal membaca file JSON: %w", err)
-	}
-	if len(data) == 0 {
-		products = []Product{}
-		log.Printf("LOG: File '%s' kosong. Membuat database produk kosong.", jsonFilePath)
-		return nil
-	}
-	if err := json.Unmarshal(data, &products); err != nil {
-		return fmt.Errorf("gagal mendekode JSON dari file: %w", err)
-	}
-	log.Printf("LOG: Data produk berhasil dimuat dari '%s'.", jsonFilePath)
-	return nil
-}
-
-func saveProductsToJsonFile() error {
-	data, err := json.MarshalIndent(products, "", "  ")
-	if err != nil {
-		return fmt.Errorf("gagal mengkodekan data ke JSON: %w", err)
-	}
-	if err := os.WriteFile(jsonFilePath, data, 0644); err != nil {
-		return fmt.Errorf("gagal menulis data JSON ke file: %w", err)
-	}
-	log.Printf("LOG: Data produk berhasil disimpan ke '%s'.", jsonFilePath)
-	return nil
-}
-
 // --- Fungsi Helper untuk Respons API (Sama seperti sebelumnya) ---
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -89,16 +44,22 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 	respondWithJSON(w, code, map[string]string{"error": message})
 }
 
-// --- API Handlers (Sama seperti sebelumnya) ---
+// --- API Handlers ---
+// Handler produk selalu bekerja pada store milik tenant permintaan (lihat tenantMiddleware).
 
 func productsHandler(w http.ResponseWriter, r *http.Request) {
 	if strings.TrimPrefix(r.URL.Path, "/api/products") != "" && strings.TrimPrefix(r.URL.Path, "/api/products/") != "" {
 		respondWithError(w, http.StatusNotFound, "Endpoint tidak ditemukan")
 		return
 	}
+	store := storeFromRequest(r)
 	switch r.Method {
 	case "GET":
-		respondWithJSON(w, http.StatusOK, products)
+		if checkNotModified(w, r, store.modTime()) {
+			log.Println("LOG: Permintaan GET /api/products: data tidak berubah (304).")
+			return
+		}
+		respondWithJSON(w, http.StatusOK, store.list())
 		log.Println("LOG: Permintaan GET /api/products berhasil diproses.")
 	case "POST":
 		var newProduct Product
@@ -111,9 +72,8 @@ func productsHandler(w http.ResponseWriter, r *http.Request) {
 			respondWithError(w, http.StatusBadRequest, "Nama dan Harga produk tidak boleh kosong atau nol")
 			return
 		}
-		newProduct.ID = getNextProductID()
-		products = append(products, newProduct)
-		if err := saveProductsToJsonFile(); err != nil {
+		newProduct, err := store.create(newProduct)
+		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Gagal menyimpan data produk")
 			log.Printf("Error saving products after POST /api/products: %v", err)
 			return
@@ -134,22 +94,19 @@ func productByIDHandler(w http.ResponseWriter, r *http.Request) {
 		log.Printf("LOG: ID tidak valid di URL: '%s'", idStr)
 		return
 	}
-	var foundProduct *Product
-	foundIndex := -1
-	for i, product := range products {
-		if product.ID == id {
-			foundProduct = &products[i]
-			foundIndex = i
-			break
-		}
-	}
-	if foundProduct == nil {
+	store := storeFromRequest(r)
+	foundProduct, found := store.get(id)
+	if !found {
 		respondWithError(w, http.StatusNotFound, "Produk tidak ditemukan")
 		log.Printf("LOG: Produk dengan ID %d tidak ditemukan.", id)
 		return
 	}
 	switch r.Method {
 	case "GET":
+		if checkNotModified(w, r, store.modTime()) {
+			log.Printf("LOG: Permintaan GET /api/products/%d: data tidak berubah (304).", id)
+			return
+		}
 		respondWithJSON(w, http.StatusOK, foundProduct)
 		log.Printf("LOG: Permintaan GET /api/products/%d berhasil diproses.", id)
 	case "PUT":
@@ -159,29 +116,26 @@ func productByIDHandler(w http.ResponseWriter, r *http.Request) {
 			log.Printf("Error decoding JSON for PUT /api/products/%d: %v", id, err)
 			return
 		}
-		if updatedProduct.Name != "" {
-			foundProduct.Name = updatedProduct.Name
-		}
-		if updatedProduct.Price != 0 {
-			foundProduct.Price = updatedProduct.Price
+		updated, found, err := store.update(id, updatedProduct)
+		if !found {
+			// Produk dihapus oleh permintaan lain di antara pengecekan dan pembaruan.
+			respondWithError(w, http.StatusNotFound, "Produk tidak ditemukan")
+			return
 		}
-		foundProduct.Stock = updatedProduct.Stock
-		if err := saveProductsToJsonFile(); err != nil {
+		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Gagal menyimpan perubahan produk")
 			log.Printf("Error saving products after PUT /api/products/%d: %v", id, err)
 			return
 		}
-		respondWithJSON(w, http.StatusOK, foundProduct)
+		respondWithJSON(w, http.StatusOK, updated)
 		log.Printf("LOG: Produk ID %d berhasil diperbarui.", id)
 	case "DELETE":
-		newProducts := make([]Product, 0)
-		for i, product := range products {
-			if i != foundIndex {
-				newProducts = append(newProducts, product)
-			}
+		found, err := store.delete(id)
+		if !found {
+			respondWithError(w, http.StatusNotFound, "Produk tidak ditemukan")
+			return
 		}
-		products = newProducts
-		if err := saveProductsToJsonFile(); err != nil {
+		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Gagal menyimpan perubahan produk (setelah hapus)")
 			log.Printf("Error saving products after DELETE /api/products/%d: %v", id, err)
 			return
@@ -194,26 +148,69 @@ func productByIDHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Config mengatur handler API Produk yang dibuat oleh NewHandler.
+type Config struct {
+	DataDir      string // Direktori tenants.json dan file katalog produk ("" berarti direktori kerja)
+	ProductsFile string // File katalog tenant default; kosong berarti <DataDir>/products.json
+	AdminKey     string // Key untuk endpoint admin; kosong berarti endpoint admin selalu ditolak
+}
+
+// NewHandler membangun handler lengkap API Produk (router, tenant, CORS, dan kompresi)
+// tanpa menjalankan server. Dipakai oleh RunProductAPICLI dan oleh program lain yang
+// ingin menjalankan API di dalam proses yang sama, misalnya alat uji beban.
+// Jika katalog tenant default gagal dimuat, handler tetap dikembalikan bersama errornya.
+func NewHandler(cfg Config) (http.Handler, error) {
+	dataDir := cfg.DataDir
+	if dataDir == "" {
+		dataDir = "."
+	}
+	// Muat daftar tenant. Katalog setiap tenant dimuat saat pertama kali dipakai.
+	reg := newTenantRegistry(dataDir)
+	reg.defaultFile = cfg.ProductsFile
+	loadErr := reg.load()
+	// Muat katalog tenant default sekarang agar error file langsung terlihat.
+	if _, err := reg.storeFor(defaultTenantID); err != nil && loadErr == nil {
+		loadErr = err
+	}
+	return newAPIHandler(reg, cfg.AdminKey), loadErr
+}
+
+// newAPIHandler menyusun router dan middleware di atas registry tenant yang diberikan.
+func newAPIHandler(reg *tenantRegistry, adminKey string) http.Handler {
+	mux := http.NewServeMux() // Membuat router (ServeMux) baru khusus untuk API ini.
+	mux.HandleFunc("/api/products", productsHandler)
+	mux.HandleFunc("/api/products/", productByIDHandler)
+	mux.HandleFunc("/api/products/search", searchProductsHandler) // Path persis, didahulukan dari "/api/products/"
+
+	// Endpoint produk dibungkus tenantMiddleware; endpoint admin memakai admin key.
+	admin := http.NewServeMux()
+	admin.HandleFunc("/api/admin/tenants", tenantsAdminHandler(reg))
+	admin.HandleFunc("/api/admin/tenants/", tenantByIDAdminHandler(reg))
+	root := http.NewServeMux()
+	root.Handle("/api/admin/", adminMiddleware(adminKey, admin))
+	root.Handle("/", tenantMiddleware(reg, mux))
+
+	// Router dibungkus middleware CORS (paling luar, agar preflight dijawab langsung)
+	// dan middleware kompresi respons.
+	return corsMiddleware(compressionMiddleware(root))
+}
+
 // RunProductAPICLI adalah fungsi yang akan dijalankan ketika opsi API Produk dipilih dari menu CLI.
 // Fungsi ini akan menjalankan HTTP server di Goroutine terpisah dan menunggu sinyal stop.
 func RunProductAPICLI() {
-	// Muat data awal dari JSON saat API diinisialisasi.
-	if err := loadProductsFromJsonFile(); err != nil {
+	adminKey, generatedAdminKey := resolveAdminKey()
+	handler, err := NewHandler(Config{DataDir: ".", AdminKey: adminKey})
+	if err != nil {
 		log.Printf("LOG: Error memuat data awal produk dari JSON: %v", err)
 		// Tidak pakai log.Fatal di sini agar aplikasi utama tidak mati
 		// jika file JSON bermasalah, tapi kita log errornya saja.
 	}
-	nextProductID = getNextProductID()
-
-	mux := http.NewServeMux() // Membuat router (ServeMux) baru khusus untuk API ini.
-	mux.HandleFunc("/api/products", productsHandler)
-	mux.HandleFunc("/api/products/", productByIDHandler)
 
 	const apiPort = ":8080" // Port untuk API ini
 	// Membuat instance HTTP server
 	serverInstance = &http.Server{
 		Addr:    apiPort,
-		Handler: mux, // Gunakan router yang sudah kita definisikan
+		Handler: handler,
 	}
 
 	// Channel untuk memberi sinyal bahwa server sudah berhenti
@@ -231,6 +228,17 @@ func RunProductAPICLI() {
 		fmt.Println("  [GET]    /api/products/{id}")
 		fmt.Println("  [PUT]    /api/products/{id}")
 		fmt.Println("  [DELETE] /api/products/{id}")
+		fmt.Println("  [GET]    /api/products/search?q={kata kunci}")
+		fmt.Println("Endpoint Admin Tenant (header X-Admin-Key):")
+		fmt.Println("  [GET]    /api/admin/tenants")
+		fmt.Println("  [POST]   /api/admin/tenants")
+		fmt.Println("  [GET]    /api/admin/tenants/{id}")
+		fmt.Println("  [POST]   /api/admin/tenants/{id}/disable")
+		fmt.Println("  [POST]   /api/admin/tenants/{id}/enable")
+		if generatedAdminKey {
+			fmt.Printf("Admin key sementara: %s (atur %s agar tetap)\n", adminKey, adminKeyEnvVariable)
+		}
+		fmt.Println("Pilih tenant dengan header X-API-Key (tanpa key: tenant 'default').")
 		fmt.Println("\nServer API siap. Pilih opsi 'Stop Product API Server' di menu untuk kembali.")
 		fmt.Println("Atau tekan Ctrl+C untuk menghentikan seluruh aplikasi.") // Ini akan tetap menghentikan seluruh aplikasi
 		// karena Ctrl+C adalah sinyal OS global.
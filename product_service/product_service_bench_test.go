@@ -0,0 +1,138 @@
+// mini-projects/product_service/product_service_bench_test.go
+package product_service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchProductNames dipakai untuk mengisi katalog dengan data yang cukup bervariasi.
+var benchProductNames = []string{"Laptop Gaming", "Mouse Wireless", "Keyboard Mekanikal", "Monitor 27 inci", "Webcam Pro", "Kabel HDMI"}
+
+// newBenchStore membuat store di direktori sementara yang sudah berisi n produk.
+func newBenchStore(b *testing.B, n int) *productStore {
+	b.Helper()
+	store := newProductStore(filepath.Join(b.TempDir(), jsonFilePath))
+	if err := store.load(); err != nil {
+		b.Fatal(err)
+	}
+	store.mu.Lock()
+	for i := 0; i < n; i++ {
+		p := Product{
+			ID:          i + 1,
+			Name:        fmt.Sprintf("%s %d", benchProductNames[i%len(benchProductNames)], i),
+			Price:       1000 + i,
+			Stock:       i % 50,
+			Description: "Produk untuk benchmark toko",
+			Tags:        []string{"elektronik", "benchmark"},
+			SKU:         fmt.Sprintf("BNC-%05d", i),
+		}
+		store.products = append(store.products, p)
+		store.index.upsert(p)
+	}
+	store.nextID = n + 1
+	store.mu.Unlock()
+	return store
+}
+
+func BenchmarkStoreGet(b *testing.B) {
+	store := newBenchStore(b, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.get(i%1000 + 1)
+	}
+}
+
+func BenchmarkStoreList(b *testing.B) {
+	store := newBenchStore(b, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.list()
+	}
+}
+
+// BenchmarkStoreCreate mengukur penambahan produk, termasuk penulisan file JSON.
+func BenchmarkStoreCreate(b *testing.B) {
+	store := newBenchStore(b, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.create(Product{Name: "Produk Baru", Price: 1}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStoreSearch(b *testing.B) {
+	store := newBenchStore(b, 1000)
+	queries := []string{"laptop", "keybord mekanik", "BNC-00042", "webc"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.search(queries[i%len(queries)], defaultSearchLimit)
+	}
+}
+
+// newBenchHandler membangun handler lengkap di atas direktori data sementara.
+func newBenchHandler(b *testing.B, n int) http.Handler {
+	b.Helper()
+	store := newBenchStore(b, n)
+	store.mu.Lock()
+	err := store.saveLocked()
+	store.mu.Unlock()
+	if err != nil {
+		b.Fatal(err)
+	}
+	handler, err := NewHandler(Config{DataDir: filepath.Dir(store.filePath)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return handler
+}
+
+func BenchmarkHandlerList(b *testing.B) {
+	handler := newBenchHandler(b, 200)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest("GET", "/api/products", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func BenchmarkHandlerGetByIDParallel(b *testing.B) {
+	handler := newBenchHandler(b, 200)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/products/%d", i%200+1), nil)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			i++
+		}
+	})
+}
+
+func BenchmarkHandlerCreate(b *testing.B) {
+	handler := newBenchHandler(b, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest("POST", "/api/products", strings.NewReader(`{"name": "Webcam Pro", "price": 800000, "stock": 15}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			b.Fatalf("status %d", rec.Code)
+		}
+	}
+}
+
+func BenchmarkHandlerSearch(b *testing.B) {
+	handler := newBenchHandler(b, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest("GET", "/api/products/search?q=labtop+gaming", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
@@ -0,0 +1,291 @@
+// mini-projects/product_service/product_service_test.go
+package product_service
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Jalankan `go test ./product_service -update` untuk memperbarui file golden.
+var updateGolden = flag.Bool("update", false, "tulis ulang file golden di testdata/golden")
+
+const testAdminKey = "kunci-admin-uji"
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	log.SetOutput(io.Discard) // Handler mencatat setiap permintaan; tidak perlu di output tes.
+	os.Exit(m.Run())
+}
+
+// seedProducts adalah isi products.json awal untuk setiap tes.
+var seedProducts = []Product{
+	{ID: 1, Name: "Webcam Pro", Price: 800000, Stock: 15, Tags: []string{"kamera"}, SKU: "WBC-PRO-01"},
+	{ID: 2, Name: "Mouse Gaming", Price: 550000, Stock: 65, Description: "Mouse dengan sensor presisi tinggi"},
+}
+
+// newTestAPI membuat handler API dengan direktori data sementara yang terisolasi.
+func newTestAPI(t *testing.T) (http.Handler, string) {
+	t.Helper()
+	dir := t.TempDir()
+	data, err := json.MarshalIndent(seedProducts, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, jsonFilePath), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	handler, err := NewHandler(Config{DataDir: dir, AdminKey: testAdminKey})
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	return handler, dir
+}
+
+// goldenResponse adalah bentuk respons yang disimpan di file golden.
+type goldenResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// assertGolden membandingkan status dan body JSON respons dengan testdata/golden/<name>.json.
+// scrub (opsional) dipakai untuk mengganti nilai yang berubah-ubah seperti API key.
+func assertGolden(t *testing.T, name string, rec *httptest.ResponseRecorder, scrub func(map[string]interface{})) {
+	t.Helper()
+	got := goldenResponse{Status: rec.Code}
+	if body := bytes.TrimSpace(rec.Body.Bytes()); len(body) > 0 {
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			t.Fatalf("body respons bukan JSON: %v\n%s", err, body)
+		}
+		if obj, ok := v.(map[string]interface{}); ok && scrub != nil {
+			scrub(obj)
+		}
+		got.Body, _ = json.Marshal(v)
+	}
+	gotJSON, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotJSON = append(gotJSON, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, gotJSON, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("file golden %s tidak bisa dibaca (jalankan dengan -update): %v", path, err)
+	}
+	if !bytes.Equal(want, gotJSON) {
+		t.Errorf("respons tidak sesuai golden %s\n--- diharapkan\n%s\n--- didapat\n%s", path, want, gotJSON)
+	}
+}
+
+func TestProductRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"list_products", "GET", "/api/products", ""},
+		{"create_product", "POST", "/api/products", `{"name": "Keyboard", "price": 75, "stock": 150, "tags": ["aksesoris"]}`},
+		{"create_product_invalid_json", "POST", "/api/products", `{"name": `},
+		{"create_product_missing_name", "POST", "/api/products", `{"price": 75}`},
+		{"create_product_zero_price", "POST", "/api/products", `{"name": "Gratis", "price": 0}`},
+		{"products_method_not_allowed", "DELETE", "/api/products", ""},
+		{"get_product", "GET", "/api/products/1", ""},
+		{"get_product_invalid_id", "GET", "/api/products/abc", ""},
+		{"get_product_not_found", "GET", "/api/products/99", ""},
+		{"update_product", "PUT", "/api/products/1", `{"name": "Webcam Pro 2", "price": 850000, "stock": 10}`},
+		{"update_product_partial", "PUT", "/api/products/2", `{"stock": 5}`},
+		{"update_product_invalid_json", "PUT", "/api/products/1", `not json`},
+		{"update_product_not_found", "PUT", "/api/products/99", `{"name": "X"}`},
+		{"delete_product", "DELETE", "/api/products/2", ""},
+		{"delete_product_not_found", "DELETE", "/api/products/99", ""},
+		{"product_method_not_allowed", "PATCH", "/api/products/1", ""},
+		{"search_products", "GET", "/api/products/search?q=webcm", ""},
+		{"search_products_missing_query", "GET", "/api/products/search", ""},
+		{"search_products_invalid_limit", "GET", "/api/products/search?q=mouse&limit=-1", ""},
+		{"search_method_not_allowed", "POST", "/api/products/search?q=mouse", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, _ := newTestAPI(t)
+			rec := doRequest(handler, tc.method, tc.path, tc.body, nil)
+			if rec.Code != http.StatusNoContent && rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %q, ingin application/json", rec.Header().Get("Content-Type"))
+			}
+			assertGolden(t, tc.name, rec, nil)
+		})
+	}
+}
+
+func TestMutationsArePersisted(t *testing.T) {
+	handler, dir := newTestAPI(t)
+	if rec := doRequest(handler, "POST", "/api/products", `{"name": "Keyboard", "price": 75}`, nil); rec.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d", rec.Code)
+	}
+	if rec := doRequest(handler, "DELETE", "/api/products/1", "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d", rec.Code)
+	}
+
+	// Handler baru di direktori yang sama harus memuat data hasil perubahan.
+	reloaded, err := NewHandler(Config{DataDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "persisted_after_reload", doRequest(reloaded, "GET", "/api/products", "", nil), nil)
+}
+
+func TestProductsFileIsInjectable(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "katalog-lain.json")
+	handler, err := NewHandler(Config{DataDir: t.TempDir(), ProductsFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := doRequest(handler, "POST", "/api/products", `{"name": "Keyboard", "price": 75}`, nil); rec.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d", rec.Code)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("file katalog yang diinjeksi tidak ditulis: %v", err)
+	}
+	if !strings.Contains(string(data), "Keyboard") {
+		t.Errorf("isi file katalog tidak memuat produk baru:\n%s", data)
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	first := doRequest(handler, "GET", "/api/products", "", nil)
+	lastModified := first.Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatal("header Last-Modified tidak ada")
+	}
+
+	rec := doRequest(handler, "GET", "/api/products", "", map[string]string{"If-Modified-Since": lastModified})
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("status = %d, body %q; ingin 304 tanpa body", rec.Code, rec.Body.String())
+	}
+
+	// Setelah perubahan, waktu If-Modified-Since yang lama tidak lagi berlaku.
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	doRequest(handler, "PUT", "/api/products/1", `{"stock": 1}`, nil)
+	rec = doRequest(handler, "GET", "/api/products/1", "", map[string]string{"If-Modified-Since": past})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status setelah perubahan = %d, ingin 200", rec.Code)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	admin := map[string]string{adminKeyHeader: testAdminKey}
+	scrubTenant := func(obj map[string]interface{}) {
+		obj["api_keys"] = []string{"*key*"}
+		obj["created_at"] = "*waktu*"
+	}
+
+	rec := doRequest(handler, "POST", "/api/admin/tenants", `{"id": "toko-a", "name": "Toko A"}`, admin)
+	var tenant Tenant
+	if err := json.Unmarshal(rec.Body.Bytes(), &tenant); err != nil || len(tenant.APIKeys) != 1 {
+		t.Fatalf("respons pembuatan tenant tidak valid: %s", rec.Body.String())
+	}
+	assertGolden(t, "tenant_create", rec, scrubTenant)
+	assertGolden(t, "tenant_create_duplicate", doRequest(handler, "POST", "/api/admin/tenants", `{"id": "toko-a"}`, admin), nil)
+	assertGolden(t, "tenant_create_invalid_id", doRequest(handler, "POST", "/api/admin/tenants", `{"id": "../etc"}`, admin), nil)
+	assertGolden(t, "tenant_admin_unauthorized", doRequest(handler, "GET", "/api/admin/tenants", "", nil), nil)
+
+	// Produk pertama tenant baru mendapat ID 1 dari urutannya sendiri.
+	byKey := map[string]string{apiKeyHeader: tenant.APIKeys[0]}
+	assertGolden(t, "tenant_create_product", doRequest(handler, "POST", "/api/products", `{"name": "Kopi Arabika", "price": 90000}`, byKey), nil)
+
+	// Tenant default tidak melihat produk toko-a, dan sebaliknya.
+	rec = doRequest(handler, "GET", "/api/products/1", "", byKey)
+	if !strings.Contains(rec.Body.String(), "Kopi Arabika") {
+		t.Errorf("toko-a mendapat produk lain: %s", rec.Body.String())
+	}
+	rec = doRequest(handler, "GET", "/api/products/2", "", byKey)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("produk tenant default bocor ke toko-a: status %d", rec.Code)
+	}
+	rec = doRequest(handler, "GET", "/api/products/search?q=kopi", "", nil)
+	if strings.Contains(rec.Body.String(), "Kopi") {
+		t.Errorf("pencarian tenant default menemukan produk toko-a: %s", rec.Body.String())
+	}
+
+	assertGolden(t, "tenant_unknown_key", doRequest(handler, "GET", "/api/products", "", map[string]string{apiKeyHeader: "salah"}), nil)
+	assertGolden(t, "tenant_key_mismatch", doRequest(handler, "GET", "/api/products", "", map[string]string{apiKeyHeader: tenant.APIKeys[0], tenantHeader: "default"}), nil)
+	assertGolden(t, "tenant_unknown_id", doRequest(handler, "GET", "/api/products", "", map[string]string{tenantHeader: "tidak-ada"}), nil)
+
+	assertGolden(t, "tenant_disable", doRequest(handler, "POST", "/api/admin/tenants/toko-a/disable", "", admin), scrubTenant)
+	assertGolden(t, "tenant_disabled_request", doRequest(handler, "GET", "/api/products", "", byKey), nil)
+	assertGolden(t, "tenant_disable_default", doRequest(handler, "POST", "/api/admin/tenants/default/disable", "", admin), nil)
+	assertGolden(t, "tenant_get_not_found", doRequest(handler, "GET", "/api/admin/tenants/tidak-ada", "", admin), nil)
+}
+
+// TestConcurrentRequests menjalankan banyak permintaan sekaligus; jalankan dengan -race.
+func TestConcurrentRequests(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	const workers = 4
+	const perWorker = 6
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				rec := doRequest(handler, "POST", "/api/products", fmt.Sprintf(`{"name": "Produk %d-%d", "price": 100}`, w, i), nil)
+				if rec.Code != http.StatusCreated {
+					t.Errorf("POST status = %d", rec.Code)
+					return
+				}
+				var p Product
+				json.Unmarshal(rec.Body.Bytes(), &p)
+				doRequest(handler, "GET", "/api/products", "", nil)
+				doRequest(handler, "GET", "/api/products/search?q=produk", "", nil)
+				doRequest(handler, "PUT", fmt.Sprintf("/api/products/%d", p.ID), `{"stock": 3}`, nil)
+				if i%2 == 0 {
+					doRequest(handler, "DELETE", fmt.Sprintf("/api/products/%d", p.ID), "", nil)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	var list []Product
+	rec := doRequest(handler, "GET", "/api/products", "", nil)
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	want := len(seedProducts) + workers*perWorker/2
+	if len(list) != want {
+		t.Errorf("jumlah produk = %d, ingin %d", len(list), want)
+	}
+	seen := make(map[int]bool)
+	for _, p := range list {
+		if seen[p.ID] {
+			t.Errorf("ID %d dipakai lebih dari sekali", p.ID)
+		}
+		seen[p.ID] = true
+	}
+}
@@ -0,0 +1,490 @@
+// mini-projects/product_service/search.go
+package product_service
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// --- Indeks Pencarian Full-Text ---
+//
+// productIndex adalah inverted index sederhana di memori: setiap term (kata yang sudah
+// dinormalisasi) menunjuk ke daftar produk yang mengandungnya, beserta frekuensinya
+// per field. Indeks diperbarui setiap kali produk ditambah, diubah, atau dihapus.
+
+type searchField int
+
+const (
+	fieldName searchField = iota
+	fieldDescription
+	fieldTags
+	fieldSKU
+	numSearchFields
+)
+
+// fieldWeights menentukan seberapa penting kecocokan di setiap field.
+var fieldWeights = [numSearchFields]float64{
+	fieldName:        3.0,
+	fieldDescription: 1.0,
+	fieldTags:        2.0,
+	fieldSKU:         4.0,
+}
+
+// Bobot jenis kecocokan: kata persis lebih relevan daripada awalan atau typo.
+const (
+	exactMatchBoost  = 1.0
+	prefixMatchBoost = 0.8
+	fuzzyMatchBoost  = 0.6
+)
+
+// Parameter BM25.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type posting struct {
+	tf [numSearchFields]int // Frekuensi term per field
+}
+
+type productIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[int]*posting // term -> ID produk -> posting
+	docTerms map[int][]string            // ID produk -> term unik (untuk penghapusan)
+	docLen   map[int]int                 // ID produk -> jumlah token
+	totalLen int
+	vocab    []string // Semua term, terurut (untuk pencarian awalan)
+}
+
+// SearchHit adalah satu hasil pencarian beserta skor relevansinya.
+type SearchHit struct {
+	Score   float64 `json:"score"`
+	Product Product `json:"product"`
+}
+
+func newProductIndex() *productIndex {
+	return &productIndex{
+		postings: make(map[string]map[int]*posting),
+		docTerms: make(map[int][]string),
+		docLen:   make(map[int]int),
+	}
+}
+
+// rebuild membangun ulang indeks dari seluruh produk (dipakai saat data dimuat dari file).
+func (ix *productIndex) rebuild(list []Product) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.postings = make(map[string]map[int]*posting)
+	ix.docTerms = make(map[int][]string)
+	ix.docLen = make(map[int]int)
+	ix.totalLen = 0
+	ix.vocab = nil
+	for _, p := range list {
+		ix.addLocked(p)
+	}
+}
+
+// upsert menambahkan produk ke indeks, atau memperbarui entrinya jika sudah ada.
+func (ix *productIndex) upsert(p Product) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeLocked(p.ID)
+	ix.addLocked(p)
+}
+
+// remove menghapus produk dari indeks.
+func (ix *productIndex) remove(id int) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeLocked(id)
+}
+
+func (ix *productIndex) addLocked(p Product) {
+	fields := [numSearchFields][]string{
+		fieldName:        analyze(p.Name),
+		fieldDescription: analyze(p.Description),
+		fieldTags:        analyze(strings.Join(p.Tags, " ")),
+		fieldSKU:         skuTerms(p.SKU),
+	}
+	length := 0
+	var unique []string
+	for f, terms := range fields {
+		length += len(terms)
+		for _, term := range terms {
+			docs, ok := ix.postings[term]
+			if !ok {
+				docs = make(map[int]*posting)
+				ix.postings[term] = docs
+				ix.insertVocab(term)
+			}
+			pst, ok := docs[p.ID]
+			if !ok {
+				pst = &posting{}
+				docs[p.ID] = pst
+				unique = append(unique, term)
+			}
+			pst.tf[f]++
+		}
+	}
+	ix.docTerms[p.ID] = unique
+	ix.docLen[p.ID] = length
+	ix.totalLen += length
+}
+
+func (ix *productIndex) removeLocked(id int) {
+	terms, ok := ix.docTerms[id]
+	if !ok {
+		return
+	}
+	for _, term := range terms {
+		docs := ix.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(ix.postings, term)
+			ix.deleteVocab(term)
+		}
+	}
+	ix.totalLen -= ix.docLen[id]
+	delete(ix.docTerms, id)
+	delete(ix.docLen, id)
+}
+
+func (ix *productIndex) insertVocab(term string) {
+	i := sort.SearchStrings(ix.vocab, term)
+	ix.vocab = append(ix.vocab, "")
+	copy(ix.vocab[i+1:], ix.vocab[i:])
+	ix.vocab[i] = term
+}
+
+func (ix *productIndex) deleteVocab(term string) {
+	i := sort.SearchStrings(ix.vocab, term)
+	if i < len(ix.vocab) && ix.vocab[i] == term {
+		ix.vocab = append(ix.vocab[:i], ix.vocab[i+1:]...)
+	}
+}
+
+// search mencari produk yang cocok dengan query dan mengembalikan ID beserta skornya,
+// diurutkan dari yang paling relevan, serta jumlah semua produk yang cocok sebelum
+// dipotong 'limit'.
+func (ix *productIndex) search(query string, limit int) ([]scoredID, int) {
+	queryTerms := analyzeQuery(query)
+	if len(queryTerms) == 0 {
+		return nil, 0
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	numDocs := len(ix.docLen)
+	if numDocs == 0 {
+		return nil, 0
+	}
+	avgLen := float64(ix.totalLen) / float64(numDocs)
+
+	scores := make(map[int]float64)
+	matchedTerms := make(map[int]int)
+	for _, qt := range queryTerms {
+		// Untuk setiap term query, ambil skor terbaik per produk dari semua
+		// kandidat (persis, awalan, atau typo) agar ekspansi tidak dihitung ganda.
+		best := make(map[int]float64)
+		for term, boost := range ix.expandTerm(qt) {
+			docs := ix.postings[term]
+			idf := math.Log(1 + (float64(numDocs)-float64(len(docs))+0.5)/(float64(len(docs))+0.5))
+			for id, pst := range docs {
+				weightedTF := 0.0
+				for f := searchField(0); f < numSearchFields; f++ {
+					weightedTF += fieldWeights[f] * float64(pst.tf[f])
+				}
+				norm := 1 - bm25B + bm25B*float64(ix.docLen[id])/avgLen
+				s := boost * idf * weightedTF * (bm25K1 + 1) / (weightedTF + bm25K1*norm)
+				if s > best[id] {
+					best[id] = s
+				}
+			}
+		}
+		for id, s := range best {
+			scores[id] += s
+			matchedTerms[id]++
+		}
+	}
+
+	hits := make([]scoredID, 0, len(scores))
+	for id, s := range scores {
+		// Produk yang cocok dengan lebih banyak kata query mendapat skor lebih tinggi.
+		coverage := float64(matchedTerms[id]) / float64(len(queryTerms))
+		hits = append(hits, scoredID{id: id, score: s * coverage})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].id < hits[j].id
+	})
+	total := len(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, total
+}
+
+type scoredID struct {
+	id    int
+	score float64
+}
+
+// expandTerm mencari term di indeks yang cocok dengan term query: persis, sebagai awalan,
+// atau dengan jarak edit maksimal 2 (toleransi typo). Nilai map adalah bobot kecocokan.
+func (ix *productIndex) expandTerm(qt queryTerm) map[string]float64 {
+	out := make(map[string]float64)
+	if _, ok := ix.postings[qt.stem]; ok {
+		out[qt.stem] = exactMatchBoost
+	}
+
+	// Awalan: "lapt" cocok dengan "laptop". Memakai bentuk tanpa stemming agar
+	// kata yang belum selesai diketik tidak terpotong aneh.
+	if len(qt.raw) >= 2 {
+		for i := sort.SearchStrings(ix.vocab, qt.raw); i < len(ix.vocab); i++ {
+			term := ix.vocab[i]
+			if !strings.HasPrefix(term, qt.raw) {
+				break
+			}
+			if _, ok := out[term]; !ok {
+				out[term] = prefixMatchBoost
+			}
+		}
+	}
+
+	maxEdits := allowedEdits(qt.stem)
+	if maxEdits == 0 {
+		return out
+	}
+	for _, term := range ix.vocab {
+		if _, ok := out[term]; ok {
+			continue
+		}
+		if abs(len(term)-len(qt.stem)) > maxEdits {
+			continue
+		}
+		if d := boundedLevenshtein(qt.stem, term, maxEdits); d <= maxEdits {
+			out[term] = fuzzyMatchBoost / float64(d)
+		}
+	}
+	return out
+}
+
+// allowedEdits menentukan jumlah typo yang ditoleransi berdasarkan panjang kata.
+// Kata pendek tidak diberi toleransi agar hasil tidak terlalu melebar.
+func allowedEdits(term string) int {
+	n := len([]rune(term))
+	switch {
+	case n <= 3:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// boundedLevenshtein menghitung jarak edit antara a dan b, dan berhenti lebih awal
+// (mengembalikan max+1) begitu jaraknya pasti melebihi max.
+func boundedLevenshtein(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// --- Normalisasi Teks ---
+
+// diacriticReplacer menghapus tanda aksen yang umum (misal "café" -> "cafe").
+var diacriticReplacer = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ò", "o", "ó", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// tokenize memecah teks menjadi kata-kata huruf kecil tanpa tanda baca.
+func tokenize(text string) []string {
+	text = diacriticReplacer.Replace(strings.ToLower(text))
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// analyze menormalisasi teks menjadi term yang disimpan di indeks.
+func analyze(text string) []string {
+	tokens := tokenize(text)
+	for i, tok := range tokens {
+		tokens[i] = stem(tok)
+	}
+	return tokens
+}
+
+// skuTerms menghasilkan term untuk SKU: setiap segmen ("wbc", "pro", "01") ditambah
+// SKU utuh tanpa pemisah ("wbcpro01") agar pencarian kode lengkap tetap cocok.
+func skuTerms(sku string) []string {
+	tokens := tokenize(sku)
+	if len(tokens) > 1 {
+		tokens = append(tokens, strings.Join(tokens, ""))
+	}
+	return tokens
+}
+
+type queryTerm struct {
+	raw  string // Kata setelah normalisasi, sebelum stemming
+	stem string
+}
+
+func analyzeQuery(query string) []queryTerm {
+	tokens := tokenize(query)
+	terms := make([]queryTerm, 0, len(tokens))
+	for _, tok := range tokens {
+		terms = append(terms, queryTerm{raw: tok, stem: stem(tok)})
+	}
+	return terms
+}
+
+// stem melakukan stemming ringan untuk bahasa Indonesia dan Inggris. Tujuannya bukan
+// akurasi linguistik, melainkan menyamakan bentuk kata yang umum ("kabelnya" -> "kabel",
+// "keyboards" -> "keyboard"). Query dan data memakai fungsi yang sama, jadi hasilnya konsisten.
+func stem(word string) string {
+	if len([]rune(word)) <= 3 || !isAlpha(word) {
+		return word
+	}
+
+	// Indonesia: partikel dan kata ganti milik. Partikel hanya dipotong jika sisanya
+	// minimal 5 huruf, karena banyak kata dasar berakhiran "lah"/"kah" ("sekolah", "masalah").
+	for _, suffix := range []string{"lah", "kah", "pun", "nya", "ku", "mu"} {
+		minStem := 4
+		if suffix == "lah" || suffix == "kah" || suffix == "pun" {
+			minStem = 5
+		}
+		if trimmed, ok := strings.CutSuffix(word, suffix); ok && len(trimmed) >= minStem {
+			word = trimmed
+			break
+		}
+	}
+	// Indonesia: akhiran "-kan".
+	if trimmed, ok := strings.CutSuffix(word, "kan"); ok && len(trimmed) >= 4 {
+		return trimmed
+	}
+
+	// Inggris: bentuk jamak dan kata kerja.
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ing") && len(word) >= 6:
+		return trimFinalE(word[:len(word)-3])
+	case strings.HasSuffix(word, "ed") && len(word) >= 5 && !isDoubleVowel(word[len(word)-3:len(word)-1]):
+		return trimFinalE(word[:len(word)-2])
+	case strings.HasSuffix(word, "es") && len(word) >= 5 &&
+		strings.ContainsAny(word[len(word)-3:len(word)-2], "sxz"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		return trimFinalE(word[:len(word)-1])
+	}
+	return trimFinalE(word)
+}
+
+// trimFinalE menghapus huruf "e" di akhir kata agar "game", "games", dan "gaming"
+// menghasilkan stem yang sama ("gam").
+func trimFinalE(word string) string {
+	if len(word) >= 4 && strings.HasSuffix(word, "e") {
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// isDoubleVowel melaporkan apakah s adalah dua huruf vokal yang sama ("ee", "oo").
+// Kata seperti "speed" dan "feed" bukan bentuk lampau, jadi "-ed" tidak dipotong.
+func isDoubleVowel(s string) bool {
+	return len(s) == 2 && s[0] == s[1] && strings.ContainsRune("aeiou", rune(s[0]))
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// --- Handler Pencarian ---
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// searchProductsHandler menangani GET /api/products/search?q=...&limit=...
+func searchProductsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		log.Printf("LOG: Metode %s tidak diizinkan untuk /api/products/search.", r.Method)
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondWithError(w, http.StatusBadRequest, "Parameter 'q' tidak boleh kosong")
+		return
+	}
+	limit := defaultSearchLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Parameter 'limit' tidak valid")
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	// "total" adalah jumlah semua produk yang cocok, bukan hanya yang dikembalikan.
+	results, total := storeFromRequest(r).search(query, limit)
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"query":   query,
+		"total":   total,
+		"results": results,
+	})
+	log.Printf("LOG: Pencarian '%s' menemukan %d produk.", query, total)
+}
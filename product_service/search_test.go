@@ -0,0 +1,161 @@
+// mini-projects/product_service/search_test.go
+package product_service
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := []struct{ word, want string }{
+		// Indonesia: partikel, kata ganti milik, dan akhiran "-kan".
+		{"kabelnya", "kabel"},
+		{"murahlah", "murah"},
+		{"bukumu", "buku"},
+		{"gunakan", "guna"},
+		{"bersihkanlah", "bersih"},
+		// Sisa kata terlalu pendek: akhiran tidak dipotong.
+		{"tasnya", "tasnya"},
+		{"ilmu", "ilmu"},
+		{"makanan", "makanan"},
+		// Kata dasar yang kebetulan berakhiran partikel.
+		{"sekolah", "sekolah"},
+		{"masalah", "masalah"},
+		{"sekolahnya", "sekolah"},
+		// Inggris: jamak dan kata kerja.
+		{"keyboards", "keyboard"},
+		{"batteries", "battery"},
+		{"classes", "class"},
+		{"boxes", "box"},
+		{"charged", "charg"},
+		// "-ed" setelah vokal ganda bukan bentuk lampau.
+		{"speed", "speed"},
+		{"feed", "feed"},
+		{"speeds", "speed"},
+		{"charging", "charg"},
+		{"game", "gam"},
+		{"games", "gam"},
+		{"gaming", "gam"},
+		// Bukan bentuk jamak, atau terlalu pendek / bukan huruf semua.
+		{"glass", "glass"},
+		{"status", "status"},
+		{"bus", "bus"},
+		{"usb3", "usb3"},
+		{"wbc01", "wbc01"},
+	}
+	for _, tt := range tests {
+		if got := stem(tt.word); got != tt.want {
+			t.Errorf("stem(%q) = %q, ingin %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestBoundedLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"laptop", "laptop", 2, 0},
+		{"laptp", "laptop", 2, 1},
+		{"keybaord", "keyboard", 2, 2}, // Pertukaran huruf dihitung dua edit
+		{"kitten", "sitting", 3, 3},
+		{"", "abc", 5, 3},
+		{"abc", "", 5, 3},
+		{"kafé", "kafe", 1, 1}, // Dihitung per rune, bukan per byte
+		// Melebihi batas: hasilnya max+1, bukan jarak sebenarnya.
+		{"kitten", "sitting", 2, 3},
+		{"abc", "", 1, 2},
+		{"aaaa", "bbbbbbbb", 1, 2},
+	}
+	for _, tt := range tests {
+		if got := boundedLevenshtein(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("boundedLevenshtein(%q, %q, %d) = %d, ingin %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestAllowedEdits(t *testing.T) {
+	tests := []struct {
+		term string
+		want int
+	}{
+		{"usb", 0},
+		{"kafé", 1},
+		{"kabel", 1},
+		{"laptop", 2},
+	}
+	for _, tt := range tests {
+		if got := allowedEdits(tt.term); got != tt.want {
+			t.Errorf("allowedEdits(%q) = %d, ingin %d", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestExpandTerm(t *testing.T) {
+	ix := newProductIndex()
+	ix.rebuild([]Product{
+		{ID: 1, Name: "Laptop Gaming"},
+		{ID: 2, Name: "Lapisan Kabel"},
+		{ID: 3, Name: "Keyboard Mekanik"},
+	})
+
+	tests := []struct {
+		query string
+		want  map[string]float64
+	}{
+		// Persis: tidak ditimpa oleh kecocokan awalan untuk term yang sama.
+		{"laptop", map[string]float64{"laptop": exactMatchBoost}},
+		{"games", map[string]float64{"gam": exactMatchBoost}},
+		// Awalan memakai kata sebelum stemming, minimal dua huruf.
+		{"lapt", map[string]float64{"laptop": prefixMatchBoost}},
+		{"la", map[string]float64{"laptop": prefixMatchBoost, "lapisan": prefixMatchBoost}},
+		{"l", map[string]float64{}},
+		// Typo: bobot dibagi jarak edit.
+		{"kabl", map[string]float64{"kabel": fuzzyMatchBoost}},
+		{"keybord", map[string]float64{"keyboard": fuzzyMatchBoost}},
+		{"keybaord", map[string]float64{"keyboard": fuzzyMatchBoost / 2}},
+		{"mekanis", map[string]float64{"mekanik": fuzzyMatchBoost}},
+		// Kata pendek tidak diberi toleransi typo; jarak di atas batas tidak cocok.
+		{"kbl", map[string]float64{}},
+		{"kabelx", map[string]float64{"kabel": fuzzyMatchBoost}},
+		{"kbel", map[string]float64{"kabel": fuzzyMatchBoost}},
+		{"kble", map[string]float64{}},
+		{"monitor", map[string]float64{}},
+	}
+	for _, tt := range tests {
+		terms := analyzeQuery(tt.query)
+		if len(terms) != 1 {
+			t.Fatalf("query %q menghasilkan %d term", tt.query, len(terms))
+		}
+		if got := ix.expandTerm(terms[0]); !maps.Equal(got, tt.want) {
+			t.Errorf("expandTerm(%q) = %v, ingin %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchTotalCountsAllMatches(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	for i := 0; i < 5; i++ {
+		doRequest(handler, "POST", "/api/products", fmt.Sprintf(`{"name": "Kabel Data %d", "price": 1000}`, i), nil)
+	}
+
+	rec := doRequest(handler, "GET", "/api/products/search?q=kabel&limit=2", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Total   int         `json:"total"`
+		Results []SearchHit `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	// "total" dipakai untuk paging, jadi tidak boleh dibatasi oleh limit.
+	if len(resp.Results) != 2 || resp.Total != 5 {
+		t.Errorf("hasil = %d, total = %d; ingin 2 hasil dari total 5", len(resp.Results), resp.Total)
+	}
+}
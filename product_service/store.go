@@ -0,0 +1,204 @@
+// mini-projects/product_service/store.go
+package product_service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// productStore menyimpan katalog produk milik satu tenant: data di memori, urutan ID,
+// file JSON tempat data disimpan, waktu perubahan terakhir, dan indeks pencarian.
+// Semua akses ke data produk harus melalui method store agar aman dipakai bersamaan.
+type productStore struct {
+	mu           sync.RWMutex
+	filePath     string
+	products     []Product
+	nextID       int
+	lastModified time.Time
+	index        *productIndex
+}
+
+func newProductStore(filePath string) *productStore {
+	return &productStore{
+		filePath: filePath,
+		products: []Product{},
+		nextID:   1,
+		index:    newProductIndex(),
+	}
+}
+
+// nextIDLocked menemukan ID berikutnya berdasarkan ID terbesar yang ada.
+func (s *productStore) nextIDLocked() int {
+	currentMaxID := 0
+	for _, p := range s.products {
+		if p.ID > currentMaxID {
+			currentMaxID = p.ID
+		}
+	}
+	return currentMaxID + 1
+}
+
+// load memuat data produk dari file JSON milik store.
+func (s *productStore) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.products = []Product{}
+	defer func() {
+		s.nextID = s.nextIDLocked()
+		s.index.rebuild(s.products)
+	}()
+
+	info, err := os.Stat(s.filePath)
+	if os.IsNotExist(err) {
+		log.Printf("LOG: File '%s' tidak ditemukan. Membuat database produk kosong.", s.filePath)
+		s.lastModified = time.Now()
+		return nil
+	}
+	if err == nil {
+		// Waktu modifikasi file menjadi titik awal Last-Modified.
+		s.lastModified = info.ModTime()
+	}
+	data, err := ioutil.ReadFile(s.filePath)
+	if err != nil {
+		return fmt.Errorf("gagal membaca file JSON: %w", err)
+	}
+	if len(data) == 0 {
+		log.Printf("LOG: File '%s' kosong. Membuat database produk kosong.", s.filePath)
+		return nil
+	}
+	if err := json.Unmarshal(data, &s.products); err != nil {
+		return fmt.Errorf("gagal mendekode JSON dari file: %w", err)
+	}
+	log.Printf("LOG: Data produk berhasil dimuat dari '%s'.", s.filePath)
+	return nil
+}
+
+// saveLocked menyimpan data produk ke file JSON. Pemanggil harus memegang s.mu.
+func (s *productStore) saveLocked() error {
+	// Data di memori sudah berubah saat fungsi ini dipanggil, meskipun penulisan file gagal.
+	s.lastModified = time.Now()
+	data, err := json.MarshalIndent(s.products, "", "  ")
+	if err != nil {
+		return fmt.Errorf("gagal mengkodekan data ke JSON: %w", err)
+	}
+	if err := os.WriteFile(s.filePath, data, 0644); err != nil {
+		return fmt.Errorf("gagal menulis data JSON ke file: %w", err)
+	}
+	log.Printf("LOG: Data produk berhasil disimpan ke '%s'.", s.filePath)
+	return nil
+}
+
+// modTime mengembalikan waktu perubahan terakhir data produk.
+func (s *productStore) modTime() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastModified
+}
+
+// list mengembalikan salinan semua produk.
+func (s *productStore) list() []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Product, len(s.products))
+	copy(out, s.products)
+	return out
+}
+
+// get mencari produk berdasarkan ID.
+func (s *productStore) get(id int) (Product, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.indexOfLocked(id); i >= 0 {
+		return s.products[i], true
+	}
+	return Product{}, false
+}
+
+func (s *productStore) indexOfLocked(id int) int {
+	for i, p := range s.products {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// create menambahkan produk baru dengan ID berikutnya dari urutan milik store ini.
+func (s *productStore) create(p Product) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.ID = s.nextID
+	s.nextID++
+	s.products = append(s.products, p)
+	s.index.upsert(p)
+	return p, s.saveLocked()
+}
+
+// update memperbarui produk. Nama, harga, deskripsi, tag, dan SKU hanya diganti jika
+// diisi; stok selalu diganti. Nilai bool kedua false jika produk tidak ditemukan.
+func (s *productStore) update(id int, patch Product) (Product, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOfLocked(id)
+	if i < 0 {
+		return Product{}, false, nil
+	}
+	p := &s.products[i]
+	if patch.Name != "" {
+		p.Name = patch.Name
+	}
+	if patch.Price != 0 {
+		p.Price = patch.Price
+	}
+	p.Stock = patch.Stock
+	if patch.Description != "" {
+		p.Description = patch.Description
+	}
+	if patch.Tags != nil {
+		p.Tags = patch.Tags
+	}
+	if patch.SKU != "" {
+		p.SKU = patch.SKU
+	}
+	s.index.upsert(*p)
+	return *p, true, s.saveLocked()
+}
+
+// delete menghapus produk. Nilai bool false jika produk tidak ditemukan.
+func (s *productStore) delete(id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOfLocked(id)
+	if i < 0 {
+		return false, nil
+	}
+	newProducts := make([]Product, 0, len(s.products)-1)
+	newProducts = append(newProducts, s.products[:i]...)
+	newProducts = append(newProducts, s.products[i+1:]...)
+	s.products = newProducts
+	s.index.remove(id)
+	return true, s.saveLocked()
+}
+
+// search menjalankan pencarian full-text di katalog store ini dan mengembalikan paling
+// banyak 'limit' hasil beserta jumlah semua produk yang cocok.
+func (s *productStore) search(query string, limit int) ([]SearchHit, int) {
+	ranked, total := s.index.search(query, limit)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hits := make([]SearchHit, 0, len(ranked))
+	for _, hit := range ranked {
+		if i := s.indexOfLocked(hit.id); i >= 0 {
+			hits = append(hits, SearchHit{Score: math.Round(hit.score*1000) / 1000, Product: s.products[i]})
+		}
+	}
+	return hits, total
+}
@@ -0,0 +1,435 @@
+// mini-projects/product_service/tenant.go
+package product_service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Multi-Tenant ---
+//
+// Setiap tenant (toko) punya katalog, urutan ID, dan file JSON sendiri. Tenant ditentukan
+// dari API key (header X-API-Key atau Authorization: Bearer). Header X-Tenant-ID hanya
+// boleh menyebut tenant pemilik key tersebut; tanpa key hanya tenant "default" yang bisa
+// dipakai. Tenant default tetap menyimpan datanya di products.json agar data lama tidak berubah.
+
+const (
+	defaultTenantID     = "default"
+	tenantsFileName     = "tenants.json"
+	tenantHeader        = "X-Tenant-ID"
+	apiKeyHeader        = "X-API-Key"
+	adminKeyHeader      = "X-Admin-Key"
+	adminKeyEnvVariable = "PRODUCT_API_ADMIN_KEY"
+)
+
+// tenantIDPattern membatasi ID tenant agar aman dipakai sebagai bagian nama file.
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Tenant adalah satu toko yang memakai server produk bersama.
+type Tenant struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	APIKeys   []string  `json:"api_keys,omitempty"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// tenantRegistry menyimpan daftar tenant (di tenants.json) dan store produk setiap tenant.
+type tenantRegistry struct {
+	mu          sync.RWMutex
+	dataDir     string
+	defaultFile string // File katalog tenant default; kosong berarti <dataDir>/products.json
+	tenants     map[string]*Tenant
+	byKey       map[string]string        // API key -> ID tenant
+	stores      map[string]*productStore // Dimuat saat pertama kali dipakai
+}
+
+func newTenantRegistry(dataDir string) *tenantRegistry {
+	return &tenantRegistry{
+		dataDir: dataDir,
+		tenants: make(map[string]*Tenant),
+		byKey:   make(map[string]string),
+		stores:  make(map[string]*productStore),
+	}
+}
+
+func (reg *tenantRegistry) registryPath() string {
+	return filepath.Join(reg.dataDir, tenantsFileName)
+}
+
+// productsFilePath mengembalikan lokasi file JSON katalog milik tenant.
+func (reg *tenantRegistry) productsFilePath(tenantID string) string {
+	if tenantID == defaultTenantID {
+		if reg.defaultFile != "" {
+			return reg.defaultFile
+		}
+		return filepath.Join(reg.dataDir, jsonFilePath)
+	}
+	return filepath.Join(reg.dataDir, "products_"+tenantID+".json")
+}
+
+// load memuat daftar tenant dari tenants.json. Tenant default selalu tersedia.
+func (reg *tenantRegistry) load() error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.tenants = make(map[string]*Tenant)
+	reg.byKey = make(map[string]string)
+	defer reg.ensureDefaultLocked()
+
+	data, err := ioutil.ReadFile(reg.registryPath())
+	if os.IsNotExist(err) {
+		log.Printf("LOG: File '%s' tidak ditemukan. Hanya tenant default yang aktif.", reg.registryPath())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("gagal membaca file tenant: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var list []*Tenant
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("gagal mendekode JSON tenant: %w", err)
+	}
+	// ID tenant menjadi bagian nama file katalog, jadi ID dari file diperiksa sama
+	// seperti saat tenant dibuat. Tenant dengan ID tidak valid tidak dimuat.
+	var invalid []string
+	for _, t := range list {
+		if !tenantIDPattern.MatchString(t.ID) {
+			invalid = append(invalid, fmt.Sprintf("'%s'", t.ID))
+			continue
+		}
+		reg.tenants[t.ID] = t
+		for _, key := range t.APIKeys {
+			reg.byKey[key] = t.ID
+		}
+	}
+	log.Printf("LOG: %d tenant berhasil dimuat dari '%s'.", len(reg.tenants), reg.registryPath())
+	if len(invalid) > 0 {
+		return fmt.Errorf("tenant dengan ID tidak valid di '%s' dilewati: %s", reg.registryPath(), strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+func (reg *tenantRegistry) ensureDefaultLocked() {
+	if _, ok := reg.tenants[defaultTenantID]; !ok {
+		reg.tenants[defaultTenantID] = &Tenant{ID: defaultTenantID, Name: "Default", CreatedAt: time.Now()}
+	}
+}
+
+// saveLocked menyimpan daftar tenant ke tenants.json. Pemanggil harus memegang reg.mu.
+func (reg *tenantRegistry) saveLocked() error {
+	data, err := json.MarshalIndent(reg.listLocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("gagal mengkodekan data tenant ke JSON: %w", err)
+	}
+	// File berisi API key, jadi hanya pemilik yang boleh membacanya.
+	if err := os.WriteFile(reg.registryPath(), data, 0600); err != nil {
+		return fmt.Errorf("gagal menulis data tenant ke file: %w", err)
+	}
+	log.Printf("LOG: Data tenant berhasil disimpan ke '%s'.", reg.registryPath())
+	return nil
+}
+
+func (reg *tenantRegistry) listLocked() []Tenant {
+	list := make([]Tenant, 0, len(reg.tenants))
+	for _, t := range reg.tenants {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (reg *tenantRegistry) list() []Tenant {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.listLocked()
+}
+
+func (reg *tenantRegistry) get(id string) (Tenant, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	t, ok := reg.tenants[id]
+	if !ok {
+		return Tenant{}, false
+	}
+	return *t, true
+}
+
+// errTenantExists dan errInvalidTenantID dikembalikan oleh create.
+var (
+	errTenantExists    = fmt.Errorf("tenant sudah ada")
+	errInvalidTenantID = fmt.Errorf("ID tenant hanya boleh berisi huruf kecil, angka, '-' atau '_' (maks. 63 karakter)")
+)
+
+// create membuat tenant baru beserta satu API key acak.
+func (reg *tenantRegistry) create(id, name string) (Tenant, error) {
+	if !tenantIDPattern.MatchString(id) {
+		return Tenant{}, errInvalidTenantID
+	}
+	key, err := generateKey()
+	if err != nil {
+		return Tenant{}, err
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.tenants[id]; ok {
+		return Tenant{}, errTenantExists
+	}
+	if name == "" {
+		name = id
+	}
+	t := &Tenant{ID: id, Name: name, APIKeys: []string{key}, CreatedAt: time.Now()}
+	reg.tenants[id] = t
+	reg.byKey[key] = id
+	if err := reg.saveLocked(); err != nil {
+		delete(reg.tenants, id)
+		delete(reg.byKey, key)
+		return Tenant{}, err
+	}
+	return *t, nil
+}
+
+// setDisabled menonaktifkan atau mengaktifkan kembali tenant.
+func (reg *tenantRegistry) setDisabled(id string, disabled bool) (Tenant, bool, error) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	t, ok := reg.tenants[id]
+	if !ok {
+		return Tenant{}, false, nil
+	}
+	previous := t.Disabled
+	t.Disabled = disabled
+	if err := reg.saveLocked(); err != nil {
+		t.Disabled = previous
+		return Tenant{}, true, err
+	}
+	return *t, true, nil
+}
+
+// tenantForKey mencari ID tenant pemilik API key.
+func (reg *tenantRegistry) tenantForKey(key string) (string, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	id, ok := reg.byKey[key]
+	return id, ok
+}
+
+// storeFor mengembalikan store produk tenant, memuatnya dari file jika belum dimuat.
+func (reg *tenantRegistry) storeFor(tenantID string) (*productStore, error) {
+	reg.mu.RLock()
+	store, ok := reg.stores[tenantID]
+	reg.mu.RUnlock()
+	if ok {
+		return store, nil
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if store, ok := reg.stores[tenantID]; ok {
+		return store, nil
+	}
+	store = newProductStore(reg.productsFilePath(tenantID))
+	if err := store.load(); err != nil {
+		return nil, fmt.Errorf("gagal memuat katalog tenant '%s': %w", tenantID, err)
+	}
+	reg.stores[tenantID] = store
+	return store, nil
+}
+
+func generateKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("gagal membuat API key: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// --- Penentuan Tenant per Permintaan ---
+
+type storeContextKey struct{}
+
+// storeFromRequest mengambil store produk tenant yang sudah ditentukan oleh tenantMiddleware.
+func storeFromRequest(r *http.Request) *productStore {
+	store, _ := r.Context().Value(storeContextKey{}).(*productStore)
+	return store
+}
+
+func withStore(r *http.Request, store *productStore) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), storeContextKey{}, store))
+}
+
+// requestAPIKey mengambil API key dari header X-API-Key atau Authorization: Bearer.
+func requestAPIKey(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get(apiKeyHeader)); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// tenantMiddleware menentukan tenant untuk setiap permintaan dan menyisipkan store
+// produknya ke context. Handler produk hanya melihat store ini, sehingga produk milik
+// tenant lain tidak mungkin dikembalikan.
+func tenantMiddleware(reg *tenantRegistry, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID := defaultTenantID
+		headerTenant := strings.TrimSpace(r.Header.Get(tenantHeader))
+
+		if key := requestAPIKey(r); key != "" {
+			id, ok := reg.tenantForKey(key)
+			if !ok {
+				respondWithError(w, http.StatusUnauthorized, "API key tidak valid")
+				return
+			}
+			if headerTenant != "" && headerTenant != id {
+				respondWithError(w, http.StatusForbidden, "API key bukan milik tenant yang diminta")
+				return
+			}
+			tenantID = id
+		} else if headerTenant != "" && headerTenant != defaultTenantID {
+			// X-Tenant-ID saja bukan bukti hak akses: selain tenant default, katalog tenant
+			// hanya bisa dibaca dan diubah dengan API key miliknya. Ditolak sebelum tenant
+			// dicari, agar jawabannya sama untuk tenant yang ada maupun tidak.
+			respondWithError(w, http.StatusUnauthorized, "API key wajib untuk tenant yang diminta")
+			return
+		}
+
+		tenant, ok := reg.get(tenantID)
+		if !ok {
+			respondWithError(w, http.StatusNotFound, "Tenant tidak ditemukan")
+			return
+		}
+		if tenant.Disabled {
+			respondWithError(w, http.StatusForbidden, "Tenant dinonaktifkan")
+			return
+		}
+		store, err := reg.storeFor(tenantID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Gagal memuat katalog tenant")
+			log.Printf("LOG: %v", err)
+			return
+		}
+		next.ServeHTTP(w, withStore(r, store))
+	})
+}
+
+// --- Endpoint Admin Tenant ---
+
+// adminMiddleware memastikan permintaan membawa header X-Admin-Key yang benar.
+func adminMiddleware(adminKey string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := r.Header.Get(adminKeyHeader)
+		if adminKey == "" || subtle.ConstantTimeCompare([]byte(given), []byte(adminKey)) != 1 {
+			respondWithError(w, http.StatusUnauthorized, "Admin key tidak valid")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tenantsAdminHandler menangani /api/admin/tenants (GET daftar, POST buat tenant).
+func tenantsAdminHandler(reg *tenantRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			respondWithJSON(w, http.StatusOK, reg.list())
+		case "POST":
+			var req struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Format JSON permintaan tidak valid")
+				return
+			}
+			t, err := reg.create(strings.TrimSpace(req.ID), strings.TrimSpace(req.Name))
+			switch {
+			case err == errInvalidTenantID:
+				respondWithError(w, http.StatusBadRequest, err.Error())
+			case err == errTenantExists:
+				respondWithError(w, http.StatusConflict, "Tenant dengan ID tersebut sudah ada")
+			case err != nil:
+				respondWithError(w, http.StatusInternalServerError, "Gagal menyimpan data tenant")
+				log.Printf("Error creating tenant '%s': %v", req.ID, err)
+			default:
+				respondWithJSON(w, http.StatusCreated, t)
+				log.Printf("LOG: Tenant baru dibuat: '%s'.", t.ID)
+			}
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		}
+	}
+}
+
+// tenantByIDAdminHandler menangani /api/admin/tenants/{id},
+// /api/admin/tenants/{id}/disable, dan /api/admin/tenants/{id}/enable.
+func tenantByIDAdminHandler(reg *tenantRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/api/admin/tenants/")
+		id, action, _ := strings.Cut(rest, "/")
+
+		switch {
+		case action == "" && r.Method == "GET":
+			t, ok := reg.get(id)
+			if !ok {
+				respondWithError(w, http.StatusNotFound, "Tenant tidak ditemukan")
+				return
+			}
+			respondWithJSON(w, http.StatusOK, t)
+		case (action == "disable" || action == "enable") && r.Method == "POST":
+			if id == defaultTenantID && action == "disable" {
+				respondWithError(w, http.StatusBadRequest, "Tenant default tidak bisa dinonaktifkan")
+				return
+			}
+			t, ok, err := reg.setDisabled(id, action == "disable")
+			if !ok {
+				respondWithError(w, http.StatusNotFound, "Tenant tidak ditemukan")
+				return
+			}
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Gagal menyimpan data tenant")
+				log.Printf("Error updating tenant '%s': %v", id, err)
+				return
+			}
+			respondWithJSON(w, http.StatusOK, t)
+			log.Printf("LOG: Tenant '%s' di-%s.", id, action)
+		case action == "" || action == "disable" || action == "enable":
+			respondWithError(w, http.StatusMethodNotAllowed, "Metode tidak diizinkan")
+		default:
+			respondWithError(w, http.StatusNotFound, "Endpoint tidak ditemukan")
+		}
+	}
+}
+
+// resolveAdminKey mengambil admin key dari environment variable, atau membuat key acak
+// yang ditampilkan sekali saat server dimulai.
+func resolveAdminKey() (key string, generated bool) {
+	if key := strings.TrimSpace(os.Getenv(adminKeyEnvVariable)); key != "" {
+		return key, false
+	}
+	key, err := generateKey()
+	if err != nil {
+		log.Printf("LOG: %v. Endpoint admin tidak aktif.", err)
+		return "", false
+	}
+	return key, true
+}
@@ -0,0 +1,121 @@
+// mini-projects/product_service/tenant_test.go
+package product_service
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createTestTenant membuat tenant lewat endpoint admin dan mengembalikan API key-nya.
+func createTestTenant(t *testing.T, handler http.Handler, id string) string {
+	t.Helper()
+	rec := doRequest(handler, "POST", "/api/admin/tenants", `{"id": "`+id+`"}`, map[string]string{adminKeyHeader: testAdminKey})
+	var tenant Tenant
+	if err := json.Unmarshal(rec.Body.Bytes(), &tenant); err != nil || rec.Code != http.StatusCreated || len(tenant.APIKeys) != 1 {
+		t.Fatalf("gagal membuat tenant %s: %d %s", id, rec.Code, rec.Body.String())
+	}
+	return tenant.APIKeys[0]
+}
+
+func TestTenantCrossAccessDenied(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	keyA := createTestTenant(t, handler, "toko-a")
+	keyB := createTestTenant(t, handler, "toko-b")
+	asA := map[string]string{apiKeyHeader: keyA}
+	if rec := doRequest(handler, "POST", "/api/products", `{"name": "Teh Hijau", "price": 30000}`, asA); rec.Code != http.StatusCreated {
+		t.Fatalf("toko-a gagal membuat produk: %d %s", rec.Code, rec.Body.String())
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		headers      map[string]string
+		want         int
+	}{
+		// X-Tenant-ID tanpa API key tidak memberi akses ke katalog tenant lain.
+		{"baca lewat header saja", "GET", "/api/products", "", map[string]string{tenantHeader: "toko-a"}, http.StatusUnauthorized},
+		{"tulis lewat header saja", "POST", "/api/products", `{"name": "Palsu", "price": 1}`, map[string]string{tenantHeader: "toko-a"}, http.StatusUnauthorized},
+		{"hapus lewat header saja", "DELETE", "/api/products/1", "", map[string]string{tenantHeader: "toko-a"}, http.StatusUnauthorized},
+		{"bearer tanpa key", "PUT", "/api/products/1", `{"price": 1}`, map[string]string{"Authorization": "Bearer ", tenantHeader: "toko-a"}, http.StatusUnauthorized},
+		// Key tenant lain ditolak jika header menyebut tenant berbeda.
+		{"key toko-b untuk toko-a", "GET", "/api/products/1", "", map[string]string{apiKeyHeader: keyB, tenantHeader: "toko-a"}, http.StatusForbidden},
+		{"key toko-b ubah toko-a", "PUT", "/api/products/1", `{"price": 1}`, map[string]string{apiKeyHeader: keyB, tenantHeader: "toko-a"}, http.StatusForbidden},
+		// Dengan key sendiri, ID produk toko-a tidak ada di katalog toko-b.
+		{"toko-b baca ID toko-a", "GET", "/api/products/1", "", map[string]string{apiKeyHeader: keyB}, http.StatusNotFound},
+		{"toko-b hapus ID toko-a", "DELETE", "/api/products/1", "", map[string]string{apiKeyHeader: keyB}, http.StatusNotFound},
+		// Tenant default tetap bisa dipakai tanpa key.
+		{"default tanpa key", "GET", "/api/products", "", map[string]string{tenantHeader: defaultTenantID}, http.StatusOK},
+		{"toko-a dengan key sendiri", "GET", "/api/products/1", "", map[string]string{apiKeyHeader: keyA, tenantHeader: "toko-a"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := doRequest(handler, tt.method, tt.path, tt.body, tt.headers); rec.Code != tt.want {
+			t.Errorf("%s: status %d, ingin %d (%s)", tt.name, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+
+	rec := doRequest(handler, "GET", "/api/products/1", "", asA)
+	var p Product
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p.Name != "Teh Hijau" || p.Price != 30000 {
+		t.Errorf("produk toko-a berubah oleh tenant lain: %s", rec.Body.String())
+	}
+}
+
+func TestTenantUnknownAndExistingLookAlikeWithoutKey(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	createTestTenant(t, handler, "toko-ada")
+	// Tanpa API key, tenant yang ada dan yang tidak ada harus dijawab sama, agar ID
+	// tenant tidak bisa ditebak satu per satu.
+	for _, id := range []string{"toko-ada", "toko-tidak-ada"} {
+		rec := doRequest(handler, "GET", "/api/products", "", map[string]string{tenantHeader: id})
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("tenant %s tanpa key: status %d, ingin 401", id, rec.Code)
+		}
+	}
+}
+
+func TestTenantRegistryRejectsInvalidIDs(t *testing.T) {
+	for _, id := range []string{"", "../x", "Toko", "a/b", "-awal", strings.Repeat("a", 64)} {
+		if tenantIDPattern.MatchString(id) {
+			t.Errorf("ID %q seharusnya tidak valid", id)
+		}
+	}
+
+	dir := t.TempDir()
+	list := `[{"id": "toko-a", "api_keys": ["kunci-a"]}, {"id": "x/../../luar", "api_keys": ["kunci-jahat"]}, {"id": "a/b"}]`
+	if err := os.WriteFile(filepath.Join(dir, tenantsFileName), []byte(list), 0600); err != nil {
+		t.Fatal(err)
+	}
+	reg := newTenantRegistry(dir)
+	err := reg.load()
+	if err == nil || !strings.Contains(err.Error(), "x/../../luar") {
+		t.Fatalf("ID tidak valid seharusnya dilaporkan, error = %v", err)
+	}
+	if _, ok := reg.get("toko-a"); !ok {
+		t.Error("tenant valid tidak dimuat")
+	}
+	if _, ok := reg.get(defaultTenantID); !ok {
+		t.Error("tenant default tidak tersedia")
+	}
+	for _, id := range []string{"x/../../luar", "a/b"} {
+		if _, ok := reg.get(id); ok {
+			t.Errorf("tenant %q dengan ID tidak valid ikut dimuat", id)
+		}
+	}
+	if _, ok := reg.tenantForKey("kunci-jahat"); ok {
+		t.Error("API key milik tenant tidak valid masih berlaku")
+	}
+
+	// Lewat HTTP, key tenant tidak valid ditolak dan tidak ada file di luar DataDir.
+	handler := newAPIHandler(reg, testAdminKey)
+	if rec := doRequest(handler, "POST", "/api/products", `{"name": "x", "price": 1}`, map[string]string{apiKeyHeader: "kunci-jahat"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("key tenant tidak valid: status %d, ingin 401", rec.Code)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "luar.json")); !os.IsNotExist(err) {
+		t.Errorf("file katalog dibuat di luar DataDir: %v", err)
+	}
+}